@@ -0,0 +1,281 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AddressRequest struct {
+	UserID            int64  `json:"user_id"`
+	Name              string `json:"name"`
+	Line1             string `json:"line1"`
+	Line2             string `json:"line2"`
+	City              string `json:"city"`
+	Region            string `json:"region"`
+	PostalCode        string `json:"postal_code"`
+	Country           string `json:"country"`
+	Phone             string `json:"phone"`
+	IsDefaultBilling  bool   `json:"is_default_billing"`
+	IsDefaultShipping bool   `json:"is_default_shipping"`
+}
+
+type DeleteAddressRequest struct {
+	UserID    int64 `json:"user_id"`
+	AddressID int64 `json:"address_id"`
+}
+
+type SetDefaultAddressRequest struct {
+	UserID    int64  `json:"user_id"`
+	AddressID int64  `json:"address_id"`
+	Type      string `json:"type"`
+}
+
+type AddressResponse struct {
+	ID                int64     `json:"id"`
+	UserID            int64     `json:"user_id"`
+	Name              string    `json:"name"`
+	Line1             string    `json:"line1"`
+	Line2             string    `json:"line2"`
+	City              string    `json:"city"`
+	Region            string    `json:"region"`
+	PostalCode        string    `json:"postal_code"`
+	Country           string    `json:"country"`
+	Phone             string    `json:"phone"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// GetAddressesHandler - List a user's address book
+func GetAddressesHandler(repo infrastructure.AddressRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "User ID required", http.StatusBadRequest)
+			return
+		}
+
+		uid, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		addresses, err := repo.GetAddressesByUserID(uid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]AddressResponse, 0, len(addresses))
+		for _, address := range addresses {
+			response = append(response, buildAddressResponse(address))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// CreateAddressHandler - Add an address to a user's address book
+func CreateAddressHandler(repo infrastructure.AddressRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req AddressRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		address, err := addressFromRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		address.IsDefaultBilling = req.IsDefaultBilling
+		address.IsDefaultShipping = req.IsDefaultShipping
+
+		created, err := repo.CreateAddress(address)
+		if err != nil {
+			writeAddressError(w, err)
+			return
+		}
+
+		writeAddress(w, http.StatusCreated, created)
+	}
+}
+
+// UpdateAddressHandler - Change an address in a user's address book
+func UpdateAddressHandler(repo infrastructure.AddressRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		addressID := r.URL.Query().Get("id")
+		if addressID == "" {
+			http.Error(w, "Address ID required", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(addressID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid address ID", http.StatusBadRequest)
+			return
+		}
+
+		var req AddressRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		address, err := addressFromRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		address.ID = id
+
+		updated, err := repo.UpdateAddress(address)
+		if err != nil {
+			writeAddressError(w, err)
+			return
+		}
+
+		writeAddress(w, http.StatusOK, updated)
+	}
+}
+
+// DeleteAddressHandler - Remove an address from a user's address book
+func DeleteAddressHandler(repo infrastructure.AddressRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req DeleteAddressRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.UserID == 0 || req.AddressID == 0 {
+			http.Error(w, "User ID and address ID required", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.DeleteAddress(req.UserID, req.AddressID); err != nil {
+			writeAddressError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetDefaultAddressHandler - Make an address the user's default billing or
+// shipping address
+func SetDefaultAddressHandler(repo infrastructure.AddressRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SetDefaultAddressRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		addressType := domain.AddressType(req.Type)
+		if req.UserID == 0 || req.AddressID == 0 || !addressType.IsValid() {
+			http.Error(w, "User ID, address ID and a type of billing or shipping required", http.StatusBadRequest)
+			return
+		}
+
+		address, err := repo.SetDefaultAddress(req.UserID, req.AddressID, addressType)
+		if err != nil {
+			writeAddressError(w, err)
+			return
+		}
+
+		writeAddress(w, http.StatusOK, address)
+	}
+}
+
+// Helper function to validate an address request
+func addressFromRequest(req AddressRequest) (*domain.Address, error) {
+	if req.UserID == 0 {
+		return nil, errors.New("user ID required")
+	}
+
+	address := &domain.Address{
+		UserID:     req.UserID,
+		Name:       req.Name,
+		Line1:      req.Line1,
+		Line2:      req.Line2,
+		City:       req.City,
+		Region:     req.Region,
+		PostalCode: req.PostalCode,
+		Country:    req.Country,
+		Phone:      req.Phone,
+	}
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// Helper function to write an address error with a matching status
+func writeAddressError(w http.ResponseWriter, err error) {
+	if errors.Is(err, infrastructure.ErrAddressNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// Helper function to write an address as JSON
+func writeAddress(w http.ResponseWriter, status int, address *domain.Address) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(buildAddressResponse(address))
+}
+
+// Helper function to build an address response
+func buildAddressResponse(address *domain.Address) AddressResponse {
+	return AddressResponse{
+		ID:                address.ID,
+		UserID:            address.UserID,
+		Name:              address.Name,
+		Line1:             address.Line1,
+		Line2:             address.Line2,
+		City:              address.City,
+		Region:            address.Region,
+		PostalCode:        address.PostalCode,
+		Country:           address.Country,
+		Phone:             address.Phone,
+		IsDefaultBilling:  address.IsDefaultBilling,
+		IsDefaultShipping: address.IsDefaultShipping,
+		CreatedAt:         address.CreatedAt,
+	}
+}
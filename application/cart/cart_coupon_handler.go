@@ -0,0 +1,133 @@
+package application
+
+import (
+	etag "ecommerce-go/application/etag"
+	pricing "ecommerce-go/application/pricing"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"net/http"
+)
+
+// ApplyCouponHandler - Apply a coupon code to the user's or guest's cart
+func ApplyCouponHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CartCouponRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		code := domain.NormalizeCouponCode(req.Code)
+		if code == "" {
+			http.Error(w, "Coupon code required", http.StatusBadRequest)
+			return
+		}
+
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		// Get the user's or guest's cart
+		cart, err := findCart(repo, guests, r, req.UserID)
+		if err != nil {
+			writeFindCartError(w, err)
+			return
+		}
+
+		promotions, err := promotionRepo.CartPromotions(cart)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Only keep the coupon if it applies to the cart as it is now
+		cart.CouponCode = code
+		if _, _, err := couponRepo.CartDiscount(cart, promotions.LineDiscounts); err != nil {
+			if isCouponRejection(err) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := repo.SetCartCoupon(cart.ID, code, version); err != nil {
+			writeCartChangeError(w, err)
+			return
+		}
+
+		cart, err = repo.GetCartByID(cart.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response, err := buildCartResponse(r, cart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
+		}
+
+		etag.Set(w, response.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// RemoveCouponHandler - Remove the coupon from the user's or guest's cart
+func RemoveCouponHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CartCouponRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		// Get the user's or guest's cart
+		cart, err := findCart(repo, guests, r, req.UserID)
+		if err != nil {
+			writeFindCartError(w, err)
+			return
+		}
+
+		if err := repo.SetCartCoupon(cart.ID, "", version); err != nil {
+			writeCartChangeError(w, err)
+			return
+		}
+
+		cart, err = repo.GetCartByID(cart.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response, err := buildCartResponse(r, cart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
+		}
+
+		etag.Set(w, response.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,101 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/notification"
+	"log"
+	"time"
+)
+
+// AbandonedCartEvent is the data of a cart.abandoned notification
+type AbandonedCartEvent struct {
+	CartID       int64               `json:"cart_id"`
+	UserID       int64               `json:"user_id"`
+	Currency     string              `json:"currency"`
+	Items        []AbandonedCartItem `json:"items"`
+	TotalAmount  domain.Money        `json:"total_amount"`
+	CouponCode   string              `json:"coupon_code,omitempty"`
+	LastActivity time.Time           `json:"last_activity"`
+}
+
+type AbandonedCartItem struct {
+	ProductID int64        `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	Price     domain.Money `json:"price"`
+}
+
+// CartExpiry periodically deletes guest carts idle for longer than GuestTTL
+// and flags user carts idle for longer than AbandonAfter as abandoned,
+// sending a cart.abandoned event for each through Notifier. A zero duration
+// turns the matching check off.
+type CartExpiry struct {
+	Repo         infrastructure.CartRepository
+	Notifier     notification.Notifier
+	GuestTTL     time.Duration
+	AbandonAfter time.Duration
+}
+
+// Start runs the checks every interval in the background
+func (e CartExpiry) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := e.Run(); err != nil {
+				log.Printf("cart expiry: %v", err)
+			}
+		}
+	}()
+}
+
+// Run performs the checks once. A cart is flagged before its event is sent,
+// so an event that fails to send is logged and not retried.
+func (e CartExpiry) Run() error {
+	if e.GuestTTL > 0 {
+		expired, err := e.Repo.ExpireGuestCarts(e.GuestTTL)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			log.Printf("cart expiry: deleted %d guest carts", expired)
+		}
+	}
+
+	if e.AbandonAfter <= 0 {
+		return nil
+	}
+	carts, err := e.Repo.MarkAbandonedCarts(e.AbandonAfter)
+	if err != nil {
+		return err
+	}
+	for _, cart := range carts {
+		event := notification.NewEvent(notification.EventCartAbandoned, buildAbandonedCartEvent(cart))
+		if err := e.Notifier.Notify(event); err != nil {
+			log.Printf("cart expiry: notify abandoned cart %d: %v", cart.ID, err)
+		}
+	}
+	return nil
+}
+
+// Helper function to build the data of a cart.abandoned event
+func buildAbandonedCartEvent(cart *domain.Cart) AbandonedCartEvent {
+	items := make([]AbandonedCartItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, AbandonedCartItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		})
+	}
+
+	return AbandonedCartEvent{
+		CartID:       cart.ID,
+		UserID:       cart.UserID,
+		Currency:     cart.Currency,
+		Items:        items,
+		TotalAmount:  cart.TotalAmount,
+		CouponCode:   cart.CouponCode,
+		LastActivity: cart.UpdatedAt,
+	}
+}
@@ -1,18 +1,24 @@
 package application
 
 import (
+	etag "ecommerce-go/application/etag"
+	pricing "ecommerce-go/application/pricing"
+	recommendation "ecommerce-go/application/recommendation"
 	"ecommerce-go/domain"
 	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/shipping"
+	"ecommerce-go/infrastructure/tax"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"time"
 )
 
 type AddToCartRequest struct {
-	UserID    int64   `json:"user_id"`
-	ProductID int64   `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
+	UserID    int64 `json:"user_id"`
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
 }
 
 type RemoveFromCartRequest struct {
@@ -26,100 +32,211 @@ type UpdateCartRequest struct {
 	Quantity  int   `json:"quantity"`
 }
 
+type CartCouponRequest struct {
+	UserID int64  `json:"user_id"`
+	Code   string `json:"code"`
+}
+
 type CartItemResponse struct {
-	ID        int64   `json:"id"`
-	ProductID int64   `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
-	Subtotal  float64 `json:"subtotal"`
+	ID         int64                      `json:"id"`
+	ProductID  int64                      `json:"product_id"`
+	Quantity   int                        `json:"quantity"`
+	Price      domain.Money               `json:"price"`
+	Subtotal   domain.Money               `json:"subtotal"`
+	Discount   domain.Money               `json:"discount"`
+	Tax        domain.Money               `json:"tax"`
+	TaxRate    string                     `json:"tax_rate"`
+	Promotions []AppliedPromotionResponse `json:"promotions"`
+}
+
+// AppliedPromotionResponse explains a promotion discount on a cart line
+type AppliedPromotionResponse struct {
+	PromotionID int64        `json:"promotion_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Discount    domain.Money `json:"discount"`
 }
 
 type CartResponse struct {
-	CartID      int64              `json:"cart_id"`
-	UserID      int64              `json:"user_id"`
-	Items       []CartItemResponse `json:"items"`
-	TotalAmount float64            `json:"total_amount"`
-	TotalItems  int                `json:"total_items"`
+	CartID            int64                    `json:"cart_id"`
+	UserID            int64                    `json:"user_id"`
+	GuestToken        string                   `json:"guest_token,omitempty"`
+	Currency          string                   `json:"currency"`
+	Items             []CartItemResponse       `json:"items"`
+	Subtotal          domain.Money             `json:"subtotal"`
+	PromotionTotal    domain.Money             `json:"promotion_discount"`
+	Coupon            *CartCouponResponse      `json:"coupon,omitempty"`
+	DiscountAmount    domain.Money             `json:"discount_amount"`
+	TaxAmount         domain.Money             `json:"tax_amount"`
+	PricesIncludeTax  bool                     `json:"prices_include_tax"`
+	TaxCountry        string                   `json:"tax_country,omitempty"`
+	TaxRegion         string                   `json:"tax_region,omitempty"`
+	TotalAmount       domain.Money             `json:"total_amount"`
+	TotalItems        int                      `json:"total_items"`
+	ShippingAddressID int64                    `json:"shipping_address_id,omitempty"`
+	ShippingOptions   []ShippingOptionResponse `json:"shipping_options"`
+	// Recommendations are only looked up when getting the cart
+	Recommendations *recommendation.RecommendationsResponse `json:"recommendations,omitempty"`
+	UpdatedAt       time.Time                               `json:"updated_at"`
+	Version         int64                                   `json:"version"`
+}
+
+// ShippingOptionResponse is one way the cart can be shipped. Its price is
+// not part of the cart total until the option is chosen at checkout.
+type ShippingOptionResponse struct {
+	Code         string       `json:"code"`
+	Name         string       `json:"name"`
+	Price        domain.Money `json:"price"`
+	MinDays      int          `json:"min_days"`
+	MaxDays      int          `json:"max_days"`
+	FreeShipping bool         `json:"free_shipping"`
+}
+
+// CartCouponResponse describes the coupon applied to a cart. A coupon that
+// no longer applies stays on the cart with a zero discount and the reason
+// in Error, so the customer can see why and remove it.
+type CartCouponResponse struct {
+	Code         string       `json:"code"`
+	Type         string       `json:"type"`
+	Discount     domain.Money `json:"discount"`
+	FreeShipping bool         `json:"free_shipping"`
+	Error        string       `json:"error,omitempty"`
 }
 
-// CreateCartHandler - Create a new cart for user
-func CreateCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
+// CreateCartHandler - Get the active cart of the user, or of the guest when
+// no user is given, creating it in the requested currency if there is none.
+// An existing cart is repriced into the requested currency.
+func CreateCartHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, currencies pricing.Currencies, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			http.Error(w, "User ID required", http.StatusBadRequest)
+		uid, err := userIDFromQuery(r)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
 			return
 		}
 
-		uid, err := strconv.ParseInt(userID, 10, 64)
+		currency, requested, err := currencies.FromRequest(r)
 		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		// Create cart
-		cart, err := repo.CreateCart(uid)
+		cart, token, created, err := getOrCreateCart(w, r, repo, guests, uid, currency)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeFindCartError(w, err)
 			return
 		}
 
-		response := CartResponse{
-			CartID:      cart.ID,
-			UserID:      cart.UserID,
-			Items:       make([]CartItemResponse, 0),
-			TotalAmount: 0,
-			TotalItems:  0,
+		if requested && currency != cart.Currency {
+			cart, err = switchCartCurrency(repo, productRepo, currencyRepo, cart, currency)
+			if err != nil {
+				writeCartCurrencyError(w, err)
+				return
+			}
+		}
+
+		response, err := buildCartResponse(r, cart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
 		}
+		response.GuestToken = token
 
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		etag.Set(w, response.Version)
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-// GetCartHandler - Get user's cart
-func GetCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
+// GetCartHandler - Get the user's or guest's cart with the products
+// recommended for it, priced in the requested currency if one is given. The
+// stored cart keeps its currency; POST /cart/create switches it.
+func GetCartHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, recommender recommendation.Recommender, currencies pricing.Currencies, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			http.Error(w, "User ID required", http.StatusBadRequest)
+		uid, err := userIDFromQuery(r)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
 			return
 		}
 
-		uid, err := strconv.ParseInt(userID, 10, 64)
+		currency, requested, err := currencies.FromRequest(r)
 		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		// Get cart by user ID
-		cart, err := repo.GetCartByUserID(uid)
+		// Reading a cart never creates one; that is left to /cart/create
+		cart, err := findCart(repo, guests, r, uid)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusNotFound)
+			writeFindCartError(w, err)
 			return
 		}
 
+		// Show the cart in the requested currency without saving the change
+		if requested && currency != cart.Currency {
+			prices, err := cartPricesIn(productRepo, currencyRepo, cart, currency)
+			if err != nil {
+				writeCartCurrencyError(w, err)
+				return
+			}
+			cart = repricedCart(cart, currency, prices)
+		}
+
 		// Convert to response
-		response := buildCartResponse(cart)
+		response, err := buildCartResponse(r, cart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
+		}
+
+		response.Recommendations, err = cartRecommendations(recommender, currencyRepo, cart)
+		if err != nil {
+			writeCartCurrencyError(w, err)
+			return
+		}
 
+		etag.Set(w, response.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-// AddToCartHandler - Add product to cart
-func AddToCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
+// Helper function to look up the products recommended for a cart's items
+// together, priced in the cart's currency
+func cartRecommendations(recommender recommendation.Recommender, currencyRepo infrastructure.CurrencyRepository, cart *domain.Cart) (*recommendation.RecommendationsResponse, error) {
+	ids := make([]int64, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	recommended, err := recommender.For(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range recommended.Products() {
+		if p.Price, err = currencyRepo.PriceIn(p, cart.Currency); err != nil {
+			return nil, err
+		}
+	}
+	return recommended.Response(), nil
+}
+
+// AddToCartHandler - Add product to cart at its current price in the cart currency
+func AddToCartHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, currencies pricing.Currencies, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -133,34 +250,87 @@ func AddToCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
 		}
 
 		// Validate input
-		if req.UserID == 0 || req.ProductID == 0 || req.Quantity <= 0 || req.Price <= 0 {
+		if req.ProductID == 0 || req.Quantity <= 0 {
 			http.Error(w, "Invalid product data", http.StatusBadRequest)
 			return
 		}
 
-		// Get user's cart
-		cart, err := repo.GetCartByUserID(req.UserID)
+		version, err := etag.IfMatch(r)
 		if err != nil {
-			http.Error(w, "Cart not found", http.StatusNotFound)
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
 			return
 		}
 
-		// Add item to cart
-		_, err = repo.AddItemToCart(cart.ID, req.ProductID, req.Quantity, req.Price)
+		currency, requested, err := currencies.FromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		product, err := productRepo.GetByID(req.ProductID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		if product.IsArchived() {
+			http.Error(w, "Product is no longer available", http.StatusConflict)
+			return
+		}
+
+		// Get the user's or guest's cart, creating it if there is none
+		cart, _, _, err := getOrCreateCart(w, r, repo, guests, req.UserID, currency)
+		if err != nil {
+			writeFindCartError(w, err)
+			return
+		}
+
+		if version != 0 && version != cart.Version {
+			writeCartChangeError(w, infrastructure.ErrVersionConflict)
+			return
+		}
+
+		if requested && currency != cart.Currency {
+			cart, err = switchCartCurrency(repo, productRepo, currencyRepo, cart, currency)
+			if err != nil {
+				writeCartCurrencyError(w, err)
+				return
+			}
+			if version != 0 {
+				version = cart.Version
+			}
+		}
+
+		price, err := currencyRepo.PriceIn(product, cart.Currency)
+		if err != nil {
+			writeCartCurrencyError(w, err)
+			return
+		}
+
+		// Add item to cart
+		_, err = repo.AddItemToCart(cart.ID, req.ProductID, req.Quantity, price, version)
+		if err != nil {
+			writeCartChangeError(w, err)
+			return
+		}
 
 		// Get updated cart
-		updatedCart, err := repo.GetCartByUserID(req.UserID)
+		updatedCart, err := repo.GetCartByID(cart.ID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		response := buildCartResponse(updatedCart)
+		response, err := buildCartResponse(r, updatedCart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
+		}
 
+		etag.Set(w, response.Version)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
@@ -168,7 +338,7 @@ func AddToCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
 }
 
 // RemoveFromCartHandler - Remove product from cart
-func RemoveFromCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
+func RemoveFromCartHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -182,41 +352,52 @@ func RemoveFromCartHandler(repo infrastructure.CartRepository) http.HandlerFunc
 		}
 
 		// Validate input
-		if req.UserID == 0 || req.ProductID == 0 {
-			http.Error(w, "User ID and Product ID required", http.StatusBadRequest)
+		if req.ProductID == 0 {
+			http.Error(w, "Product ID required", http.StatusBadRequest)
+			return
+		}
+
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
 			return
 		}
 
-		// Get user's cart
-		cart, err := repo.GetCartByUserID(req.UserID)
+		// Get the user's or guest's cart
+		cart, err := findCart(repo, guests, r, req.UserID)
 		if err != nil {
-			http.Error(w, "Cart not found", http.StatusNotFound)
+			writeFindCartError(w, err)
 			return
 		}
 
 		// Remove item from cart
-		err = repo.RemoveItemFromCart(cart.ID, req.ProductID)
+		err = repo.RemoveItemFromCart(cart.ID, req.ProductID, version)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeCartChangeError(w, err)
 			return
 		}
 
 		// Get updated cart
-		updatedCart, err := repo.GetCartByUserID(req.UserID)
+		updatedCart, err := repo.GetCartByID(cart.ID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		response := buildCartResponse(updatedCart)
+		response, err := buildCartResponse(r, updatedCart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
+		}
 
+		etag.Set(w, response.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
 // UpdateCartItemHandler - Update product quantity in cart
-func UpdateCartItemHandler(repo infrastructure.CartRepository) http.HandlerFunc {
+func UpdateCartItemHandler(repo infrastructure.CartRepository, guests GuestCarts, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPut {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -230,107 +411,353 @@ func UpdateCartItemHandler(repo infrastructure.CartRepository) http.HandlerFunc
 		}
 
 		// Validate input
-		if req.UserID == 0 || req.ProductID == 0 || req.Quantity <= 0 {
+		if req.ProductID == 0 || req.Quantity <= 0 {
 			http.Error(w, "Invalid request data", http.StatusBadRequest)
 			return
 		}
 
-		// Get user's cart
-		cart, err := repo.GetCartByUserID(req.UserID)
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		// Get the user's or guest's cart
+		cart, err := findCart(repo, guests, r, req.UserID)
 		if err != nil {
-			http.Error(w, "Cart not found", http.StatusNotFound)
+			writeFindCartError(w, err)
 			return
 		}
 
 		// Update cart item
-		_, err = repo.UpdateCartItem(cart.ID, req.ProductID, req.Quantity)
+		_, err = repo.UpdateCartItem(cart.ID, req.ProductID, req.Quantity, version)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeCartChangeError(w, err)
 			return
 		}
 
 		// Get updated cart
-		updatedCart, err := repo.GetCartByUserID(req.UserID)
+		updatedCart, err := repo.GetCartByID(cart.ID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		response := buildCartResponse(updatedCart)
+		response, err := buildCartResponse(r, updatedCart, couponRepo, promotionRepo, taxes, delivery)
+		if err != nil {
+			writeCartResponseError(w, err)
+			return
+		}
 
+		etag.Set(w, response.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
 // ClearCartHandler - Clear all items from cart
-func ClearCartHandler(repo infrastructure.CartRepository) http.HandlerFunc {
+func ClearCartHandler(repo infrastructure.CartRepository, guests GuestCarts) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			http.Error(w, "User ID required", http.StatusBadRequest)
+		uid, err := userIDFromQuery(r)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
 			return
 		}
 
-		uid, err := strconv.ParseInt(userID, 10, 64)
+		version, err := etag.IfMatch(r)
 		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
 			return
 		}
 
-		// Get user's cart
-		cart, err := repo.GetCartByUserID(uid)
+		// Get the user's or guest's cart
+		cart, err := findCart(repo, guests, r, uid)
 		if err != nil {
-			http.Error(w, "Cart not found", http.StatusNotFound)
+			writeFindCartError(w, err)
 			return
 		}
 
 		// Clear cart
-		err = repo.ClearCart(cart.ID)
+		err = repo.ClearCart(cart.ID, version)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeCartChangeError(w, err)
 			return
 		}
 
-		response := CartResponse{
-			CartID:      cart.ID,
-			UserID:      uid,
-			Items:       make([]CartItemResponse, 0),
-			TotalAmount: 0,
-			TotalItems:  0,
+		cart, err = repo.GetCartByID(cart.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
+		response := CartResponse{
+			CartID:          cart.ID,
+			UserID:          cart.UserID,
+			Currency:        cart.Currency,
+			Items:           make([]CartItemResponse, 0),
+			Subtotal:        domain.NewMoney(0, cart.Currency),
+			PromotionTotal:  domain.NewMoney(0, cart.Currency),
+			DiscountAmount:  domain.NewMoney(0, cart.Currency),
+			TaxAmount:       domain.NewMoney(0, cart.Currency),
+			TotalAmount:     domain.NewMoney(0, cart.Currency),
+			TotalItems:      0,
+			ShippingOptions: make([]ShippingOptionResponse, 0),
+			UpdatedAt:       cart.UpdatedAt,
+			Version:         cart.Version,
+		}
+
+		etag.Set(w, response.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-// Helper function to build cart response
-func buildCartResponse(cart *domain.Cart) CartResponse {
+// Helper function to build cart response, including the discounts of the
+// automatic promotions and of the applied coupon, and the tax and shipping
+// options for the address selected by the request, the user's default
+// shipping address or else the requested or default tax location
+func buildCartResponse(r *http.Request, cart *domain.Cart, couponRepo infrastructure.CouponRepository, promotionRepo infrastructure.PromotionRepository, taxes pricing.Taxes, delivery pricing.Shipping) (CartResponse, error) {
+	address, err := delivery.FromRequest(r, cart.UserID)
+	if err != nil {
+		return CartResponse{}, err
+	}
+	location := taxes.FromRequest(r)
+	destination := shipping.Destination{Country: location.Country, Region: location.Region}
+	if address != nil {
+		location = tax.Location{Country: address.Country, Region: address.Region}
+		destination = shipping.Destination{Country: address.Country, Region: address.Region, PostalCode: address.PostalCode}
+	}
+
+	promotions, err := promotionRepo.CartPromotions(cart)
+	if err != nil {
+		return CartResponse{}, err
+	}
+
 	items := make([]CartItemResponse, 0)
 	totalItems := 0
 
 	for _, item := range cart.Items {
+		applied := make([]AppliedPromotionResponse, 0)
+		for _, promotion := range promotions.Lines[item.ProductID] {
+			applied = append(applied, AppliedPromotionResponse{
+				PromotionID: promotion.PromotionID,
+				Name:        promotion.Name,
+				Description: promotion.Description,
+				Discount:    promotion.Discount,
+			})
+		}
+
 		items = append(items, CartItemResponse{
-			ID:        item.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-			Subtotal:  float64(item.Quantity) * item.Price,
+			ID:         item.ID,
+			ProductID:  item.ProductID,
+			Quantity:   item.Quantity,
+			Price:      item.Price,
+			Subtotal:   item.Price.Mul(int64(item.Quantity)),
+			Discount:   domain.NewMoney(0, cart.Currency).Add(promotions.LineDiscounts[item.ProductID]),
+			Promotions: applied,
 		})
 		totalItems += item.Quantity
 	}
 
-	return CartResponse{
-		CartID:      cart.ID,
-		UserID:      cart.UserID,
-		Items:       items,
-		TotalAmount: cart.TotalAmount,
-		TotalItems:  totalItems,
+	response := CartResponse{
+		CartID:         cart.ID,
+		UserID:         cart.UserID,
+		Currency:       cart.Currency,
+		Items:          items,
+		Subtotal:       cart.TotalAmount,
+		PromotionTotal: promotions.Total,
+		DiscountAmount: promotions.Total,
+		TaxCountry:     location.Country,
+		TaxRegion:      location.Region,
+		TotalAmount:    cart.TotalAmount.Sub(promotions.Total),
+		TotalItems:     totalItems,
+		UpdatedAt:      cart.UpdatedAt,
+		Version:        cart.Version,
+	}
+	if address != nil {
+		response.ShippingAddressID = address.ID
+	}
+
+	couponDiscount := domain.NewMoney(0, cart.Currency)
+	if cart.CouponCode != "" {
+		coupon, discount, err := couponRepo.CartDiscount(cart, promotions.LineDiscounts)
+		response.Coupon = &CartCouponResponse{Code: cart.CouponCode, Discount: discount}
+		if coupon != nil {
+			response.Coupon.Type = string(coupon.Type)
+		}
+		switch {
+		case isCouponRejection(err):
+			response.Coupon.Error = err.Error()
+		case err != nil:
+			return CartResponse{}, err
+		default:
+			response.Coupon.FreeShipping = coupon.Type == domain.CouponTypeFreeShipping
+			couponDiscount = discount
+			response.DiscountAmount = response.DiscountAmount.Add(discount)
+			response.TotalAmount = response.TotalAmount.Sub(discount)
+		}
+	}
+
+	// Tax what is left of each line once the coupon is spread over them
+	taxed, err := taxes.Calculator.Calculate(location, tax.CartLines(cart.Items, promotions.LineDiscounts, couponDiscount))
+	if err != nil {
+		return CartResponse{}, err
+	}
+	for i, line := range taxed.Lines {
+		response.Items[i].Tax = domain.NewMoney(0, cart.Currency).Add(line.Amount)
+		response.Items[i].TaxRate = tax.FormatRate(line.Rate)
+	}
+	response.TaxAmount = domain.NewMoney(0, cart.Currency).Add(taxed.Total)
+	response.PricesIncludeTax = taxed.PricesIncludeTax
+	if !taxed.PricesIncludeTax {
+		response.TotalAmount = response.TotalAmount.Add(response.TaxAmount)
+	}
+
+	response.ShippingOptions, err = cartShippingOptions(cart, delivery.Provider, destination, response)
+	if err != nil {
+		return CartResponse{}, err
+	}
+
+	return response, nil
+}
+
+// Helper function to price the shipping options of a cart. The parcel is
+// worth the discounted goods, and a free shipping coupon waives every option.
+func cartShippingOptions(cart *domain.Cart, provider shipping.RateProvider, destination shipping.Destination, response CartResponse) ([]ShippingOptionResponse, error) {
+	options := make([]ShippingOptionResponse, 0)
+	if len(cart.Items) == 0 {
+		return options, nil
+	}
+
+	weight := 0
+	for _, item := range cart.Items {
+		weight += item.WeightGrams * item.Quantity
+	}
+
+	rates, err := provider.Rates(destination, shipping.Parcel{
+		WeightGrams: weight,
+		Value:       response.Subtotal.Sub(response.DiscountAmount),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	waived := response.Coupon != nil && response.Coupon.FreeShipping
+	for _, rate := range rates {
+		option := ShippingOptionResponse{
+			Code:         rate.Code,
+			Name:         rate.Name,
+			Price:        rate.Price,
+			MinDays:      rate.MinDays,
+			MaxDays:      rate.MaxDays,
+			FreeShipping: rate.FreeShipping || waived,
+		}
+		if waived {
+			option.Price = domain.NewMoney(0, cart.Currency)
+		}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// Helper function to write an error building a cart response
+func writeCartResponseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pricing.ErrInvalidAddressID):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, infrastructure.ErrAddressNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function reporting whether err means the coupon does not apply,
+// as opposed to a failure evaluating it
+func isCouponRejection(err error) bool {
+	return errors.Is(err, domain.ErrCouponInactive) ||
+		errors.Is(err, domain.ErrCouponMinimumNotMet) ||
+		errors.Is(err, domain.ErrCouponNotApplicable) ||
+		errors.Is(err, domain.ErrCouponUsageExceeded) ||
+		errors.Is(err, infrastructure.ErrCouponNotFound) ||
+		errors.Is(err, infrastructure.ErrExchangeRateNotFound)
+}
+
+// Helper function to move a cart into another currency, repricing every item
+// at the product's current price in that currency
+func switchCartCurrency(repo infrastructure.CartRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, cart *domain.Cart, currency string) (*domain.Cart, error) {
+	prices, err := cartPricesIn(productRepo, currencyRepo, cart, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repo.RepriceCart(cart.ID, currency, prices, cart.Version); err != nil {
+		return nil, err
+	}
+
+	return repo.GetCartByID(cart.ID)
+}
+
+// Helper function to look up the current price in currency of every product
+// in a cart
+func cartPricesIn(productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, cart *domain.Cart, currency string) (map[int64]domain.Money, error) {
+	prices := make(map[int64]domain.Money, len(cart.Items))
+	for _, item := range cart.Items {
+		product, err := productRepo.GetByID(item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, fmt.Errorf("product %d no longer exists", item.ProductID)
+		}
+
+		price, err := currencyRepo.PriceIn(product, currency)
+		if err != nil {
+			return nil, err
+		}
+		prices[item.ProductID] = price
+	}
+	return prices, nil
+}
+
+// Helper function to copy a cart into another currency at the given item
+// prices, leaving the stored cart as it is
+func repricedCart(cart *domain.Cart, currency string, prices map[int64]domain.Money) *domain.Cart {
+	repriced := *cart
+	repriced.Currency = currency
+	repriced.TotalAmount = domain.NewMoney(0, currency)
+	repriced.Items = make([]domain.CartItem, len(cart.Items))
+	for i, item := range cart.Items {
+		item.Price = prices[item.ProductID]
+		repriced.Items[i] = item
+		repriced.TotalAmount = repriced.TotalAmount.Add(item.Price.Mul(int64(item.Quantity)))
+	}
+	return &repriced
+}
+
+// Helper function to map pricing errors, and errors saving a cart in its new
+// currency, to HTTP responses
+func writeCartCurrencyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, infrastructure.ErrExchangeRateNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeCartChangeError(w, err)
+}
+
+// Helper function to map errors changing a cart to HTTP responses
+func writeCartChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrVersionConflict):
+		http.Error(w, "Cart was modified by another request", http.StatusPreconditionFailed)
+	case errors.Is(err, infrastructure.ErrCartNotFound), errors.Is(err, infrastructure.ErrCartItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
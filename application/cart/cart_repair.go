@@ -0,0 +1,98 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// CartRepair consolidates the duplicate active carts left from before users
+// were limited to one. Each user keeps their most recently changed cart; the
+// others are repriced into its currency and merged into it with Strategy.
+type CartRepair struct {
+	Repo         infrastructure.CartRepository
+	ProductRepo  infrastructure.ProductRepository
+	CurrencyRepo infrastructure.CurrencyRepository
+	Strategy     domain.CartMergeStrategy
+}
+
+// CartRepairReport summarizes a repair
+type CartRepairReport struct {
+	Users       int
+	Merged      int
+	Deactivated int
+}
+
+// Run consolidates every user's active carts. With dryRun it only reports
+// what it would do. A cart that cannot be repriced, because a product is
+// gone or an exchange rate is missing, is deactivated rather than merged.
+func (c CartRepair) Run(dryRun bool) (CartRepairReport, error) {
+	duplicates, err := c.Repo.GetDuplicateActiveCarts()
+	if err != nil {
+		return CartRepairReport{}, err
+	}
+
+	userIDs := make([]int64, 0, len(duplicates))
+	for userID := range duplicates {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+
+	report := CartRepairReport{Users: len(userIDs)}
+	for _, userID := range userIDs {
+		cartIDs := duplicates[userID]
+		keep := cartIDs[0]
+		for _, cartID := range cartIDs[1:] {
+			merged, err := c.merge(cartID, keep, dryRun)
+			if err != nil {
+				return report, fmt.Errorf("user %d: %w", userID, err)
+			}
+			if merged {
+				report.Merged++
+			} else {
+				report.Deactivated++
+			}
+		}
+	}
+	return report, nil
+}
+
+// merge moves the cart sourceID into targetID, or deactivates it when it
+// cannot be repriced, and reports whether it was merged. With dryRun it only
+// checks whether the cart can be repriced.
+func (c CartRepair) merge(sourceID, targetID int64, dryRun bool) (bool, error) {
+	target, err := c.Repo.GetCartByID(targetID)
+	if err != nil {
+		return false, err
+	}
+	source, err := c.Repo.GetCartByID(sourceID)
+	if err != nil {
+		return false, err
+	}
+
+	if dryRun {
+		if source.Currency != target.Currency {
+			if _, err := cartPricesIn(c.ProductRepo, c.CurrencyRepo, source, target.Currency); err != nil {
+				log.Printf("repair carts: would deactivate cart %d: %v", sourceID, err)
+				return false, nil
+			}
+		}
+		log.Printf("repair carts: would merge cart %d into %d", sourceID, targetID)
+		return true, nil
+	}
+
+	if source.Currency != target.Currency {
+		_, err := switchCartCurrency(c.Repo, c.ProductRepo, c.CurrencyRepo, source, target.Currency)
+		if err != nil {
+			log.Printf("repair carts: deactivating cart %d: %v", sourceID, err)
+			return false, c.Repo.DeactivateCart(sourceID)
+		}
+	}
+
+	if _, err := c.Repo.MergeCarts(sourceID, targetID, c.Strategy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,216 @@
+package application
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// GuestCartCookie is the cookie carrying a guest's cart token
+	GuestCartCookie = "guest_cart"
+	// GuestCartHeader carries the token for clients that do not keep cookies
+	GuestCartHeader = "X-Guest-Cart"
+)
+
+var (
+	ErrInvalidGuestToken = errors.New("invalid guest cart token")
+	ErrNoCartOwner       = errors.New("user ID or guest cart token required")
+)
+
+// GuestCarts issues the signed tokens identifying guest carts and merges a
+// guest's cart into their own when they log in. A token is the guest ID
+// followed by its HMAC-SHA256 under Secret.
+type GuestCarts struct {
+	Secret       []byte
+	MaxAge       time.Duration
+	Strategy     domain.CartMergeStrategy
+	Repo         infrastructure.CartRepository
+	ProductRepo  infrastructure.ProductRepository
+	CurrencyRepo infrastructure.CurrencyRepository
+}
+
+// Issue creates a new guest ID and its token
+func (g GuestCarts) Issue() (guestID, token string, err error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	guestID = hex.EncodeToString(id)
+	return guestID, guestID + "." + g.sign(guestID), nil
+}
+
+// Verify returns the guest ID of a token
+func (g GuestCarts) Verify(token string) (string, error) {
+	guestID, signature, ok := strings.Cut(token, ".")
+	if !ok || guestID == "" || !hmac.Equal([]byte(signature), []byte(g.sign(guestID))) {
+		return "", ErrInvalidGuestToken
+	}
+	return guestID, nil
+}
+
+// FromRequest returns the guest ID carried by the X-Guest-Cart header or
+// the guest cart cookie, and whether the request carried a token at all
+func (g GuestCarts) FromRequest(r *http.Request) (string, bool, error) {
+	token := r.Header.Get(GuestCartHeader)
+	if token == "" {
+		if cookie, err := r.Cookie(GuestCartCookie); err == nil {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		return "", false, nil
+	}
+
+	guestID, err := g.Verify(token)
+	return guestID, true, err
+}
+
+// SetCookie stores a guest cart token in the client
+func (g GuestCarts) SetCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     GuestCartCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(g.MaxAge.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearCookie removes the guest cart token from the client
+func (g GuestCarts) ClearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     GuestCartCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// MergeGuestCart merges the cart of the guest making the request into the
+// user's active cart, repricing it into the user's cart currency first. A
+// request without a valid token or whose guest cart is gone is left alone.
+func (g GuestCarts) MergeGuestCart(w http.ResponseWriter, r *http.Request, userID int64) error {
+	guestID, ok, err := g.FromRequest(r)
+	if !ok {
+		return nil
+	}
+	if err != nil {
+		g.ClearCookie(w)
+		return nil
+	}
+
+	guestCart, err := g.Repo.GetCartByGuestID(guestID)
+	if errors.Is(err, infrastructure.ErrCartNotFound) {
+		g.ClearCookie(w)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	userCart, err := g.Repo.GetCartByUserID(userID)
+	switch {
+	case err == nil:
+		if userCart.Currency != guestCart.Currency {
+			_, err = switchCartCurrency(g.Repo, g.ProductRepo, g.CurrencyRepo, guestCart, userCart.Currency)
+			if err != nil {
+				return err
+			}
+		}
+	case !errors.Is(err, infrastructure.ErrCartNotFound):
+		return err
+	}
+
+	if _, err := g.Repo.MergeGuestCart(guestID, userID, g.Strategy); err != nil {
+		return err
+	}
+	g.ClearCookie(w)
+	return nil
+}
+
+// sign returns the hex encoded HMAC-SHA256 of a guest ID
+func (g GuestCarts) sign(guestID string) string {
+	mac := hmac.New(sha256.New, g.Secret)
+	mac.Write([]byte(guestID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Helper function to find the active cart of the user, or of the guest
+// making the request when userID is zero
+func findCart(repo infrastructure.CartRepository, guests GuestCarts, r *http.Request, userID int64) (*domain.Cart, error) {
+	if userID != 0 {
+		return repo.GetCartByUserID(userID)
+	}
+
+	guestID, ok, err := guests.FromRequest(r)
+	if !ok {
+		return nil, ErrNoCartOwner
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetCartByGuestID(guestID)
+}
+
+// Helper function to find the active cart of the user or of the guest making
+// the request like findCart, creating an empty one in currency if there is
+// none. A new guest cart is issued a token, returned and set as a cookie.
+func getOrCreateCart(w http.ResponseWriter, r *http.Request, repo infrastructure.CartRepository, guests GuestCarts, userID int64, currency string) (cart *domain.Cart, token string, created bool, err error) {
+	if userID != 0 {
+		cart, created, err = repo.GetOrCreateCart(userID, currency)
+		return cart, "", created, err
+	}
+
+	cart, err = findCart(repo, guests, r, 0)
+	if err == nil || !(errors.Is(err, ErrNoCartOwner) || errors.Is(err, infrastructure.ErrCartNotFound)) {
+		return cart, "", false, err
+	}
+
+	// No token yet, or the guest cart expired
+	guestID, token, err := guests.Issue()
+	if err != nil {
+		return nil, "", false, err
+	}
+	cart, err = repo.CreateGuestCart(guestID, currency)
+	if err != nil {
+		return nil, "", false, err
+	}
+	guests.SetCookie(w, token)
+	return cart, token, true, nil
+}
+
+// Helper function to write an error finding a cart
+func writeFindCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNoCartOwner):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrInvalidGuestToken):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, infrastructure.ErrCartNotFound):
+		http.Error(w, "Cart not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to read the optional user_id query parameter
+func userIDFromQuery(r *http.Request) (int64, error) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(userID, 10, 64)
+}
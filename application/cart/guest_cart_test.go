@@ -0,0 +1,101 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGuestCartsIssueAndVerify(t *testing.T) {
+	guests := GuestCarts{Secret: []byte("secret")}
+	guestID, token, err := guests.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(guestID) != 32 || token != guestID+"."+guests.sign(guestID) {
+		t.Fatalf("got guest ID %q and token %q", guestID, token)
+	}
+
+	_, other, err := guests.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == token {
+		t.Errorf("two tokens issued alike: %q", token)
+	}
+
+	signature := token[strings.Index(token, ".")+1:]
+	changedID := "f" + guestID[1:]
+	if guestID[0] == 'f' {
+		changedID = "0" + guestID[1:]
+	}
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		wantErr bool
+	}{
+		{"issued token", "secret", token, false},
+		{"another secret", "other", token, true},
+		{"guest ID changed", "secret", changedID + "." + signature, true},
+		{"signature changed", "secret", guestID + "." + strings.ToUpper(signature), true},
+		{"no signature", "secret", guestID, true},
+		{"empty signature", "secret", guestID + ".", true},
+		{"no guest ID", "secret", "." + signature, true},
+		{"empty", "secret", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := GuestCarts{Secret: []byte(tt.secret)}.Verify(tt.token)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidGuestToken) {
+				t.Errorf("%s: got error %v, want %v", tt.name, err, ErrInvalidGuestToken)
+			}
+			continue
+		}
+		if err != nil || got != guestID {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, guestID)
+		}
+	}
+}
+
+func TestGuestCartsFromRequest(t *testing.T) {
+	guests := GuestCarts{Secret: []byte("secret")}
+	guestID, token, err := guests.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		header    string
+		cookie    string
+		wantID    string
+		wantToken bool
+		wantErr   bool
+	}{
+		{"no token", "", "", "", false, false},
+		{"header", token, "", guestID, true, false},
+		{"cookie", "", token, guestID, true, false},
+		{"header before cookie", token, "forged.token", guestID, true, false},
+		{"forged header", "forged.token", token, "", true, true},
+		{"forged cookie", "", "forged.token", "", true, true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/cart", nil)
+		if tt.header != "" {
+			r.Header.Set(GuestCartHeader, tt.header)
+		}
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: GuestCartCookie, Value: tt.cookie})
+		}
+
+		got, ok, err := guests.FromRequest(r)
+		if got != tt.wantID || ok != tt.wantToken || (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %q, %v, %v, want %q, %v, error %v", tt.name, got, ok, err, tt.wantID, tt.wantToken, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package application
+
+import (
+	pricing "ecommerce-go/application/pricing"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/tax"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxSKULength is the longest SKU the Product table holds
+const maxSKULength = 64
+
+// Catalog imports and exports products in bulk, identified by SKU
+type Catalog struct {
+	Repo       infrastructure.ProductRepository
+	Currencies pricing.Currencies
+}
+
+// ImportReport summarizes an import. With DryRun nothing was written and
+// the counts are what the import would have done.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Rows    int              `json:"rows"`
+	Created int              `json:"created"`
+	Updated int              `json:"updated"`
+	Failed  int              `json:"failed"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// ImportRowError explains why a row was not imported
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+// exportRecord is a product as written by JSON Lines exports
+type exportRecord struct {
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	TaxClass    string `json:"tax_class"`
+	WeightGrams int    `json:"weight_grams"`
+	Price       string `json:"price"`
+	Currency    string `json:"currency"`
+	Stock       int    `json:"stock"`
+}
+
+// Import reads products in format from r a row at a time, creating the
+// products whose SKU is new and updating the others. Columns left out of a
+// row keep the product's current values, as do blank names, prices, weights
+// and stock counts. Every row is saved on its own, so
+// rows that fail validation are reported and skipped without affecting the
+// rest. The returned error is for problems that stop the import as a whole,
+// such as an unreadable header. Price changes are recorded as made by
+// changedBy, which is zero when unknown.
+func (c Catalog) Import(r io.Reader, format Format, dryRun bool, changedBy int64) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Errors: make([]ImportRowError, 0)}
+
+	reader, err := newRecordReader(r, format)
+	if err != nil {
+		return report, err
+	}
+
+	// Products a dry run would have created, which later rows would update
+	pending := make(map[string]*domain.Product)
+	for {
+		rec, err := reader.Next()
+		if err == io.EOF {
+			return report, nil
+		}
+
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			report.Rows++
+			report.fail(rec.row, "", rowErr.err)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		report.Rows++
+		created, err := c.importRecord(rec, dryRun, changedBy, pending)
+		if err != nil {
+			report.fail(rec.row, strings.TrimSpace(rec.fields[columnSKU]), err)
+			continue
+		}
+		if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+}
+
+// Export writes every product in format to w
+func (c Catalog) Export(w io.Writer, format Format) error {
+	products, err := c.Repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	if format == FormatJSONL {
+		encoder := json.NewEncoder(w)
+		for _, p := range products {
+			if err := encoder.Encode(toExportRecord(p)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, p := range products {
+		rec := toExportRecord(p)
+		err := writer.Write([]string{
+			rec.SKU, rec.Name, rec.Description, rec.Category, rec.TaxClass,
+			strconv.Itoa(rec.WeightGrams), rec.Price, rec.Currency, strconv.Itoa(rec.Stock),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// importRecord validates a row and creates or updates its product, and
+// reports whether the product is new
+func (c Catalog) importRecord(rec record, dryRun bool, changedBy int64, pending map[string]*domain.Product) (bool, error) {
+	sku := strings.TrimSpace(rec.fields[columnSKU])
+	if sku == "" {
+		return false, errors.New("sku is required")
+	}
+	if len(sku) > maxSKULength {
+		return false, fmt.Errorf("sku must be at most %d characters", maxSKULength)
+	}
+
+	product, err := c.Repo.GetBySKU(sku)
+	if err != nil {
+		return false, err
+	}
+	created := product == nil
+	if product == nil {
+		product = &domain.Product{SKU: sku, TaxClass: tax.ClassStandard}
+		if earlier, ok := pending[sku]; ok {
+			copied := *earlier
+			product, created = &copied, false
+		}
+	}
+
+	if err := c.apply(product, rec.fields, created); err != nil {
+		return false, err
+	}
+
+	if dryRun {
+		if product.ID == 0 {
+			pending[sku] = product
+		}
+		return created, nil
+	}
+	if product.ID == 0 {
+		_, err = c.Repo.Create(product)
+	} else {
+		// Imports overwrite whatever the product holds
+		product.Version = 0
+		err = c.Repo.Update(product, changedBy, domain.PriceChangeImport)
+	}
+	return created, err
+}
+
+// apply sets the product's fields from the columns present in a row, then
+// checks the result is a valid product
+func (c Catalog) apply(product *domain.Product, fields map[string]string, created bool) error {
+	if value := strings.TrimSpace(fields[columnName]); value != "" {
+		product.Name = value
+	}
+	if value, ok := fields[columnDescription]; ok {
+		product.Description = value
+	}
+	if value, ok := fields[columnCategory]; ok {
+		product.Category = strings.TrimSpace(value)
+	}
+	if value, ok := fields[columnTaxClass]; ok && strings.TrimSpace(value) != "" {
+		product.TaxClass = strings.TrimSpace(value)
+	}
+	if value := fields[columnWeightGrams]; strings.TrimSpace(value) != "" {
+		weight, err := parseCount(columnWeightGrams, value)
+		if err != nil {
+			return err
+		}
+		product.WeightGrams = weight
+	}
+	if value := fields[columnStock]; strings.TrimSpace(value) != "" {
+		stock, err := parseCount(columnStock, value)
+		if err != nil {
+			return err
+		}
+		product.Stock = stock
+	}
+
+	// A new price without a currency is in the product's current currency,
+	// or the default one for new products
+	currency := product.Price.Currency
+	if value, ok := fields[columnCurrency]; ok && strings.TrimSpace(value) != "" {
+		currency = strings.ToUpper(strings.TrimSpace(value))
+	}
+	if currency == "" {
+		currency = c.Currencies.Default
+	}
+	if !c.Currencies.IsSupported(currency) {
+		return fmt.Errorf("unsupported currency %q", currency)
+	}
+	if value := fields[columnPrice]; strings.TrimSpace(value) != "" {
+		price, err := domain.ParseMoney(value, currency)
+		if err != nil {
+			return fmt.Errorf("invalid price %q", value)
+		}
+		product.Price = price
+	} else if created || currency != product.Price.Currency {
+		return errors.New("price is required")
+	}
+
+	if product.Name == "" {
+		return errors.New("name is required")
+	}
+	if !product.Price.IsPositive() {
+		return errors.New("price must be positive")
+	}
+	return nil
+}
+
+// fail records a row that was not imported
+func (r *ImportReport) fail(row int, sku string, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, ImportRowError{Row: row, SKU: sku, Message: err.Error()})
+}
+
+// parseCount parses a non-negative whole number column
+func parseCount(column, value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a whole number of at least 0", column)
+	}
+	return n, nil
+}
+
+func toExportRecord(p *domain.Product) exportRecord {
+	return exportRecord{
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		Category:    p.Category,
+		TaxClass:    p.TaxClass,
+		WeightGrams: p.WeightGrams,
+		Price:       p.Price.String(),
+		Currency:    p.Price.Currency,
+		Stock:       p.Stock,
+	}
+}
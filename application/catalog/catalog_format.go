@@ -0,0 +1,233 @@
+package application
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is a file format for catalog imports and exports
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// ParseFormat parses a format name, accepting "ndjson" for JSON Lines
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// ContentType returns the MIME type of files in format f
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+var (
+	ErrUnknownFormat = errors.New("unknown catalog format")
+	ErrUnknownColumn = errors.New("unknown catalog column")
+)
+
+// Catalog columns, in export order. An import may leave out any column but
+// sku; a product created by the import also needs a name and a price.
+const (
+	columnSKU         = "sku"
+	columnName        = "name"
+	columnDescription = "description"
+	columnCategory    = "category"
+	columnTaxClass    = "tax_class"
+	columnWeightGrams = "weight_grams"
+	columnPrice       = "price"
+	columnCurrency    = "currency"
+	columnStock       = "stock"
+)
+
+var columns = []string{
+	columnSKU, columnName, columnDescription, columnCategory, columnTaxClass,
+	columnWeightGrams, columnPrice, columnCurrency, columnStock,
+}
+
+// record is one product row of an import, holding the columns present in
+// the row by name
+type record struct {
+	row    int
+	fields map[string]string
+}
+
+// recordReader reads the rows of an import one at a time. Next returns
+// io.EOF after the last row. A row that cannot be parsed is returned with
+// a *rowError so the import can carry on; any other error ends the import.
+type recordReader interface {
+	Next() (record, error)
+}
+
+// rowError is a problem with a single row of an import
+type rowError struct {
+	row int
+	err error
+}
+
+func (e *rowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.row, e.err)
+}
+
+// newRecordReader reads rows in format from r
+func newRecordReader(r io.Reader, format Format) (recordReader, error) {
+	if format == FormatCSV {
+		return newCSVReader(r)
+	}
+	return &jsonlReader{scanner: newLineScanner(r)}, nil
+}
+
+// csvReader reads CSV with a header row naming the columns
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+	row    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV has no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Spreadsheets often start the file with a byte order mark
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !isColumn(name) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+		header[i] = name
+	}
+	if !contains(header, columnSKU) {
+		return nil, fmt.Errorf("CSV header must include %q", columnSKU)
+	}
+
+	return &csvReader{reader: reader, header: header, row: 1}, nil
+}
+
+func (c *csvReader) Next() (record, error) {
+	values, err := c.reader.Read()
+	if err == io.EOF {
+		return record{}, io.EOF
+	}
+	c.row++
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return record{row: c.row}, &rowError{row: c.row, err: parseErr.Err}
+	}
+	if err != nil {
+		return record{}, err
+	}
+	if len(values) != len(c.header) {
+		return record{row: c.row}, &rowError{
+			row: c.row,
+			err: fmt.Errorf("has %d fields, header has %d", len(values), len(c.header)),
+		}
+	}
+
+	fields := make(map[string]string, len(values))
+	for i, value := range values {
+		fields[c.header[i]] = value
+	}
+	return record{row: c.row, fields: fields}, nil
+}
+
+// jsonlReader reads one JSON object per line. Blank lines are skipped but
+// counted, so rows are numbered by line.
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func (j *jsonlReader) Next() (record, error) {
+	for j.scanner.Scan() {
+		j.row++
+		line := bytes.TrimSpace(j.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(line, &object); err != nil {
+			return record{row: j.row}, &rowError{row: j.row, err: errors.New("invalid JSON object")}
+		}
+
+		fields := make(map[string]string, len(object))
+		for name, raw := range object {
+			if !isColumn(name) {
+				return record{row: j.row}, &rowError{row: j.row, err: fmt.Errorf("%w: %q", ErrUnknownColumn, name)}
+			}
+			value, err := jsonScalar(raw)
+			if err != nil {
+				return record{row: j.row}, &rowError{row: j.row, err: fmt.Errorf("%s: %v", name, err)}
+			}
+			fields[name] = value
+		}
+		return record{row: j.row, fields: fields}, nil
+	}
+
+	if err := j.scanner.Err(); err != nil {
+		return record{}, err
+	}
+	return record{}, io.EOF
+}
+
+// newLineScanner scans lines of up to 1 MiB
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	return scanner
+}
+
+// jsonScalar returns a JSON string's value, or a number's literal text, so
+// prices keep their exact decimal digits
+func jsonScalar(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String(), nil
+	}
+	return "", errors.New("must be a string or a number")
+}
+
+func isColumn(name string) bool {
+	return contains(columns, name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package application
+
+import (
+	staff "ecommerce-go/application/staff"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ImportProductsHandler - Create and update products from a CSV or JSON
+// Lines request body (staff only). The format comes from the "format"
+// query parameter or the Content-Type, and "dry_run=true" only validates.
+// Price changes are recorded against the staff member acting on the request.
+func ImportProductsHandler(catalog Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		format, err := requestFormat(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		dryRun := false
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			dryRun, err = strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+				return
+			}
+		}
+
+		report, err := catalog.Import(r.Body, format, dryRun, staff.StaffID(r))
+		if err != nil {
+			// Rows before the failure may already be saved, so report them
+			// alongside the error
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(struct {
+				Error string `json:"error"`
+				ImportReport
+			}{err.Error(), report})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// ExportProductsHandler - Download every product as CSV or JSON Lines (staff only)
+func ExportProductsHandler(catalog Catalog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := r.URL.Query().Get("format")
+		if name == "" {
+			name = string(FormatCSV)
+		}
+		format, err := ParseFormat(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		filename := fmt.Sprintf("products-%s.%s", time.Now().UTC().Format("20060102"), format)
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+		// The response has started, so a failure can only be logged and the
+		// download cut short
+		if err := catalog.Export(w, format); err != nil {
+			log.Printf("export products: %v", err)
+		}
+	}
+}
+
+// Helper function to find the format of an import request
+func requestFormat(r *http.Request) (Format, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		return ParseFormat(name)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/jsonl":
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("%w: set the format parameter to csv or jsonl", ErrUnknownFormat)
+}
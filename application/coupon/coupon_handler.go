@@ -0,0 +1,200 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type CreateCouponRequest struct {
+	Code           string       `json:"code"`
+	Type           string       `json:"type"`
+	PercentOff     string       `json:"percent_off"`
+	AmountOff      domain.Money `json:"amount_off"`
+	MinOrderValue  domain.Money `json:"min_order_value"`
+	StartsAt       *time.Time   `json:"starts_at"`
+	EndsAt         *time.Time   `json:"ends_at"`
+	MaxUses        int          `json:"max_uses"`
+	MaxUsesPerUser int          `json:"max_uses_per_user"`
+	ProductIDs     []int64      `json:"product_ids"`
+	Categories     []string     `json:"categories"`
+}
+
+type SetCouponActiveRequest struct {
+	CouponID int64 `json:"coupon_id"`
+	Active   bool  `json:"active"`
+}
+
+type CouponResponse struct {
+	ID             int64         `json:"id"`
+	Code           string        `json:"code"`
+	Type           string        `json:"type"`
+	PercentOff     string        `json:"percent_off,omitempty"`
+	AmountOff      *domain.Money `json:"amount_off,omitempty"`
+	MinOrderValue  *domain.Money `json:"min_order_value,omitempty"`
+	StartsAt       *time.Time    `json:"starts_at,omitempty"`
+	EndsAt         *time.Time    `json:"ends_at,omitempty"`
+	MaxUses        int           `json:"max_uses"`
+	MaxUsesPerUser int           `json:"max_uses_per_user"`
+	ProductIDs     []int64       `json:"product_ids"`
+	Categories     []string      `json:"categories"`
+	IsActive       bool          `json:"is_active"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// CreateCouponHandler - Create a coupon (staff only)
+func CreateCouponHandler(repo infrastructure.CouponRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CreateCouponRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		coupon := &domain.Coupon{
+			Code:           domain.NormalizeCouponCode(req.Code),
+			Type:           domain.CouponType(req.Type),
+			AmountOff:      req.AmountOff,
+			MinOrderValue:  req.MinOrderValue,
+			StartsAt:       req.StartsAt,
+			EndsAt:         req.EndsAt,
+			MaxUses:        req.MaxUses,
+			MaxUsesPerUser: req.MaxUsesPerUser,
+			ProductIDs:     req.ProductIDs,
+			Categories:     req.Categories,
+			IsActive:       true,
+		}
+
+		if req.PercentOff != "" {
+			percent, err := domain.ParsePercent(req.PercentOff)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			coupon.PercentOff = percent
+		}
+
+		// Validate input
+		if err := coupon.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		coupon, err := repo.CreateCoupon(coupon)
+		if err != nil {
+			writeCouponError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(buildCouponResponse(coupon))
+	}
+}
+
+// GetCouponsHandler - List all coupons (staff only)
+func GetCouponsHandler(repo infrastructure.CouponRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		coupons, err := repo.GetCoupons()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]CouponResponse, 0, len(coupons))
+		for _, coupon := range coupons {
+			response = append(response, buildCouponResponse(coupon))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// SetCouponActiveHandler - Enable or disable a coupon (staff only)
+func SetCouponActiveHandler(repo infrastructure.CouponRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SetCouponActiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.CouponID == 0 {
+			http.Error(w, "Coupon ID required", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.SetCouponActive(req.CouponID, req.Active); err != nil {
+			writeCouponError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Helper function to map coupon errors to HTTP responses
+func writeCouponError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrCouponNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrCouponExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to build coupon response
+func buildCouponResponse(coupon *domain.Coupon) CouponResponse {
+	response := CouponResponse{
+		ID:             coupon.ID,
+		Code:           coupon.Code,
+		Type:           string(coupon.Type),
+		StartsAt:       coupon.StartsAt,
+		EndsAt:         coupon.EndsAt,
+		MaxUses:        coupon.MaxUses,
+		MaxUsesPerUser: coupon.MaxUsesPerUser,
+		ProductIDs:     coupon.ProductIDs,
+		Categories:     coupon.Categories,
+		IsActive:       coupon.IsActive,
+		CreatedAt:      coupon.CreatedAt,
+	}
+	if response.ProductIDs == nil {
+		response.ProductIDs = make([]int64, 0)
+	}
+	if response.Categories == nil {
+		response.Categories = make([]string, 0)
+	}
+
+	if coupon.Type == domain.CouponTypePercentage {
+		response.PercentOff = domain.FormatPercent(coupon.PercentOff)
+	}
+	if !coupon.AmountOff.IsZero() {
+		response.AmountOff = &coupon.AmountOff
+	}
+	if !coupon.MinOrderValue.IsZero() {
+		response.MinOrderValue = &coupon.MinOrderValue
+	}
+
+	return response
+}
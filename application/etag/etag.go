@@ -0,0 +1,40 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrPreconditionFailed means the If-Match header names no current version
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Format returns the ETag of a resource at version: the version quoted
+func Format(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Set writes the ETag header of a resource at version
+func Set(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", Format(version))
+}
+
+// IfMatch returns the version required by the If-Match header, or zero when
+// the request has no If-Match or matches any version with "*". A header that
+// names no version of ours, or several, can never be satisfied.
+func IfMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	if strings.Contains(value, ",") || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return 0, ErrPreconditionFailed
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrPreconditionFailed
+	}
+	return version, nil
+}
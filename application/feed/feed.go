@@ -0,0 +1,284 @@
+package application
+
+import (
+	"bytes"
+	"crypto/sha256"
+	media "ecommerce-go/application/media"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format is a product feed file format
+type Format string
+
+const (
+	// FormatXML is an RSS 2.0 feed with Google Merchant "g:" elements
+	FormatXML Format = "xml"
+	// FormatCSV is a spreadsheet feed with Google Merchant column names
+	FormatCSV Format = "csv"
+)
+
+// googleNamespace is the namespace of the Google Merchant feed elements
+const googleNamespace = "http://base.google.com/ns/1.0"
+
+// maxTitleLength and maxAdditionalImages are the Google Merchant limits on
+// an item's title and extra images
+const (
+	maxTitleLength      = 150
+	maxAdditionalImages = 10
+)
+
+// csvColumns are the header row of CSV feeds
+var csvColumns = []string{
+	"id", "title", "description", "link", "image_link", "additional_image_link", "availability",
+	"price", "condition", "product_type", "shipping_weight", "identifier_exists",
+}
+
+// ContentType returns the MIME type of feeds in format f
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/rss+xml; charset=utf-8"
+}
+
+// Feeds generates the product feeds read by comparison shopping engines and
+// keeps the latest of each format to serve
+type Feeds struct {
+	Repo  infrastructure.ProductRepository
+	Media media.Media
+	// SiteURL is the storefront address product and image links start with
+	SiteURL string
+	// Title names the store in XML feeds
+	Title string
+
+	mu        sync.RWMutex
+	generated map[Format]*Generated
+}
+
+// Generated is a feed as last generated, with the ETag and time it is
+// served with
+type Generated struct {
+	Data        []byte
+	ETag        string
+	GeneratedAt time.Time
+}
+
+// feedItem is a product as listed in a feed
+type feedItem struct {
+	ID               string   `xml:"g:id"`
+	Title            string   `xml:"title"`
+	Description      string   `xml:"description"`
+	Link             string   `xml:"link"`
+	ImageLink        string   `xml:"g:image_link,omitempty"`
+	AdditionalImages []string `xml:"g:additional_image_link,omitempty"`
+	Availability     string   `xml:"g:availability"`
+	Price            string   `xml:"g:price"`
+	Condition        string   `xml:"g:condition"`
+	ProductType      string   `xml:"g:product_type,omitempty"`
+	ShippingWeight   string   `xml:"g:shipping_weight,omitempty"`
+	IdentifierExists string   `xml:"g:identifier_exists"`
+}
+
+// rssFeed is the document of XML feeds
+type rssFeed struct {
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	Namespace string   `xml:"xmlns:g,attr"`
+	Channel   struct {
+		Title       string     `xml:"title"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		Items       []feedItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// Start regenerates the feeds every interval in the background, starting
+// right away
+func (f *Feeds) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := f.Run(); err != nil {
+				log.Printf("product feeds: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run regenerates every feed once from the products that are not archived
+func (f *Feeds) Run() error {
+	items, err := f.items()
+	if err != nil {
+		return err
+	}
+
+	generated := make(map[Format]*Generated, 2)
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, format := range []Format{FormatXML, FormatCSV} {
+		data, err := f.encode(items, format)
+		if err != nil {
+			return err
+		}
+		generated[format] = &Generated{Data: data, ETag: contentETag(data), GeneratedAt: now}
+
+		// An unchanged feed keeps its time, so If-Modified-Since still matches
+		if previous := f.Latest(format); previous != nil && previous.ETag == generated[format].ETag {
+			generated[format] = previous
+		}
+	}
+
+	f.mu.Lock()
+	f.generated = generated
+	f.mu.Unlock()
+	log.Printf("product feeds: generated %d items", len(items))
+	return nil
+}
+
+// Latest returns the feed in format as last generated, or nil if it has not
+// been generated yet
+func (f *Feeds) Latest(format Format) *Generated {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.generated[format]
+}
+
+// items lists the products that are not archived as feed items
+func (f *Feeds) items() ([]feedItem, error) {
+	products, err := f.Repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	images, err := f.Media.Repo.GetImagesByProductIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]feedItem, 0, len(products))
+	for _, p := range products {
+		items = append(items, f.item(p, images[p.ID]))
+	}
+	return items, nil
+}
+
+// item converts a product and its images in display order to a feed item
+func (f *Feeds) item(p *domain.Product, images []*domain.ProductImage) feedItem {
+	item := feedItem{
+		ID:               p.SKU,
+		Title:            truncate(p.Name, maxTitleLength),
+		Description:      p.Description,
+		Link:             f.productLink(p),
+		Availability:     "out_of_stock",
+		Price:            p.Price.String() + " " + p.Price.Currency,
+		Condition:        "new",
+		ProductType:      p.Category,
+		IdentifierExists: "no",
+	}
+	if item.ID == "" {
+		item.ID = strconv.FormatInt(p.ID, 10)
+	}
+	if item.Description == "" {
+		item.Description = p.Name
+	}
+	if p.Stock > 0 {
+		item.Availability = "in_stock"
+	}
+	if p.WeightGrams > 0 {
+		item.ShippingWeight = strconv.Itoa(p.WeightGrams) + " g"
+	}
+	for i, img := range images {
+		switch {
+		case i == 0:
+			item.ImageLink = f.absolute(f.Media.URL(img.Key))
+		case i <= maxAdditionalImages:
+			item.AdditionalImages = append(item.AdditionalImages, f.absolute(f.Media.URL(img.Key)))
+		}
+	}
+	return item
+}
+
+// productLink returns the storefront address of a product, by slug when it
+// has one
+func (f *Feeds) productLink(p *domain.Product) string {
+	if p.Slug == "" {
+		return f.absolute("/product?id=" + strconv.FormatInt(p.ID, 10))
+	}
+	return f.absolute("/products/" + p.Slug)
+}
+
+// absolute turns a path on the storefront into a full URL
+func (f *Feeds) absolute(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return path
+	}
+	return strings.TrimRight(f.SiteURL, "/") + path
+}
+
+// encode writes the items as a feed in format
+func (f *Feeds) encode(items []feedItem, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	if format == FormatXML {
+		feed := rssFeed{Version: "2.0", Namespace: googleNamespace}
+		feed.Channel.Title = f.Title
+		feed.Channel.Link = f.absolute("/")
+		feed.Channel.Description = f.Title + " products"
+		feed.Channel.Items = items
+
+		buf.WriteString(xml.Header)
+		encoder := xml.NewEncoder(&buf)
+		encoder.Indent("", "  ")
+		if err := encoder.Encode(feed); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(csvColumns); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		err := writer.Write([]string{
+			item.ID, item.Title, item.Description, item.Link, item.ImageLink, strings.Join(item.AdditionalImages, ","),
+			item.Availability, item.Price, item.Condition, item.ProductType, item.ShippingWeight, item.IdentifierExists,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// contentETag returns a strong ETag naming the content of data
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// truncate shortens s to at most max runes
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
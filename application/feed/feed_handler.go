@@ -0,0 +1,32 @@
+package application
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// ProductFeedHandler - Serve the product feed in format as last generated,
+// generating it first if it has not been yet. Requests with If-None-Match
+// or If-Modified-Since get 304 Not Modified while the feed is unchanged.
+func ProductFeedHandler(feeds *Feeds, format Format) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		feed := feeds.Latest(format)
+		if feed == nil {
+			if err := feeds.Run(); err != nil {
+				http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+				return
+			}
+			feed = feeds.Latest(format)
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("ETag", feed.ETag)
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeContent(w, r, "", feed.GeneratedAt, bytes.NewReader(feed.Data))
+	}
+}
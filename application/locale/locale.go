@@ -0,0 +1,107 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locales holds the storefront locale settings. Product content as created
+// and updated is in Default; other supported locales come from translations.
+type Locales struct {
+	Default   string
+	Supported []string
+}
+
+// FromRequest returns the locales to look for content in, best first and
+// ending with the default locale. An explicit "locale" query parameter
+// comes first, then the languages of the Accept-Language header by
+// preference. Each locale is followed by its base language, so "fr-CA"
+// falls back to "fr". Unsupported locales are skipped.
+func (l Locales) FromRequest(r *http.Request) []string {
+	var requested []string
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		requested = append(requested, locale)
+	}
+	requested = append(requested, parseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+
+	chain := make([]string, 0, len(requested)+1)
+	seen := make(map[string]bool)
+	add := func(locale string) {
+		if !seen[locale] && l.IsSupported(locale) {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+	for _, tag := range requested {
+		locale, err := domain.NormalizeLocale(tag)
+		if err != nil {
+			continue
+		}
+		add(locale)
+		add(domain.BaseLocale(locale))
+	}
+	add(l.Default)
+	return chain
+}
+
+// IsSupported reports whether content may be served in locale
+func (l Locales) IsSupported(locale string) bool {
+	if locale == l.Default {
+		return true
+	}
+	for _, supported := range l.Supported {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// SetHeaders marks a response as negotiated on Accept-Language and in the
+// language of locale
+func SetHeaders(w http.ResponseWriter, locale string) {
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", locale)
+}
+
+// Helper function to list the language tags of an Accept-Language header
+// by preference, dropping the wildcard and those with a quality of zero
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(name) == "q" {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			tags = append(tags, weighted{tag, quality})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, t.tag)
+	}
+	return result
+}
@@ -0,0 +1,438 @@
+package application
+
+import (
+	"bytes"
+	"crypto/rand"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/storage"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	errUnsupportedImage = errors.New("unsupported image type")
+	errInvalidImage     = errors.New("invalid image")
+	errImageTooLarge    = errors.New("image dimensions too large")
+)
+
+// maxImagePixels bounds the decoded size of an upload, so a small file
+// cannot claim huge dimensions and exhaust memory
+const maxImagePixels = 40_000_000
+
+// imageExtensions lists the accepted content types, as sniffed from the
+// upload rather than trusted from the client, with their file extensions
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Media stores product images in Store and serves them below BaseURL.
+// Uploads larger than MaxUploadBytes are refused, and a thumbnail is
+// generated for each entry in ThumbnailSizes, which maps a size name to the
+// longest side in pixels.
+type Media struct {
+	Repo           infrastructure.ProductImageRepository
+	Store          storage.BlobStore
+	BaseURL        string
+	MaxUploadBytes int64
+	ThumbnailSizes map[string]int
+}
+
+type ReorderImagesRequest struct {
+	ProductID int64   `json:"product_id"`
+	ImageIDs  []int64 `json:"image_ids"`
+}
+
+type DeleteImageRequest struct {
+	ProductID int64 `json:"product_id"`
+	ImageID   int64 `json:"image_id"`
+}
+
+type ImageResponse struct {
+	ID          int64                        `json:"id"`
+	URL         string                       `json:"url"`
+	ContentType string                       `json:"content_type"`
+	Width       int                          `json:"width"`
+	Height      int                          `json:"height"`
+	SizeBytes   int64                        `json:"size_bytes"`
+	AltText     string                       `json:"alt_text"`
+	Position    int                          `json:"position"`
+	Thumbnails  map[string]ThumbnailResponse `json:"thumbnails"`
+}
+
+type ThumbnailResponse struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// URL returns the address a blob is served at
+func (m Media) URL(key string) string {
+	return strings.TrimRight(m.BaseURL, "/") + "/" + key
+}
+
+// BuildImageResponses builds the ordered image list shown with a product
+func (m Media) BuildImageResponses(images []*domain.ProductImage) []ImageResponse {
+	response := make([]ImageResponse, 0, len(images))
+	for _, img := range images {
+		response = append(response, m.buildImageResponse(img))
+	}
+	return response
+}
+
+// RemoveProductImages deletes every image of a product with its files
+func (m Media) RemoveProductImages(productID int64) error {
+	images, err := m.Repo.GetImages(productID)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		removed, err := m.Repo.DeleteImage(productID, img.ID)
+		if err != nil {
+			return err
+		}
+		m.removeBlobs(removed)
+	}
+	return nil
+}
+
+// UploadImageHandler - Upload an image for a product as the multipart field
+// "image", with optional "alt_text" (staff only)
+func UploadImageHandler(media Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		productID, err := strconv.ParseInt(r.URL.Query().Get("product_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		// Leave room for the multipart framing and the other fields
+		r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadBytes+64<<10)
+		if err := r.ParseMultipartForm(media.MaxUploadBytes); err != nil {
+			writeUploadError(w, err, media.MaxUploadBytes)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		file, _, err := r.FormFile("image")
+		if err != nil {
+			http.Error(w, "Image file required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadBytes+1))
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if int64(len(data)) > media.MaxUploadBytes {
+			writeUploadError(w, &http.MaxBytesError{Limit: media.MaxUploadBytes}, media.MaxUploadBytes)
+			return
+		}
+
+		altText := strings.TrimSpace(r.FormValue("alt_text"))
+		if len(altText) > 255 {
+			http.Error(w, "Alt text must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		img, err := media.store(productID, data, altText)
+		if err != nil {
+			writeImageError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(media.buildImageResponse(img))
+	}
+}
+
+// GetImagesHandler - List a product's images in display order
+func GetImagesHandler(media Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		productID, err := strconv.ParseInt(r.URL.Query().Get("product_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		images, err := media.Repo.GetImages(productID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(media.BuildImageResponses(images))
+	}
+}
+
+// ReorderImagesHandler - Set the display order of a product's images (staff only)
+func ReorderImagesHandler(media Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ReorderImagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.ProductID == 0 {
+			http.Error(w, "Product ID required", http.StatusBadRequest)
+			return
+		}
+
+		images, err := media.Repo.ReorderImages(req.ProductID, req.ImageIDs)
+		if err != nil {
+			writeImageError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(media.BuildImageResponses(images))
+	}
+}
+
+// DeleteImageHandler - Delete a product image and its files (staff only)
+func DeleteImageHandler(media Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req DeleteImageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.ProductID == 0 || req.ImageID == 0 {
+			http.Error(w, "Product ID and image ID required", http.StatusBadRequest)
+			return
+		}
+
+		img, err := media.Repo.DeleteImage(req.ProductID, req.ImageID)
+		if err != nil {
+			writeImageError(w, err)
+			return
+		}
+
+		// The image is already gone from the product, so files that fail to
+		// delete are only logged
+		media.removeBlobs(img)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ServeMediaHandler - Serve stored files below the media base URL
+func ServeMediaHandler(media Media) http.HandlerFunc {
+	prefix := strings.TrimRight(media.BaseURL, "/") + "/"
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, prefix)
+		blob, contentType, err := media.Store.Get(key)
+		if errors.Is(err, storage.ErrBlobNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer blob.Close()
+
+		// Keys are never reused, so files can be cached indefinitely
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		io.Copy(w, blob)
+	}
+}
+
+// store checks an upload is an image, writes it and its thumbnails to blob
+// storage under a new key and records it against the product. Files written
+// before a failure are removed again.
+func (m Media) store(productID int64, data []byte, altText string) (*domain.ProductImage, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedImage, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidImage, err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", errImageTooLarge, config.Width, config.Height)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidImage, err)
+	}
+
+	prefix := fmt.Sprintf("products/%d/%s", productID, newBlobID())
+	img := &domain.ProductImage{
+		ProductID:   productID,
+		Key:         prefix + "/original" + extension,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		SizeBytes:   int64(len(data)),
+		AltText:     altText,
+	}
+	if err := m.Store.Put(img.Key, data, contentType); err != nil {
+		return nil, err
+	}
+
+	thumbnails, err := m.storeThumbnails(prefix, decoded, contentType)
+	img.Thumbnails = thumbnails
+	if err != nil {
+		m.removeBlobs(img)
+		return nil, err
+	}
+
+	saved, err := m.Repo.AddImage(img)
+	if err != nil {
+		m.removeBlobs(img)
+		return nil, err
+	}
+	return saved, nil
+}
+
+// storeThumbnails writes a thumbnail in every configured size. Sizes are
+// generated from the largest down, each scaled from the one before, so the
+// full image is only read once.
+func (m Media) storeThumbnails(prefix string, src image.Image, contentType string) ([]domain.ImageThumbnail, error) {
+	names := make([]string, 0, len(m.ThumbnailSizes))
+	for name := range m.ThumbnailSizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return m.ThumbnailSizes[names[i]] > m.ThumbnailSizes[names[j]] })
+
+	thumbnails := make([]domain.ImageThumbnail, 0, len(names))
+	for _, name := range names {
+		scaled := scaleDown(src, m.ThumbnailSizes[name])
+		src = scaled
+
+		data, thumbType, err := encodeThumbnail(scaled, contentType)
+		if err != nil {
+			return thumbnails, err
+		}
+		thumbnail := domain.ImageThumbnail{
+			Size:   name,
+			Key:    prefix + "/" + name + imageExtensions[thumbType],
+			Width:  scaled.Bounds().Dx(),
+			Height: scaled.Bounds().Dy(),
+		}
+		if err := m.Store.Put(thumbnail.Key, data, thumbType); err != nil {
+			return thumbnails, err
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+	return thumbnails, nil
+}
+
+// removeBlobs deletes an image's files, logging failures
+func (m Media) removeBlobs(img *domain.ProductImage) {
+	keys := []string{img.Key}
+	for _, thumbnail := range img.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	for _, key := range keys {
+		if err := m.Store.Delete(key); err != nil {
+			log.Printf("media: delete %s: %v", key, err)
+		}
+	}
+}
+
+// buildImageResponse builds an image response with the URLs of its files
+func (m Media) buildImageResponse(img *domain.ProductImage) ImageResponse {
+	thumbnails := make(map[string]ThumbnailResponse, len(img.Thumbnails))
+	for _, thumbnail := range img.Thumbnails {
+		thumbnails[thumbnail.Size] = ThumbnailResponse{
+			URL:    m.URL(thumbnail.Key),
+			Width:  thumbnail.Width,
+			Height: thumbnail.Height,
+		}
+	}
+
+	return ImageResponse{
+		ID:          img.ID,
+		URL:         m.URL(img.Key),
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		SizeBytes:   img.SizeBytes,
+		AltText:     img.AltText,
+		Position:    img.Position,
+		Thumbnails:  thumbnails,
+	}
+}
+
+// Helper function to map errors reading an upload to HTTP responses
+func writeUploadError(w http.ResponseWriter, err error, limit int64) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Image exceeds the %d byte limit", limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, "Invalid multipart request", http.StatusBadRequest)
+}
+
+// Helper function to map image errors to HTTP responses
+func writeImageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrImageNotFound), errors.Is(err, infrastructure.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errUnsupportedImage):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, errInvalidImage), errors.Is(err, infrastructure.ErrInvalidImageOrder):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errImageTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to generate the random part of a new image's keys
+func newBlobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,298 @@
+package application
+
+import (
+	pricing "ecommerce-go/application/pricing"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/shipping"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CheckoutRequest struct {
+	UserID            int64  `json:"user_id"`
+	Country           string `json:"country"`
+	Region            string `json:"region"`
+	ShippingAddressID int64  `json:"shipping_address_id"`
+	BillingAddressID  int64  `json:"billing_address_id"`
+	ShippingMethod    string `json:"shipping_method"`
+}
+
+// OrderAddressResponse is an address as it was when the order was placed
+type OrderAddressResponse struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone,omitempty"`
+}
+
+type OrderItemResponse struct {
+	ID               int64        `json:"id"`
+	ProductID        int64        `json:"product_id"`
+	ProductName      string       `json:"product_name"`
+	Quantity         int          `json:"quantity"`
+	ReturnedQuantity int          `json:"returned_quantity"`
+	Price            domain.Money `json:"price"`
+	Subtotal         domain.Money `json:"subtotal"`
+	DiscountAmount   domain.Money `json:"discount_amount"`
+	TaxAmount        domain.Money `json:"tax_amount"`
+	TaxRate          string       `json:"tax_rate"`
+}
+
+type OrderResponse struct {
+	OrderID          int64                 `json:"order_id"`
+	UserID           int64                 `json:"user_id"`
+	Status           string                `json:"status"`
+	Items            []OrderItemResponse   `json:"items"`
+	DiscountAmount   domain.Money          `json:"discount_amount"`
+	CouponCode       string                `json:"coupon_code,omitempty"`
+	TaxAmount        domain.Money          `json:"tax_amount"`
+	PricesIncludeTax bool                  `json:"prices_include_tax"`
+	TaxCountry       string                `json:"tax_country,omitempty"`
+	TaxRegion        string                `json:"tax_region,omitempty"`
+	ShippingMethod   string                `json:"shipping_method,omitempty"`
+	ShippingAmount   domain.Money          `json:"shipping_amount"`
+	ShippingAddress  *OrderAddressResponse `json:"shipping_address,omitempty"`
+	BillingAddress   *OrderAddressResponse `json:"billing_address,omitempty"`
+	TotalAmount      domain.Money          `json:"total_amount"`
+	RefundedAmount   domain.Money          `json:"refunded_amount"`
+	NetAmount        domain.Money          `json:"net_amount"`
+	TotalItems       int                   `json:"total_items"`
+	CreatedAt        time.Time             `json:"created_at"`
+}
+
+// CheckoutHandler - Convert the user's active cart into an order. It ships to
+// the requested address or the user's default shipping address and is taxed
+// there; an order without either is not shipped and is taxed for the
+// requested country and region or the default tax location.
+func CheckoutHandler(repo infrastructure.OrderRepository, taxes pricing.Taxes, delivery pricing.Shipping) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CheckoutRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Validate input
+		if req.UserID == 0 {
+			http.Error(w, "User ID required", http.StatusBadRequest)
+			return
+		}
+
+		shipTo, err := delivery.Address(req.UserID, req.ShippingAddressID)
+		if err != nil {
+			writeCheckoutError(w, err)
+			return
+		}
+		if shipTo == nil && req.ShippingMethod != "" {
+			http.Error(w, "Shipping address required", http.StatusBadRequest)
+			return
+		}
+
+		// Bill the default billing address, or else the shipping address
+		opts := infrastructure.CheckoutOptions{
+			Taxes:            taxes.Calculator,
+			TaxLocation:      taxes.Location(req.Country, req.Region),
+			Shipping:         delivery.Provider,
+			ShippingMethod:   req.ShippingMethod,
+			BillingAddressID: req.BillingAddressID,
+		}
+		if shipTo != nil {
+			opts.ShippingAddressID = shipTo.ID
+		}
+		if opts.BillingAddressID == 0 {
+			billTo, err := delivery.Addresses.GetDefaultAddress(req.UserID, domain.AddressTypeBilling)
+			switch {
+			case err == nil:
+				opts.BillingAddressID = billTo.ID
+			case !errors.Is(err, infrastructure.ErrAddressNotFound):
+				writeCheckoutError(w, err)
+				return
+			default:
+				opts.BillingAddressID = opts.ShippingAddressID
+			}
+		}
+
+		order, err := repo.Checkout(req.UserID, opts)
+		if err != nil {
+			writeCheckoutError(w, err)
+			return
+		}
+
+		response := buildOrderResponse(order)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// Helper function to write a checkout error with a matching status
+func writeCheckoutError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrCartNotFound),
+		errors.Is(err, infrastructure.ErrAddressNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrCartEmpty),
+		errors.Is(err, infrastructure.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, infrastructure.ErrInsufficientStock),
+		errors.Is(err, infrastructure.ErrProductArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrCouponInactive),
+		errors.Is(err, domain.ErrCouponMinimumNotMet),
+		errors.Is(err, domain.ErrCouponNotApplicable),
+		errors.Is(err, domain.ErrCouponUsageExceeded),
+		errors.Is(err, infrastructure.ErrCouponNotFound),
+		errors.Is(err, shipping.ErrMethodUnavailable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GetOrderHandler - Get a single order by ID
+func GetOrderHandler(repo infrastructure.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		orderID := r.URL.Query().Get("id")
+		if orderID == "" {
+			http.Error(w, "Order ID required", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(orderID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+			return
+		}
+
+		order, err := repo.GetOrderByID(id)
+		if errors.Is(err, infrastructure.ErrOrderNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := buildOrderResponse(order)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// GetUserOrdersHandler - Get all orders of a user
+func GetUserOrdersHandler(repo infrastructure.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		userID := r.URL.Query().Get("user_id")
+		if userID == "" {
+			http.Error(w, "User ID required", http.StatusBadRequest)
+			return
+		}
+
+		uid, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		orders, err := repo.GetOrdersByUserID(uid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]OrderResponse, 0, len(orders))
+		for _, order := range orders {
+			response = append(response, buildOrderResponse(order))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// Helper function to build order response
+func buildOrderResponse(order *domain.Order) OrderResponse {
+	items := make([]OrderItemResponse, 0)
+	totalItems := 0
+
+	for _, item := range order.Items {
+		items = append(items, OrderItemResponse{
+			ID:               item.ID,
+			ProductID:        item.ProductID,
+			ProductName:      item.ProductName,
+			Quantity:         item.Quantity,
+			ReturnedQuantity: item.ReturnedQuantity,
+			Price:            item.Price,
+			Subtotal:         item.Price.Mul(int64(item.Quantity)),
+			DiscountAmount:   item.DiscountAmount,
+			TaxAmount:        item.TaxAmount,
+			TaxRate:          item.TaxRate,
+		})
+		totalItems += item.Quantity
+	}
+
+	return OrderResponse{
+		OrderID:          order.ID,
+		UserID:           order.UserID,
+		Status:           string(order.Status),
+		Items:            items,
+		DiscountAmount:   order.DiscountAmount,
+		CouponCode:       order.CouponCode,
+		TaxAmount:        order.TaxAmount,
+		PricesIncludeTax: order.PricesIncludeTax,
+		TaxCountry:       order.TaxCountry,
+		TaxRegion:        order.TaxRegion,
+		ShippingMethod:   order.ShippingMethod,
+		ShippingAmount:   order.ShippingAmount,
+		ShippingAddress:  buildOrderAddressResponse(order.ShippingAddress),
+		BillingAddress:   buildOrderAddressResponse(order.BillingAddress),
+		TotalAmount:      order.TotalAmount,
+		RefundedAmount:   order.RefundedAmount,
+		NetAmount:        order.TotalAmount.Sub(order.RefundedAmount),
+		TotalItems:       totalItems,
+		CreatedAt:        order.CreatedAt,
+	}
+}
+
+// Helper function to build an order address response
+func buildOrderAddressResponse(address *domain.Address) *OrderAddressResponse {
+	if address == nil {
+		return nil
+	}
+	return &OrderAddressResponse{
+		Name:       address.Name,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		Phone:      address.Phone,
+	}
+}
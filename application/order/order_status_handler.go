@@ -0,0 +1,119 @@
+package application
+
+import (
+	staff "ecommerce-go/application/staff"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type UpdateOrderStatusRequest struct {
+	OrderID int64  `json:"order_id"`
+	Status  string `json:"status"`
+	Note    string `json:"note"`
+}
+
+type OrderStatusChangeResponse struct {
+	ID         int64     `json:"id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  int64     `json:"changed_by"`
+	Note       string    `json:"note"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// UpdateOrderStatusHandler - Advance an order through its lifecycle (staff
+// only). Payments move orders to paid and refunded.
+func UpdateOrderStatusHandler(repo infrastructure.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req UpdateOrderStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Validate input
+		staffID := staff.StaffID(r)
+		if req.OrderID == 0 || staffID == 0 || req.Status == "" {
+			http.Error(w, "Order ID, staff ID and status required", http.StatusBadRequest)
+			return
+		}
+
+		order, err := repo.UpdateOrderStatus(req.OrderID, domain.OrderStatus(req.Status), staffID, req.Note)
+		if err != nil {
+			writeOrderStatusError(w, err)
+			return
+		}
+
+		response := buildOrderResponse(order)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// GetOrderHistoryHandler - List the status changes of an order (staff only)
+func GetOrderHistoryHandler(repo infrastructure.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		orderID := r.URL.Query().Get("id")
+		if orderID == "" {
+			http.Error(w, "Order ID required", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(orderID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+			return
+		}
+
+		history, err := repo.GetOrderHistory(id)
+		if err != nil {
+			writeOrderStatusError(w, err)
+			return
+		}
+
+		response := make([]OrderStatusChangeResponse, 0, len(history))
+		for _, change := range history {
+			response = append(response, OrderStatusChangeResponse{
+				ID:         change.ID,
+				FromStatus: string(change.FromStatus),
+				ToStatus:   string(change.ToStatus),
+				ChangedBy:  change.ChangedBy,
+				Note:       change.Note,
+				ChangedAt:  change.ChangedAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// Helper function to map order status errors to HTTP responses
+func writeOrderStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrInvalidStatus), errors.Is(err, infrastructure.ErrPaymentStatus):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, infrastructure.ErrInvalidTransition), errors.Is(err, infrastructure.ErrOrderHasPayment):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,357 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/payment"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header identifying a payment attempt
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type AuthorizePaymentRequest struct {
+	OrderID       int64  `json:"order_id"`
+	PaymentMethod string `json:"payment_method"`
+}
+
+type PaymentActionRequest struct {
+	PaymentID int64 `json:"payment_id"`
+}
+
+type RefundPaymentRequest struct {
+	PaymentID int64        `json:"payment_id"`
+	Amount    domain.Money `json:"amount"`
+}
+
+type PaymentResponse struct {
+	ID             int64        `json:"id"`
+	OrderID        int64        `json:"order_id"`
+	Provider       string       `json:"provider"`
+	Amount         domain.Money `json:"amount"`
+	RefundedAmount domain.Money `json:"refunded_amount"`
+	Currency       string       `json:"currency"`
+	Status         string       `json:"status"`
+	Message        string       `json:"message,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// AuthorizePaymentHandler - Create an idempotent payment intent for an order.
+// An order has at most one live payment; another key is refused while it lasts.
+func AuthorizePaymentHandler(repo infrastructure.PaymentRepository, orderRepo infrastructure.OrderRepository, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			http.Error(w, "Idempotency-Key header required", http.StatusBadRequest)
+			return
+		}
+
+		var req AuthorizePaymentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Validate input
+		if req.OrderID == 0 {
+			http.Error(w, "Order ID required", http.StatusBadRequest)
+			return
+		}
+
+		// Replaying a key returns the payment created by the first request
+		existing, err := repo.GetPaymentByIdempotencyKey(key)
+		if err == nil {
+			writePayment(w, http.StatusOK, existing)
+			return
+		}
+		if !errors.Is(err, infrastructure.ErrPaymentNotFound) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		order, err := orderRepo.GetOrderByID(req.OrderID)
+		if errors.Is(err, infrastructure.ErrOrderNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if order.Status != domain.OrderStatusPending {
+			http.Error(w, "Order is not awaiting payment", http.StatusConflict)
+			return
+		}
+
+		created, err := repo.CreatePayment(&domain.Payment{
+			OrderID:        order.ID,
+			Provider:       provider.Name(),
+			IdempotencyKey: key,
+			Amount:         order.TotalAmount,
+			Currency:       order.TotalAmount.Currency,
+			Status:         domain.PaymentStatusPending,
+		})
+		if errors.Is(err, infrastructure.ErrDuplicatePayment) {
+			existing, err := repo.GetPaymentByIdempotencyKey(key)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writePayment(w, http.StatusOK, existing)
+			return
+		}
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+
+		result, err := provider.Authorize(payment.AuthorizeRequest{
+			IdempotencyKey: key,
+			Amount:         created.Amount,
+			Currency:       created.Currency,
+			PaymentMethod:  req.PaymentMethod,
+		})
+		if err != nil {
+			repo.UpdatePaymentStatus(created.ID, domain.PaymentStatusFailed, "", err.Error())
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		updated, err := repo.UpdatePaymentStatus(created.ID, result.Status, result.Reference, result.Message)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writePayment(w, http.StatusCreated, updated)
+	}
+}
+
+// CapturePaymentHandler - Capture an authorized payment and mark the order paid
+func CapturePaymentHandler(repo infrastructure.PaymentRepository, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req PaymentActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		p, ok := loadPayment(w, repo, req.PaymentID, domain.PaymentStatusCaptured)
+		if !ok {
+			return
+		}
+
+		result, err := provider.Capture(p.ProviderRef, p.Amount)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		updated, err := repo.UpdatePaymentStatus(p.ID, result.Status, result.Reference, result.Message)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+
+		writePayment(w, http.StatusOK, updated)
+	}
+}
+
+// VoidPaymentHandler - Cancel an authorized payment
+func VoidPaymentHandler(repo infrastructure.PaymentRepository, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req PaymentActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		p, ok := loadPayment(w, repo, req.PaymentID, domain.PaymentStatusVoided)
+		if !ok {
+			return
+		}
+
+		result, err := provider.Void(p.ProviderRef)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		updated, err := repo.UpdatePaymentStatus(p.ID, result.Status, result.Reference, result.Message)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+
+		writePayment(w, http.StatusOK, updated)
+	}
+}
+
+// RefundPaymentHandler - Refund part or all of a captured payment
+func RefundPaymentHandler(repo infrastructure.PaymentRepository, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req RefundPaymentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Any refundable payment can accept a partial refund
+		p, ok := loadPayment(w, repo, req.PaymentID, domain.PaymentStatusPartiallyRefunded)
+		if !ok {
+			return
+		}
+
+		// The refund is reserved against the payment before the provider is
+		// called, so concurrent requests cannot refund more than was captured.
+		// Without an amount the remaining balance is refunded.
+		refund, err := repo.ReserveRefund(p.ID, req.Amount)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+
+		if _, err := provider.Refund(p.ProviderRef, refund.Amount, refund.IdempotencyKey); err != nil {
+			if releaseErr := repo.ReleaseRefund(refund.ID); releaseErr != nil {
+				log.Printf("payment %d: releasing refund %d: %v", p.ID, refund.ID, releaseErr)
+			}
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		updated, err := repo.ConfirmRefund(refund.ID)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+
+		writePayment(w, http.StatusOK, updated)
+	}
+}
+
+// GetOrderPaymentsHandler - List the payments of an order
+func GetOrderPaymentsHandler(repo infrastructure.PaymentRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		orderID := r.URL.Query().Get("order_id")
+		if orderID == "" {
+			http.Error(w, "Order ID required", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(orderID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+			return
+		}
+
+		payments, err := repo.GetPaymentsByOrderID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]PaymentResponse, 0, len(payments))
+		for _, p := range payments {
+			response = append(response, buildPaymentResponse(p))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// Helper function to load a payment and check it can move to next
+func loadPayment(w http.ResponseWriter, repo infrastructure.PaymentRepository, id int64, next domain.PaymentStatus) (*domain.Payment, bool) {
+	if id == 0 {
+		http.Error(w, "Payment ID required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	p, err := repo.GetPaymentByID(id)
+	if err != nil {
+		writePaymentError(w, err)
+		return nil, false
+	}
+
+	if !p.Status.CanTransitionTo(next) {
+		http.Error(w, "Payment cannot be "+string(next)+" from status "+string(p.Status), http.StatusConflict)
+		return nil, false
+	}
+
+	return p, true
+}
+
+// Helper function to map payment errors to HTTP responses
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrPaymentNotFound),
+		errors.Is(err, infrastructure.ErrRefundNotFound),
+		errors.Is(err, infrastructure.ErrOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrRefundExceedsPayment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, infrastructure.ErrInvalidPaymentTransition),
+		errors.Is(err, infrastructure.ErrRefundNotPending),
+		errors.Is(err, infrastructure.ErrOrderNotAwaitingPayment),
+		errors.Is(err, infrastructure.ErrOrderPaymentInProgress),
+		errors.Is(err, infrastructure.ErrInvalidTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to write a payment as JSON
+func writePayment(w http.ResponseWriter, status int, p *domain.Payment) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(buildPaymentResponse(p))
+}
+
+// Helper function to build payment response
+func buildPaymentResponse(p *domain.Payment) PaymentResponse {
+	return PaymentResponse{
+		ID:             p.ID,
+		OrderID:        p.OrderID,
+		Provider:       p.Provider,
+		Amount:         p.Amount,
+		RefundedAmount: p.RefundedAmount,
+		Currency:       p.Currency,
+		Status:         string(p.Status),
+		Message:        p.Message,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
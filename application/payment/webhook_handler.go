@@ -0,0 +1,57 @@
+package application
+
+import (
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/payment"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// maxWebhookBytes bounds the size of an accepted webhook payload
+const maxWebhookBytes = 64 << 10
+
+// PaymentWebhookHandler - Apply asynchronous status updates from the provider
+func PaymentWebhookHandler(repo infrastructure.PaymentRepository, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		event, err := provider.ParseWebhook(payload, r.Header.Get(payment.SignatureHeader))
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Invalid webhook payload", http.StatusBadRequest)
+			return
+		}
+
+		p, err := repo.GetPaymentByProviderRef(provider.Name(), event.Reference)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+
+		// Providers redeliver webhooks; only the first delivery is applied
+		_, err = repo.ApplyWebhookEvent(provider.Name(), event.ID, p.ID, event.Status, event.Reference, event.Message)
+		if errors.Is(err, infrastructure.ErrInvalidPaymentTransition) {
+			// Stale or out-of-order event; acknowledge so it is not retried
+			log.Printf("payment webhook %s ignored: %v", event.ID, err)
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,50 @@
+package application
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// CurrencyHeader is the request header selecting the display currency
+const CurrencyHeader = "X-Currency"
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// Currencies holds the storefront currency settings
+type Currencies struct {
+	Default   string
+	Supported []string
+}
+
+// FromRequest returns the currency selected by the "currency" query
+// parameter or the X-Currency header, in that order, and whether the
+// request selected one at all. Without a selection the default is returned.
+func (c Currencies) FromRequest(r *http.Request) (string, bool, error) {
+	currency := r.URL.Query().Get("currency")
+	if currency == "" {
+		currency = r.Header.Get(CurrencyHeader)
+	}
+	if currency == "" {
+		return c.Default, false, nil
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !c.IsSupported(currency) {
+		return "", true, ErrUnsupportedCurrency
+	}
+	return currency, true, nil
+}
+
+// IsSupported reports whether currency may be selected
+func (c Currencies) IsSupported(currency string) bool {
+	if currency == c.Default {
+		return true
+	}
+	for _, supported := range c.Supported {
+		if supported == currency {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package application
+
+import (
+	"ecommerce-go/config"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type ExchangeRateResponse struct {
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetExchangeRatesHandler - List the stored exchange rates
+func GetExchangeRatesHandler(repo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rates, err := repo.GetRates()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeExchangeRates(w, rates)
+	}
+}
+
+// ReloadExchangeRatesHandler - Reload the exchange rates from the rates file (staff only)
+func ReloadExchangeRatesHandler(repo infrastructure.CurrencyRepository, ratesFile string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rates, err := config.LoadExchangeRates(ratesFile)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if err := repo.SaveRates(rates); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rates, err = repo.GetRates()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeExchangeRates(w, rates)
+	}
+}
+
+// Helper function to write exchange rates as JSON
+func writeExchangeRates(w http.ResponseWriter, rates []*domain.ExchangeRate) {
+	response := make([]ExchangeRateResponse, 0, len(rates))
+	for _, rate := range rates {
+		response = append(response, ExchangeRateResponse{
+			Base:      rate.Base,
+			Quote:     rate.Quote,
+			Rate:      rate.Rate.FloatString(8),
+			UpdatedAt: rate.UpdatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/shipping"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+var ErrInvalidAddressID = errors.New("invalid address ID")
+
+// Shipping holds the shipping rate source and the address books carts are
+// shipped to
+type Shipping struct {
+	Provider  shipping.RateProvider
+	Addresses infrastructure.AddressRepository
+}
+
+// Address returns the user's address with the given ID, or their default
+// shipping address when id is zero. It returns nil without an error when
+// the user has no default.
+func (s Shipping) Address(userID, id int64) (*domain.Address, error) {
+	if id != 0 {
+		return s.Addresses.GetAddressByID(userID, id)
+	}
+
+	address, err := s.Addresses.GetDefaultAddress(userID, domain.AddressTypeShipping)
+	if errors.Is(err, infrastructure.ErrAddressNotFound) {
+		return nil, nil
+	}
+	return address, err
+}
+
+// FromRequest returns the address selected by the "address_id" query
+// parameter, falling back to the user's default shipping address
+func (s Shipping) FromRequest(r *http.Request, userID int64) (*domain.Address, error) {
+	var id int64
+	if param := r.URL.Query().Get("address_id"); param != "" {
+		var err error
+		id, err = strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidAddressID
+		}
+	}
+	return s.Address(userID, id)
+}
@@ -0,0 +1,28 @@
+package application
+
+import (
+	"ecommerce-go/infrastructure/tax"
+	"net/http"
+)
+
+// Taxes holds the tax engine and the location carts are taxed in when the
+// customer has not said where they are
+type Taxes struct {
+	Calculator tax.Calculator
+	Default    tax.Location
+}
+
+// Location returns the location for a country and region, or the default
+// when no country is given
+func (t Taxes) Location(country, region string) tax.Location {
+	if country == "" {
+		return t.Default.Normalize()
+	}
+	return tax.Location{Country: country, Region: region}.Normalize()
+}
+
+// FromRequest returns the location selected by the "country" and "region"
+// query parameters
+func (t Taxes) FromRequest(r *http.Request) tax.Location {
+	return t.Location(r.URL.Query().Get("country"), r.URL.Query().Get("region"))
+}
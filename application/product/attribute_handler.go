@@ -0,0 +1,214 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type CreateAttributeRequest struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Filterable *bool  `json:"filterable"`
+}
+
+type SetProductAttributesRequest struct {
+	ProductID  int64             `json:"product_id"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type AttributeResponse struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Filterable bool   `json:"filterable"`
+}
+
+// GetAttributesHandler - List the attributes products can carry
+func GetAttributesHandler(repo infrastructure.AttributeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		attributes, err := repo.GetAttributes()
+		if err != nil {
+			http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]AttributeResponse, 0, len(attributes))
+		for _, attribute := range attributes {
+			response = append(response, buildAttributeResponse(attribute))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// CreateAttributeHandler - Define a new product attribute, filterable
+// unless stated otherwise (staff only)
+func CreateAttributeHandler(repo infrastructure.AttributeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CreateAttributeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		attribute := &domain.Attribute{
+			Code:       req.Code,
+			Name:       req.Name,
+			Type:       domain.AttributeType(req.Type),
+			Filterable: req.Filterable == nil || *req.Filterable,
+		}
+		if attribute.Type == "" {
+			attribute.Type = domain.AttributeText
+		}
+		if err := attribute.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		created, err := repo.CreateAttribute(attribute)
+		if err != nil {
+			writeAttributeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(buildAttributeResponse(created))
+	}
+}
+
+// DeleteAttributeHandler - Remove an attribute from every product (staff only)
+func DeleteAttributeHandler(repo infrastructure.AttributeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "Attribute code required", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.DeleteAttribute(code); err != nil {
+			writeAttributeError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// SetProductAttributesHandler - Replace a product's attribute values (staff only)
+func SetProductAttributesHandler(repo infrastructure.AttributeRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SetProductAttributesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.ProductID == 0 {
+			http.Error(w, "Product ID required", http.StatusBadRequest)
+			return
+		}
+
+		attributes, err := attributesByCode(repo)
+		if err != nil {
+			http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+			return
+		}
+
+		// Store values in canonical form so filters match them exactly
+		values := make(map[string]string, len(req.Attributes))
+		for code, value := range req.Attributes {
+			attribute, ok := attributes[code]
+			if !ok {
+				http.Error(w, infrastructure.ErrAttributeNotFound.Error()+": "+code, http.StatusBadRequest)
+				return
+			}
+			values[code], err = attribute.Normalize(value)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := repo.SetProductAttributes(req.ProductID, values); err != nil {
+			writeAttributeError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SetProductAttributesRequest{ProductID: req.ProductID, Attributes: values})
+	}
+}
+
+// Helper function to load the attribute definitions keyed by code
+func attributesByCode(repo infrastructure.AttributeRepository) (map[string]*domain.Attribute, error) {
+	attributes, err := repo.GetAttributes()
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*domain.Attribute, len(attributes))
+	for _, attribute := range attributes {
+		byCode[attribute.Code] = attribute
+	}
+	return byCode, nil
+}
+
+// Helper function to build an attribute response
+func buildAttributeResponse(attribute *domain.Attribute) AttributeResponse {
+	return AttributeResponse{
+		Code:       attribute.Code,
+		Name:       attribute.Name,
+		Type:       string(attribute.Type),
+		Filterable: attribute.Filterable,
+	}
+}
+
+// Helper function to map attribute errors to HTTP responses
+func writeAttributeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrAttributeNotFound),
+		errors.Is(err, infrastructure.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrAttributeExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to read an attribute value map for one product
+func productAttributeValues(repo infrastructure.AttributeRepository, productID int64) (map[string]string, error) {
+	values, err := repo.GetProductAttributes([]int64{productID})
+	if err != nil {
+		return nil, err
+	}
+	if values[productID] == nil {
+		return map[string]string{}, nil
+	}
+	return values[productID], nil
+}
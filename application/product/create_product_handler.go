@@ -1,78 +1,120 @@
 package application
 
 import (
+	etag "ecommerce-go/application/etag"
+	pricing "ecommerce-go/application/pricing"
+	staff "ecommerce-go/application/staff"
 	"ecommerce-go/domain"
 	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/tax"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
+// maxSKULength is the longest SKU the Product table holds
+const maxSKULength = 64
+
 type CreateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	SKU         string       `json:"sku"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Category    string       `json:"category"`
+	TaxClass    string       `json:"tax_class"`
+	WeightGrams int          `json:"weight_grams"`
+	Price       domain.Money `json:"price"`
+	Stock       int          `json:"stock"`
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	SKU         string       `json:"sku"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Category    string       `json:"category"`
+	TaxClass    string       `json:"tax_class"`
+	WeightGrams int          `json:"weight_grams"`
+	Price       domain.Money `json:"price"`
+	Stock       *int         `json:"stock"` // unchanged when omitted
 }
 
 type ProductResponse struct {
-	ID          int64   `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	ID          int64        `json:"id"`
+	SKU         string       `json:"sku,omitempty"`
+	Slug        string       `json:"slug"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Category    string       `json:"category"`
+	TaxClass    string       `json:"tax_class"`
+	WeightGrams int          `json:"weight_grams"`
+	Price       domain.Money `json:"price"`
+	Stock       int          `json:"stock"`
+	Version     int64        `json:"version"`
 }
 
-func CreateProductHandler(repo infrastructure.ProductRepository) http.HandlerFunc {
+func CreateProductHandler(repo infrastructure.ProductRepository, currencies pricing.Currencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
+		// A bare price amount is in the default currency
 		var req CreateProductRequest
+		req.Price.Currency = currencies.Default
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
 
 		// Validate input
-		if req.Name == "" || req.Price <= 0 {
+		req.SKU = strings.TrimSpace(req.SKU)
+		if req.Name == "" || len(req.SKU) > maxSKULength || !req.Price.IsPositive() || !currencies.IsSupported(req.Price.Currency) || req.Stock < 0 || req.WeightGrams < 0 {
 			http.Error(w, "Invalid product data", http.StatusBadRequest)
 			return
 		}
 
 		// Create product
 		product := &domain.Product{
+			SKU:         req.SKU,
 			Name:        req.Name,
 			Description: req.Description,
+			Category:    req.Category,
+			TaxClass:    taxClass(req.TaxClass),
+			WeightGrams: req.WeightGrams,
 			Price:       req.Price,
+			Stock:       req.Stock,
 		}
 
 		id, err := repo.Create(product)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeProductChangeError(w, err)
 			return
 		}
 
 		response := ProductResponse{
 			ID:          id,
+			SKU:         product.SKU,
+			Slug:        product.Slug,
 			Name:        product.Name,
 			Description: product.Description,
+			Category:    product.Category,
+			TaxClass:    product.TaxClass,
+			WeightGrams: product.WeightGrams,
 			Price:       product.Price,
+			Stock:       product.Stock,
+			Version:     product.Version,
 		}
 
+		etag.Set(w, product.Version)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-func UpdateProductHandler(repo infrastructure.ProductRepository) http.HandlerFunc {
+func UpdateProductHandler(repo infrastructure.ProductRepository, currencies pricing.Currencies) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPut {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -92,38 +134,59 @@ func UpdateProductHandler(repo infrastructure.ProductRepository) http.HandlerFun
 			return
 		}
 
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
 		var req UpdateProductRequest
+		req.Price.Currency = currencies.Default
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
 
 		// Validate input
-		if req.Name == "" || req.Price <= 0 {
+		req.SKU = strings.TrimSpace(req.SKU)
+		if req.Name == "" || len(req.SKU) > maxSKULength || !req.Price.IsPositive() || !currencies.IsSupported(req.Price.Currency) || (req.Stock != nil && *req.Stock < 0) || req.WeightGrams < 0 {
 			http.Error(w, "Invalid product data", http.StatusBadRequest)
 			return
 		}
 
+		stock := domain.UnchangedStock
+		if req.Stock != nil {
+			stock = *req.Stock
+		}
+
 		// Update product
 		product := &domain.Product{
 			ID:          id,
+			SKU:         req.SKU,
 			Name:        req.Name,
 			Description: req.Description,
+			Category:    req.Category,
+			TaxClass:    taxClass(req.TaxClass),
+			WeightGrams: req.WeightGrams,
 			Price:       req.Price,
+			Stock:       stock,
+			Version:     version,
 		}
 
-		err = repo.Update(product)
+		err = repo.Update(product, staff.StaffID(r), domain.PriceChangeManual)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeProductChangeError(w, err)
 			return
 		}
 
+		etag.Set(w, product.Version)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(product)
 	}
 }
 
-// DeleteProductHandler - Delete a product
+// DeleteProductHandler - Archive a product, hiding it from the catalog and
+// stopping new purchases. Carts and orders holding it are unaffected.
 func DeleteProductHandler(repo infrastructure.ProductRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodDelete {
@@ -144,10 +207,16 @@ func DeleteProductHandler(repo infrastructure.ProductRepository) http.HandlerFun
 			return
 		}
 
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
 		// Delete product
-		err = repo.Delete(id)
+		err = repo.Delete(id, version)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeProductChangeError(w, err)
 			return
 		}
 
@@ -155,3 +224,91 @@ func DeleteProductHandler(repo infrastructure.ProductRepository) http.HandlerFun
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// RestoreProductHandler - Bring back an archived product (staff only)
+func RestoreProductHandler(repo infrastructure.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil || id <= 0 {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		if err := repo.Restore(id, version); err != nil {
+			writeProductChangeError(w, err)
+			return
+		}
+
+		product, err := repo.GetByID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+
+		etag.Set(w, product.Version)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(product)
+	}
+}
+
+// GetArchivedProductsHandler - List archived products, most recently
+// archived first (staff only)
+func GetArchivedProductsHandler(repo infrastructure.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		products, err := repo.GetArchived()
+		if err != nil {
+			http.Error(w, "Failed to fetch products", http.StatusInternalServerError)
+			return
+		}
+		if products == nil {
+			products = []*domain.Product{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(products)
+	}
+}
+
+// Helper function to map errors changing a product to HTTP responses
+func writeProductChangeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrVersionConflict):
+		http.Error(w, "Product was modified by another request", http.StatusPreconditionFailed)
+	case errors.Is(err, infrastructure.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrSKUExists),
+		errors.Is(err, infrastructure.ErrProductArchived),
+		errors.Is(err, infrastructure.ErrProductNotArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function defaulting the tax class of a product
+func taxClass(class string) string {
+	if class == "" {
+		return tax.ClassStandard
+	}
+	return class
+}
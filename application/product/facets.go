@@ -0,0 +1,117 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// attributeFilterPrefix marks the query parameters filtering products by an
+// attribute, such as attr.colour=red. Repeating a parameter selects products
+// with any of its values; different attributes must all match.
+const attributeFilterPrefix = "attr."
+
+// attributeFilters maps each filtered attribute code to its selected values
+type attributeFilters map[string]map[string]bool
+
+type Facet struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Type   string       `json:"type"`
+	Values []FacetValue `json:"values"`
+}
+
+type FacetValue struct {
+	Value    string `json:"value"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected"`
+}
+
+// Helper function to read the attribute filters of a product listing.
+// Only filterable attributes can be filtered by.
+func parseAttributeFilters(query url.Values, attributes map[string]*domain.Attribute) (attributeFilters, error) {
+	filters := make(attributeFilters)
+	for param, values := range query {
+		if !strings.HasPrefix(param, attributeFilterPrefix) {
+			continue
+		}
+		code := strings.TrimPrefix(param, attributeFilterPrefix)
+		attribute, ok := attributes[code]
+		if !ok || !attribute.Filterable {
+			return nil, fmt.Errorf("cannot filter by attribute %q", code)
+		}
+
+		selected := make(map[string]bool, len(values))
+		for _, value := range values {
+			normalized, err := attribute.Normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			selected[normalized] = true
+		}
+		filters[code] = selected
+	}
+	return filters, nil
+}
+
+// matches reports whether a product with values passes every filter except
+// the one on skip
+func (f attributeFilters) matches(values map[string]string, skip string) bool {
+	for code, selected := range f {
+		if code == skip {
+			continue
+		}
+		if !selected[values[code]] {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper function to count the products having each value of each
+// filterable attribute. An attribute's counts apply the filters on the other
+// attributes but not its own, so selecting one value leaves its alternatives
+// available to add. Selected values are listed even when no product has them.
+func buildFacets(attributes []*domain.Attribute, products []*domain.Product, values map[int64]map[string]string, filters attributeFilters) []Facet {
+	facets := make([]Facet, 0, len(attributes))
+	for _, attribute := range attributes {
+		if !attribute.Filterable {
+			continue
+		}
+
+		counts := make(map[string]int)
+		for value := range filters[attribute.Code] {
+			counts[value] = 0
+		}
+		for _, p := range products {
+			value, ok := values[p.ID][attribute.Code]
+			if ok && filters.matches(values[p.ID], attribute.Code) {
+				counts[value]++
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+
+		facet := Facet{
+			Code:   attribute.Code,
+			Name:   attribute.Name,
+			Type:   string(attribute.Type),
+			Values: make([]FacetValue, 0, len(counts)),
+		}
+		for value, count := range counts {
+			facet.Values = append(facet.Values, FacetValue{
+				Value:    value,
+				Count:    count,
+				Selected: filters[attribute.Code][value],
+			})
+		}
+		sort.Slice(facet.Values, func(i, j int) bool {
+			return attribute.LessValue(facet.Values[i].Value, facet.Values[j].Value)
+		})
+		facets = append(facets, facet)
+	}
+	return facets
+}
@@ -1,16 +1,52 @@
 package application
 
 import (
+	etag "ecommerce-go/application/etag"
+	locale "ecommerce-go/application/locale"
+	media "ecommerce-go/application/media"
+	pricing "ecommerce-go/application/pricing"
+	recommendation "ecommerce-go/application/recommendation"
+	"ecommerce-go/domain"
 	infrastructure "ecommerce-go/infrastructure/mysql"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
-func GetAllProductsHandler(productRepo infrastructure.ProductRepository) http.HandlerFunc {
+// ProductDetails is a product with the summary of its approved reviews, its
+// images in display order and its attribute values by code. Name and
+// Description are localized, with Locale the locale of the name. A single
+// product also comes with its recommendations.
+type ProductDetails struct {
+	*domain.Product
+	Locale          string
+	Rating          domain.RatingSummary
+	Images          []media.ImageResponse
+	Attributes      map[string]string
+	Recommendations *recommendation.RecommendationsResponse `json:",omitempty"`
+}
+
+// ProductListResponse is a product listing with the facets to narrow it
+// down, returned when facets are requested
+type ProductListResponse struct {
+	Products []ProductDetails `json:"products"`
+	Facets   []Facet          `json:"facets"`
+}
+
+// GetAllProductsHandler - List products in the request's language, narrowed
+// down by a search for "q" and attribute filters such as attr.colour=red,
+// with facet counts when "facets=true". Search results come best match first.
+func GetAllProductsHandler(productRepo infrastructure.ProductRepository, reviewRepo infrastructure.ReviewRepository, images media.Media, attributeRepo infrastructure.AttributeRepository, translationRepo infrastructure.TranslationRepository, currencyRepo infrastructure.CurrencyRepository, currencies pricing.Currencies, locales locale.Locales) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
+		currency, requested, err := currencies.FromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		product, err := productRepo.GetAll()
 		if err != nil {
 			http.Error(w, "Failed to fetch product", http.StatusInternalServerError)
@@ -22,12 +58,131 @@ func GetAllProductsHandler(productRepo infrastructure.ProductRepository) http.Ha
 			return
 		}
 
+		chain := locales.FromRequest(r)
+		if query := strings.TrimSpace(r.URL.Query().Get("q")); query != "" {
+			found, err := translationRepo.SearchProducts(query, translatedLocales(chain, locales.Default))
+			if err != nil {
+				http.Error(w, "Failed to search products", http.StatusInternalServerError)
+				return
+			}
+			byID := make(map[int64]*domain.Product, len(product))
+			for _, p := range product {
+				byID[p.ID] = p
+			}
+			product = make([]*domain.Product, 0, len(found))
+			for _, id := range found {
+				if p, ok := byID[id]; ok {
+					product = append(product, p)
+				}
+			}
+		}
+
+		withFacets := false
+		if value := r.URL.Query().Get("facets"); value != "" {
+			withFacets, err = strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, "Invalid facets", http.StatusBadRequest)
+				return
+			}
+		}
+
+		attributes, err := attributeRepo.GetAttributes()
+		if err != nil {
+			http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+			return
+		}
+		byCode := make(map[string]*domain.Attribute, len(attributes))
+		for _, attribute := range attributes {
+			byCode[attribute.Code] = attribute
+		}
+		filters, err := parseAttributeFilters(r.URL.Query(), byCode)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ids := make([]int64, 0, len(product))
+		for _, p := range product {
+			ids = append(ids, p.ID)
+		}
+		attributeValues, err := attributeRepo.GetProductAttributes(ids)
+		if err != nil {
+			http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+			return
+		}
+
+		// Facets are counted over every product, each ignoring its own filter
+		var facets []Facet
+		if withFacets {
+			facets = buildFacets(attributes, product, attributeValues, filters)
+		}
+
+		matching := make([]*domain.Product, 0, len(product))
+		for _, p := range product {
+			if filters.matches(attributeValues[p.ID], "") {
+				matching = append(matching, p)
+			}
+		}
+		product = matching
+
+		if requested {
+			for _, p := range product {
+				if err := priceProduct(currencyRepo, p, currency); err != nil {
+					writePriceError(w, err)
+					return
+				}
+			}
+		}
+
+		ids = ids[:0]
+		for _, p := range product {
+			ids = append(ids, p.ID)
+		}
+		ratings, err := reviewRepo.GetRatingSummaries(ids)
+		if err != nil {
+			http.Error(w, "Failed to fetch ratings", http.StatusInternalServerError)
+			return
+		}
+
+		productImages, err := images.Repo.GetImagesByProductIDs(ids)
+		if err != nil {
+			http.Error(w, "Failed to fetch images", http.StatusInternalServerError)
+			return
+		}
+
+		contentLocales, err := localizeProducts(translationRepo, product, chain, locales.Default)
+		if err != nil {
+			http.Error(w, "Failed to fetch translations", http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]ProductDetails, 0, len(product))
+		for _, p := range product {
+			values := attributeValues[p.ID]
+			if values == nil {
+				values = map[string]string{}
+			}
+			response = append(response, ProductDetails{
+				Product:    p,
+				Locale:     contentLocales[p.ID],
+				Rating:     ratings[p.ID],
+				Images:     images.BuildImageResponses(productImages[p.ID]),
+				Attributes: values,
+			})
+		}
+
+		locale.SetHeaders(w, chain[0])
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(product)
+		if withFacets {
+			json.NewEncoder(w).Encode(ProductListResponse{Products: response, Facets: facets})
+			return
+		}
+		json.NewEncoder(w).Encode(response)
 	}
 }
 
-func GetProductHandler(productRepo infrastructure.ProductRepository) http.HandlerFunc {
+// GetProductHandler - Get a single product in the request's language
+func GetProductHandler(productRepo infrastructure.ProductRepository, reviewRepo infrastructure.ReviewRepository, images media.Media, attributeRepo infrastructure.AttributeRepository, translationRepo infrastructure.TranslationRepository, currencyRepo infrastructure.CurrencyRepository, recommender recommendation.Recommender, currencies pricing.Currencies, locales locale.Locales) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		idParam := r.URL.Query().Get("id")
@@ -48,12 +203,117 @@ func GetProductHandler(productRepo infrastructure.ProductRepository) http.Handle
 			return
 		}
 
-		if product == nil {
+		if product == nil || product.IsArchived() {
 			http.Error(w, "Product not found", http.StatusNotFound)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(product)
+		writeProductDetails(w, r, product, reviewRepo, images, attributeRepo, translationRepo, currencyRepo, recommender, currencies, locales)
+	}
+}
+
+// GetProductBySlugHandler - Get a single product by its slug in the
+// request's language. A slug the product had before redirects permanently
+// to its current one.
+func GetProductBySlugHandler(productRepo infrastructure.ProductRepository, reviewRepo infrastructure.ReviewRepository, images media.Media, attributeRepo infrastructure.AttributeRepository, translationRepo infrastructure.TranslationRepository, currencyRepo infrastructure.CurrencyRepository, recommender recommendation.Recommender, currencies pricing.Currencies, locales locale.Locales) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		slug := r.PathValue("slug")
+		product, err := productRepo.GetBySlug(slug)
+		if err != nil {
+			http.Error(w, "Failed to fetch product", http.StatusInternalServerError)
+			return
+		}
+
+		if product == nil || product.IsArchived() {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+
+		if product.Slug != slug {
+			target := "/products/" + url.PathEscape(product.Slug)
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+
+		writeProductDetails(w, r, product, reviewRepo, images, attributeRepo, translationRepo, currencyRepo, recommender, currencies, locales)
+	}
+}
+
+// Helper function to write a product with its reviews summary, images and
+// attribute values and recommendations, priced and localized for the request
+func writeProductDetails(w http.ResponseWriter, r *http.Request, product *domain.Product, reviewRepo infrastructure.ReviewRepository, images media.Media, attributeRepo infrastructure.AttributeRepository, translationRepo infrastructure.TranslationRepository, currencyRepo infrastructure.CurrencyRepository, recommender recommendation.Recommender, currencies pricing.Currencies, locales locale.Locales) {
+	currency, requested, err := currencies.FromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recommended, err := recommender.For([]int64{product.ID})
+	if err != nil {
+		http.Error(w, "Failed to fetch recommendations", http.StatusInternalServerError)
+		return
+	}
+
+	if requested {
+		for _, p := range append([]*domain.Product{product}, recommended.Products()...) {
+			if err := priceProduct(currencyRepo, p, currency); err != nil {
+				writePriceError(w, err)
+				return
+			}
+		}
+	}
+
+	rating, err := reviewRepo.GetRatingSummary(product.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch ratings", http.StatusInternalServerError)
+		return
+	}
+
+	productImages, err := images.Repo.GetImages(product.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch images", http.StatusInternalServerError)
+		return
+	}
+
+	attributeValues, err := productAttributeValues(attributeRepo, product.ID)
+	if err != nil {
+		http.Error(w, "Failed to fetch attributes", http.StatusInternalServerError)
+		return
+	}
+
+	contentLocales, err := localizeProducts(translationRepo, append([]*domain.Product{product}, recommended.Products()...), locales.FromRequest(r), locales.Default)
+	if err != nil {
+		http.Error(w, "Failed to fetch translations", http.StatusInternalServerError)
+		return
+	}
+
+	etag.Set(w, product.Version)
+	locale.SetHeaders(w, contentLocales[product.ID])
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProductDetails{
+		Product:         product,
+		Locale:          contentLocales[product.ID],
+		Rating:          rating,
+		Images:          images.BuildImageResponses(productImages),
+		Attributes:      attributeValues,
+		Recommendations: recommended.Response(),
+	})
+}
+
+// Helper function to replace a product's price with its price in currency
+func priceProduct(currencyRepo infrastructure.CurrencyRepository, product *domain.Product, currency string) error {
+	price, err := currencyRepo.PriceIn(product, currency)
+	if err != nil {
+		return err
 	}
+	product.Price = price
+	return nil
 }
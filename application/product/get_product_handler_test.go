@@ -0,0 +1,71 @@
+package application
+
+import (
+	locale "ecommerce-go/application/locale"
+	media "ecommerce-go/application/media"
+	pricing "ecommerce-go/application/pricing"
+	recommendation "ecommerce-go/application/recommendation"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slugProducts finds products by their current or former slugs
+type slugProducts struct {
+	infrastructure.ProductRepository
+	bySlug map[string]*domain.Product
+}
+
+func (p slugProducts) GetBySlug(slug string) (*domain.Product, error) {
+	return p.bySlug[slug], nil
+}
+
+func TestGetProductBySlugHandlerRedirects(t *testing.T) {
+	archivedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mug := &domain.Product{ID: 1, Slug: "blue-mug-2"}
+	tea := &domain.Product{ID: 2, Slug: "thé-vert"}
+	archived := &domain.Product{ID: 3, Slug: "red-mug", DeletedAt: &archivedAt}
+	products := slugProducts{bySlug: map[string]*domain.Product{
+		"blue-mug-2": mug,
+		"cup":        mug,
+		"old-mug":    mug,
+		"green-tea":  tea,
+		"red-mug":    archived,
+		"red-cup":    archived,
+	}}
+
+	handler := GetProductBySlugHandler(products, nil, media.Media{}, nil, nil, nil, recommendation.Recommender{}, pricing.Currencies{}, locale.Locales{})
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantURL    string
+	}{
+		{"former slug", "/products/cup", http.StatusMovedPermanently, "/products/blue-mug-2"},
+		{"older slug", "/products/old-mug", http.StatusMovedPermanently, "/products/blue-mug-2"},
+		{"query kept", "/products/cup?currency=EUR", http.StatusMovedPermanently, "/products/blue-mug-2?currency=EUR"},
+		{"current slug escaped", "/products/green-tea", http.StatusMovedPermanently, "/products/th%C3%A9-vert"},
+		{"archived product", "/products/red-mug", http.StatusNotFound, ""},
+		{"former slug of archived product", "/products/red-cup", http.StatusNotFound, ""},
+		{"unknown slug", "/products/nothing", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/products/{slug}", handler)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: got status %d, want %d", tt.name, w.Code, tt.wantStatus)
+			continue
+		}
+		if got := w.Header().Get("Location"); got != tt.wantURL {
+			t.Errorf("%s: got location %q, want %q", tt.name, got, tt.wantURL)
+		}
+	}
+}
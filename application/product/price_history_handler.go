@@ -0,0 +1,223 @@
+package application
+
+import (
+	pricing "ecommerce-go/application/pricing"
+	staff "ecommerce-go/application/staff"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CreatePriceScheduleRequest struct {
+	ProductID int64        `json:"product_id"`
+	Price     domain.Money `json:"price"`
+	StartsAt  time.Time    `json:"starts_at"`
+	EndsAt    *time.Time   `json:"ends_at"`
+}
+
+type CancelPriceScheduleRequest struct {
+	ScheduleID int64 `json:"schedule_id"`
+}
+
+type PriceChangeResponse struct {
+	ID         int64        `json:"id"`
+	OldPrice   domain.Money `json:"old_price"`
+	NewPrice   domain.Money `json:"new_price"`
+	Source     string       `json:"source"`
+	ScheduleID int64        `json:"schedule_id,omitempty"`
+	ChangedBy  int64        `json:"changed_by"`
+	ChangedAt  time.Time    `json:"changed_at"`
+}
+
+type PriceScheduleResponse struct {
+	ID          int64         `json:"id"`
+	ProductID   int64         `json:"product_id"`
+	Price       domain.Money  `json:"price"`
+	StartsAt    time.Time     `json:"starts_at"`
+	EndsAt      *time.Time    `json:"ends_at,omitempty"`
+	RevertPrice *domain.Money `json:"revert_price,omitempty"`
+	Status      string        `json:"status"`
+	CreatedBy   int64         `json:"created_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// GetPriceHistoryHandler - List the changes to a product's base price, oldest first
+func GetPriceHistoryHandler(productRepo infrastructure.ProductRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		history, err := productRepo.GetPriceHistory(id)
+		if err != nil {
+			writePriceScheduleError(w, err)
+			return
+		}
+
+		response := make([]PriceChangeResponse, 0, len(history))
+		for _, change := range history {
+			response = append(response, PriceChangeResponse{
+				ID:         change.ID,
+				OldPrice:   change.OldPrice,
+				NewPrice:   change.NewPrice,
+				Source:     string(change.Source),
+				ScheduleID: change.ScheduleID,
+				ChangedBy:  change.ChangedBy,
+				ChangedAt:  change.ChangedAt,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// GetPriceSchedulesHandler - List the scheduled price changes of a product (staff only)
+func GetPriceSchedulesHandler(repo infrastructure.PriceScheduleRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("product_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		schedules, err := repo.GetSchedules(id)
+		if err != nil {
+			writePriceScheduleError(w, err)
+			return
+		}
+
+		response := make([]PriceScheduleResponse, 0, len(schedules))
+		for _, schedule := range schedules {
+			response = append(response, buildPriceScheduleResponse(schedule))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// CreatePriceScheduleHandler - Schedule a price change, or a sale when it
+// has an end (staff only)
+func CreatePriceScheduleHandler(repo infrastructure.PriceScheduleRepository, currencies pricing.Currencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// A bare price amount is in the default currency
+		var req CreatePriceScheduleRequest
+		req.Price.Currency = currencies.Default
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		staffID := staff.StaffID(r)
+		if req.ProductID == 0 || staffID == 0 {
+			http.Error(w, "Product ID and staff ID required", http.StatusBadRequest)
+			return
+		}
+
+		schedule := &domain.PriceSchedule{
+			ProductID: req.ProductID,
+			Price:     req.Price,
+			StartsAt:  req.StartsAt,
+			EndsAt:    req.EndsAt,
+			CreatedBy: staffID,
+		}
+		if err := schedule.Validate(time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		created, err := repo.CreateSchedule(schedule)
+		if err != nil {
+			writePriceScheduleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(buildPriceScheduleResponse(created))
+	}
+}
+
+// CancelPriceScheduleHandler - Cancel a scheduled price change that has not started (staff only)
+func CancelPriceScheduleHandler(repo infrastructure.PriceScheduleRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CancelPriceScheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.ScheduleID == 0 {
+			http.Error(w, "Schedule ID required", http.StatusBadRequest)
+			return
+		}
+
+		schedule, err := repo.CancelSchedule(req.ScheduleID)
+		if err != nil {
+			writePriceScheduleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(buildPriceScheduleResponse(schedule))
+	}
+}
+
+// Helper function to build a price schedule response
+func buildPriceScheduleResponse(schedule *domain.PriceSchedule) PriceScheduleResponse {
+	return PriceScheduleResponse{
+		ID:          schedule.ID,
+		ProductID:   schedule.ProductID,
+		Price:       schedule.Price,
+		StartsAt:    schedule.StartsAt,
+		EndsAt:      schedule.EndsAt,
+		RevertPrice: schedule.RevertPrice,
+		Status:      string(schedule.Status),
+		CreatedBy:   schedule.CreatedBy,
+		CreatedAt:   schedule.CreatedAt,
+	}
+}
+
+// Helper function to map price history and schedule errors to HTTP responses
+func writePriceScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrProductNotFound),
+		errors.Is(err, infrastructure.ErrScheduleNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrScheduleOverlap),
+		errors.Is(err, infrastructure.ErrScheduleNotPending),
+		errors.Is(err, infrastructure.ErrProductArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrCurrencyMismatch):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
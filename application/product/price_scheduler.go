@@ -0,0 +1,35 @@
+package application
+
+import (
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"log"
+	"time"
+)
+
+// PriceScheduler periodically applies the scheduled price changes that are
+// due, starting and ending sales
+type PriceScheduler struct {
+	Repo infrastructure.PriceScheduleRepository
+}
+
+// Start runs the scheduler every interval in the background
+func (s PriceScheduler) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Run(); err != nil {
+				log.Printf("price scheduler: %v", err)
+			}
+		}
+	}()
+}
+
+// Run applies the due price changes once
+func (s PriceScheduler) Run() error {
+	started, ended, err := s.Repo.ApplyDueSchedules()
+	if started > 0 || ended > 0 {
+		log.Printf("price scheduler: started %d and ended %d scheduled prices", started, ended)
+	}
+	return err
+}
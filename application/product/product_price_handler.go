@@ -0,0 +1,144 @@
+package application
+
+import (
+	pricing "ecommerce-go/application/pricing"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type SetProductPriceRequest struct {
+	ProductID int64        `json:"product_id"`
+	Price     domain.Money `json:"price"`
+}
+
+type ProductPricesResponse struct {
+	ProductID int64          `json:"product_id"`
+	BasePrice domain.Money   `json:"base_price"`
+	Prices    []domain.Money `json:"prices"`
+}
+
+// GetProductPricesHandler - List the base price and the price list entries of a product
+func GetProductPricesHandler(productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		product, err := productRepo.GetByID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+
+		prices, err := currencyRepo.GetProductPrices(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ProductPricesResponse{
+			ProductID: product.ID,
+			BasePrice: product.Price,
+			Prices:    prices,
+		})
+	}
+}
+
+// SetProductPriceHandler - Set the explicit price of a product in one currency (staff only)
+func SetProductPriceHandler(productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, currencies pricing.Currencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SetProductPriceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Validate input
+		if req.ProductID == 0 || !req.Price.IsPositive() || !currencies.IsSupported(req.Price.Currency) {
+			http.Error(w, "Invalid price data", http.StatusBadRequest)
+			return
+		}
+
+		product, err := productRepo.GetByID(req.ProductID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		if product.Price.Currency == req.Price.Currency {
+			http.Error(w, "Use /product/update to change the base price", http.StatusBadRequest)
+			return
+		}
+
+		if err := currencyRepo.SetProductPrice(req.ProductID, req.Price); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(req)
+	}
+}
+
+// DeleteProductPriceHandler - Remove a price list entry so the converted price applies again (staff only)
+func DeleteProductPriceHandler(currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		currency := strings.ToUpper(r.URL.Query().Get("currency"))
+		if !domain.IsCurrencyCode(currency) {
+			http.Error(w, "Invalid currency", http.StatusBadRequest)
+			return
+		}
+
+		if err := currencyRepo.DeleteProductPrice(id, currency); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Helper function to map pricing errors to HTTP responses
+func writePriceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, infrastructure.ErrExchangeRateNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
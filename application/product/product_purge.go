@@ -0,0 +1,48 @@
+package application
+
+import (
+	media "ecommerce-go/application/media"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"log"
+	"time"
+)
+
+// ProductPurge periodically deletes the products archived for longer than
+// Retention that no cart or order references, removing their images from
+// Media as well
+type ProductPurge struct {
+	Repo      infrastructure.ProductRepository
+	Media     media.Media
+	Retention time.Duration
+}
+
+// Start runs the purge every interval in the background
+func (p ProductPurge) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := p.Run(); err != nil {
+				log.Printf("product purge: %v", err)
+			}
+		}
+	}()
+}
+
+// Run purges once. Products are deleted before their images, so images that
+// fail to delete are logged and left behind.
+func (p ProductPurge) Run() error {
+	ids, err := p.Repo.PurgeArchived(p.Retention)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := p.Media.RemoveProductImages(id); err != nil {
+			log.Printf("product purge: remove images of product %d: %v", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("product purge: deleted %d archived products", len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package application
+
+import (
+	locale "ecommerce-go/application/locale"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxTranslatedNameLength is the longest name the ProductTranslation table holds
+const maxTranslatedNameLength = 255
+
+type SetTranslationRequest struct {
+	ProductID   int64  `json:"product_id"`
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type TranslationResponse struct {
+	Locale      string `json:"locale"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GetTranslationsHandler - List a product's translations (staff only)
+func GetTranslationsHandler(repo infrastructure.TranslationRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		translations, err := repo.GetTranslations(id)
+		if err != nil {
+			writeTranslationError(w, err)
+			return
+		}
+
+		response := make([]TranslationResponse, 0, len(translations))
+		for _, t := range translations {
+			response = append(response, TranslationResponse{Locale: t.Locale, Name: t.Name, Description: t.Description})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// SetTranslationHandler - Set a product's name and description in a
+// supported locale other than the default one (staff only). An empty field
+// falls back to the next locale.
+func SetTranslationHandler(repo infrastructure.TranslationRepository, locales locale.Locales) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SetTranslationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		translationLocale, ok := translationLocale(w, req.Locale, locales)
+		if !ok {
+			return
+		}
+
+		// Validate input
+		req.Name = strings.TrimSpace(req.Name)
+		if req.ProductID == 0 || (req.Name == "" && strings.TrimSpace(req.Description) == "") || len(req.Name) > maxTranslatedNameLength {
+			http.Error(w, "Invalid translation data", http.StatusBadRequest)
+			return
+		}
+
+		translation := &domain.ProductTranslation{
+			ProductID:   req.ProductID,
+			Locale:      translationLocale,
+			Name:        req.Name,
+			Description: req.Description,
+		}
+		if err := repo.SetTranslation(translation); err != nil {
+			writeTranslationError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TranslationResponse{Locale: translation.Locale, Name: translation.Name, Description: translation.Description})
+	}
+}
+
+// DeleteTranslationHandler - Remove a product's translation (staff only)
+func DeleteTranslationHandler(repo infrastructure.TranslationRepository, locales locale.Locales) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		translationLocale, ok := translationLocale(w, r.URL.Query().Get("locale"), locales)
+		if !ok {
+			return
+		}
+
+		if err := repo.DeleteTranslation(id, translationLocale); err != nil {
+			writeTranslationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Helper function to check the locale of a translation, writing an error
+// if it is invalid, unsupported or the default locale
+func translationLocale(w http.ResponseWriter, tag string, locales locale.Locales) (string, bool) {
+	normalized, err := domain.NormalizeLocale(tag)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	if normalized == locales.Default {
+		http.Error(w, "Use /product/update to change the default locale content", http.StatusBadRequest)
+		return "", false
+	}
+	if !locales.IsSupported(normalized) {
+		http.Error(w, "Unsupported locale", http.StatusBadRequest)
+		return "", false
+	}
+	return normalized, true
+}
+
+// Helper function to list the locales of a request's fallback chain that
+// come from translations. The default locale is the products' own content,
+// so locales after it in the chain are never used.
+func translatedLocales(chain []string, defaultLocale string) []string {
+	translated := make([]string, 0, len(chain))
+	for _, l := range chain {
+		if l == defaultLocale {
+			break
+		}
+		translated = append(translated, l)
+	}
+	return translated
+}
+
+// Helper function to localize products in place for the locales of a
+// request, returning the locale each product's name is in
+func localizeProducts(repo infrastructure.TranslationRepository, products []*domain.Product, chain []string, defaultLocale string) (map[int64]string, error) {
+	ids := make([]int64, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+
+	translated := translatedLocales(chain, defaultLocale)
+	translations, err := repo.GetTranslationsFor(ids, translated)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[int64]string, len(products))
+	for _, p := range products {
+		used[p.ID] = defaultLocale
+		if l := p.Localize(translations[p.ID], translated); l != "" {
+			used[p.ID] = l
+		}
+	}
+	return used, nil
+}
+
+// Helper function to map translation errors to HTTP responses
+func writeTranslationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrTranslationNotFound),
+		errors.Is(err, infrastructure.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
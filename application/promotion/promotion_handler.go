@@ -0,0 +1,222 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type PromotionTierRequest struct {
+	MinQuantity int    `json:"min_quantity"`
+	PercentOff  string `json:"percent_off"`
+}
+
+type CreatePromotionRequest struct {
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type"`
+	Priority    int                    `json:"priority"`
+	Stackable   bool                   `json:"stackable"`
+	StartsAt    *time.Time             `json:"starts_at"`
+	EndsAt      *time.Time             `json:"ends_at"`
+	ProductIDs  []int64                `json:"product_ids"`
+	Categories  []string               `json:"categories"`
+	BuyQuantity int                    `json:"buy_quantity"`
+	GetQuantity int                    `json:"get_quantity"`
+	PercentOff  string                 `json:"percent_off"`
+	Tiers       []PromotionTierRequest `json:"tiers"`
+	BundlePrice domain.Money           `json:"bundle_price"`
+}
+
+type SetPromotionActiveRequest struct {
+	PromotionID int64 `json:"promotion_id"`
+	Active      bool  `json:"active"`
+}
+
+type PromotionTierResponse struct {
+	MinQuantity int    `json:"min_quantity"`
+	PercentOff  string `json:"percent_off"`
+}
+
+type PromotionResponse struct {
+	ID          int64                   `json:"id"`
+	Name        string                  `json:"name"`
+	Type        string                  `json:"type"`
+	Description string                  `json:"description"`
+	Priority    int                     `json:"priority"`
+	Stackable   bool                    `json:"stackable"`
+	StartsAt    *time.Time              `json:"starts_at,omitempty"`
+	EndsAt      *time.Time              `json:"ends_at,omitempty"`
+	ProductIDs  []int64                 `json:"product_ids"`
+	Categories  []string                `json:"categories"`
+	BuyQuantity int                     `json:"buy_quantity,omitempty"`
+	GetQuantity int                     `json:"get_quantity,omitempty"`
+	PercentOff  string                  `json:"percent_off,omitempty"`
+	Tiers       []PromotionTierResponse `json:"tiers,omitempty"`
+	BundlePrice *domain.Money           `json:"bundle_price,omitempty"`
+	IsActive    bool                    `json:"is_active"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+// CreatePromotionHandler - Create an automatic promotion (staff only)
+func CreatePromotionHandler(repo infrastructure.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CreatePromotionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		promotion := &domain.Promotion{
+			Name:        req.Name,
+			Type:        domain.PromotionType(req.Type),
+			Priority:    req.Priority,
+			Stackable:   req.Stackable,
+			StartsAt:    req.StartsAt,
+			EndsAt:      req.EndsAt,
+			ProductIDs:  req.ProductIDs,
+			Categories:  req.Categories,
+			BuyQuantity: req.BuyQuantity,
+			GetQuantity: req.GetQuantity,
+			BundlePrice: req.BundlePrice,
+			IsActive:    true,
+		}
+
+		if req.PercentOff != "" {
+			percent, err := domain.ParsePercent(req.PercentOff)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			promotion.PercentOff = percent
+		}
+		for _, tier := range req.Tiers {
+			percent, err := domain.ParsePercent(tier.PercentOff)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			promotion.Tiers = append(promotion.Tiers, domain.PromotionTier{MinQuantity: tier.MinQuantity, PercentOff: percent})
+		}
+
+		// Validate input
+		if err := promotion.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		promotion, err := repo.CreatePromotion(promotion)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(buildPromotionResponse(promotion))
+	}
+}
+
+// GetPromotionsHandler - List all promotions (staff only)
+func GetPromotionsHandler(repo infrastructure.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		promotions, err := repo.GetPromotions()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]PromotionResponse, 0, len(promotions))
+		for _, promotion := range promotions {
+			response = append(response, buildPromotionResponse(promotion))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// SetPromotionActiveHandler - Enable or disable a promotion (staff only)
+func SetPromotionActiveHandler(repo infrastructure.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SetPromotionActiveRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.PromotionID == 0 {
+			http.Error(w, "Promotion ID required", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.SetPromotionActive(req.PromotionID, req.Active); err != nil {
+			if errors.Is(err, infrastructure.ErrPromotionNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Helper function to build promotion response
+func buildPromotionResponse(promotion *domain.Promotion) PromotionResponse {
+	response := PromotionResponse{
+		ID:          promotion.ID,
+		Name:        promotion.Name,
+		Type:        string(promotion.Type),
+		Description: promotion.Describe(),
+		Priority:    promotion.Priority,
+		Stackable:   promotion.Stackable,
+		StartsAt:    promotion.StartsAt,
+		EndsAt:      promotion.EndsAt,
+		ProductIDs:  promotion.ProductIDs,
+		Categories:  promotion.Categories,
+		BuyQuantity: promotion.BuyQuantity,
+		GetQuantity: promotion.GetQuantity,
+		IsActive:    promotion.IsActive,
+		CreatedAt:   promotion.CreatedAt,
+	}
+	if response.ProductIDs == nil {
+		response.ProductIDs = make([]int64, 0)
+	}
+	if response.Categories == nil {
+		response.Categories = make([]string, 0)
+	}
+
+	if promotion.PercentOff > 0 {
+		response.PercentOff = domain.FormatPercent(promotion.PercentOff)
+	}
+	for _, tier := range promotion.Tiers {
+		response.Tiers = append(response.Tiers, PromotionTierResponse{
+			MinQuantity: tier.MinQuantity,
+			PercentOff:  domain.FormatPercent(tier.PercentOff),
+		})
+	}
+	if !promotion.BundlePrice.IsZero() {
+		response.BundlePrice = &promotion.BundlePrice
+	}
+
+	return response
+}
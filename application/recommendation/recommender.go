@@ -0,0 +1,100 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"log"
+	"time"
+)
+
+// Recommender periodically recomputes the product recommendations from the
+// orders and carts, and looks them up for products and carts
+type Recommender struct {
+	Repo infrastructure.RecommendationRepository
+	// Limit is the most products recommended of each kind
+	Limit int
+	// MinScore is the fewest orders or carts two products must share to be
+	// recommended with each other
+	MinScore int
+}
+
+// Recommendations are the products recommended for a product or cart, best
+// first
+type Recommendations struct {
+	BoughtTogether []*domain.Product
+	AlsoViewed     []*domain.Product
+}
+
+type RecommendedProductResponse struct {
+	ID    int64        `json:"id"`
+	Slug  string       `json:"slug"`
+	Name  string       `json:"name"`
+	Price domain.Money `json:"price"`
+}
+
+type RecommendationsResponse struct {
+	FrequentlyBoughtTogether []RecommendedProductResponse `json:"frequently_bought_together"`
+	CustomersAlsoViewed      []RecommendedProductResponse `json:"customers_also_viewed"`
+}
+
+// Start runs the recommender every interval in the background, starting
+// right away
+func (r Recommender) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := r.Run(); err != nil {
+				log.Printf("recommender: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Run recomputes the recommendations once
+func (r Recommender) Run() error {
+	n, err := r.Repo.Compute(r.Limit, r.MinScore)
+	if err != nil {
+		return err
+	}
+	log.Printf("recommender: stored %d recommendations", n)
+	return nil
+}
+
+// For looks up the recommendations for a product, or for the products of a
+// cart together
+func (r Recommender) For(productIDs []int64) (Recommendations, error) {
+	boughtTogether, err := r.Repo.GetRecommendations(productIDs, domain.RecommendationBoughtTogether, r.Limit)
+	if err != nil {
+		return Recommendations{}, err
+	}
+	alsoViewed, err := r.Repo.GetRecommendations(productIDs, domain.RecommendationAlsoViewed, r.Limit)
+	if err != nil {
+		return Recommendations{}, err
+	}
+	return Recommendations{BoughtTogether: boughtTogether, AlsoViewed: alsoViewed}, nil
+}
+
+// Products lists every recommended product, for pricing or localizing them
+// in place
+func (rec Recommendations) Products() []*domain.Product {
+	return append(append([]*domain.Product{}, rec.BoughtTogether...), rec.AlsoViewed...)
+}
+
+// Response converts the recommendations to their JSON form
+func (rec Recommendations) Response() *RecommendationsResponse {
+	return &RecommendationsResponse{
+		FrequentlyBoughtTogether: productResponses(rec.BoughtTogether),
+		CustomersAlsoViewed:      productResponses(rec.AlsoViewed),
+	}
+}
+
+// Helper function to convert recommended products to their JSON form
+func productResponses(products []*domain.Product) []RecommendedProductResponse {
+	response := make([]RecommendedProductResponse, 0, len(products))
+	for _, p := range products {
+		response = append(response, RecommendedProductResponse{ID: p.ID, Slug: p.Slug, Name: p.Name, Price: p.Price})
+	}
+	return response
+}
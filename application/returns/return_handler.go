@@ -0,0 +1,381 @@
+package application
+
+import (
+	staff "ecommerce-go/application/staff"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/payment"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CreateReturnRequest struct {
+	UserID      int64  `json:"user_id"`
+	OrderItemID int64  `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason"`
+	Comment     string `json:"comment"`
+}
+
+type ReviewReturnRequest struct {
+	ReturnID int64  `json:"return_id"`
+	Note     string `json:"note"`
+}
+
+type RefundReturnRequest struct {
+	ReturnID int64        `json:"return_id"`
+	Amount   domain.Money `json:"amount"`
+}
+
+type ReturnResponse struct {
+	ID           int64        `json:"id"`
+	OrderID      int64        `json:"order_id"`
+	OrderItemID  int64        `json:"order_item_id"`
+	UserID       int64        `json:"user_id"`
+	Quantity     int          `json:"quantity"`
+	Reason       string       `json:"reason"`
+	Comment      string       `json:"comment"`
+	Status       string       `json:"status"`
+	RefundAmount domain.Money `json:"refund_amount"`
+	StaffNote    string       `json:"staff_note,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// CreateReturnHandler - Request the return of an order line
+func CreateReturnHandler(repo infrastructure.ReturnRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CreateReturnRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		// Validate input
+		reason := domain.ReturnReason(req.Reason)
+		if req.UserID == 0 || req.OrderItemID == 0 || req.Quantity <= 0 || !reason.IsValid() {
+			http.Error(w, "Invalid return data", http.StatusBadRequest)
+			return
+		}
+
+		ret, err := repo.CreateReturn(&domain.ReturnRequest{
+			OrderItemID: req.OrderItemID,
+			UserID:      req.UserID,
+			Quantity:    req.Quantity,
+			Reason:      reason,
+			Comment:     req.Comment,
+		})
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		writeReturn(w, http.StatusCreated, ret)
+	}
+}
+
+// GetOrderReturnsHandler - List the return requests of an order
+func GetOrderReturnsHandler(repo infrastructure.ReturnRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		orderID := r.URL.Query().Get("order_id")
+		if orderID == "" {
+			http.Error(w, "Order ID required", http.StatusBadRequest)
+			return
+		}
+
+		id, err := strconv.ParseInt(orderID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid order ID", http.StatusBadRequest)
+			return
+		}
+
+		returns, err := repo.GetReturnsByOrderID(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeReturns(w, returns)
+	}
+}
+
+// GetReturnsByStatusHandler - List return requests awaiting action (staff only)
+func GetReturnsByStatusHandler(repo infrastructure.ReturnRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = string(domain.ReturnStatusRequested)
+		}
+
+		returns, err := repo.GetReturnsByStatus(domain.ReturnStatus(status))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeReturns(w, returns)
+	}
+}
+
+// ApproveReturnHandler - Approve a return request (staff only)
+func ApproveReturnHandler(repo infrastructure.ReturnRepository) http.HandlerFunc {
+	return reviewReturnHandler(repo, domain.ReturnStatusApproved)
+}
+
+// RejectReturnHandler - Reject a return request (staff only)
+func RejectReturnHandler(repo infrastructure.ReturnRepository) http.HandlerFunc {
+	return reviewReturnHandler(repo, domain.ReturnStatusRejected)
+}
+
+// ReceiveReturnHandler - Mark returned goods as received and restock them (staff only)
+func ReceiveReturnHandler(repo infrastructure.ReturnRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ReviewReturnRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		staffID := staff.StaffID(r)
+		if req.ReturnID == 0 || staffID == 0 {
+			http.Error(w, "Return ID and staff ID required", http.StatusBadRequest)
+			return
+		}
+
+		ret, err := repo.ReceiveReturn(req.ReturnID, staffID)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		writeReturn(w, http.StatusOK, ret)
+	}
+}
+
+// RefundReturnHandler - Refund a received return through the payment provider (staff only).
+// Without an amount the full line value is refunded; a lower amount issues a partial refund.
+// The return is claimed before the provider is called, and the refund is keyed on the
+// return, so concurrent or repeated requests refund it once.
+func RefundReturnHandler(repo infrastructure.ReturnRepository, orderRepo infrastructure.OrderRepository, paymentRepo infrastructure.PaymentRepository, provider payment.Provider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req RefundReturnRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		staffID := staff.StaffID(r)
+		if req.ReturnID == 0 || staffID == 0 || req.Amount.IsNegative() {
+			http.Error(w, "Invalid refund data", http.StatusBadRequest)
+			return
+		}
+
+		ret, err := repo.GetReturnByID(req.ReturnID)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		if !ret.Status.CanTransitionTo(domain.ReturnStatusRefunding) {
+			http.Error(w, "Return must be received before it is refunded", http.StatusConflict)
+			return
+		}
+
+		order, err := orderRepo.GetOrderByID(ret.OrderID)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		var lineValue domain.Money
+		lineDiscounts := domain.NewMoney(0, order.Currency)
+		for _, item := range order.Items {
+			lineDiscounts = lineDiscounts.Add(item.DiscountAmount)
+			if item.ID == ret.OrderItemID {
+				// The returned units carry their share of the line's discounts
+				// and of any tax charged on top of it
+				net := item.Price.Mul(int64(item.Quantity)).Sub(item.DiscountAmount)
+				if !order.PricesIncludeTax {
+					net = net.Add(item.TaxAmount)
+				}
+				lineValue = net.MulRat(int64(ret.Quantity), int64(item.Quantity))
+			}
+		}
+
+		// Orders placed before coupons were spread over their lines share the
+		// coupon discount in proportion to line value
+		if couponDiscount := order.DiscountAmount.Sub(lineDiscounts); couponDiscount.IsPositive() {
+			subtotal := order.TotalAmount.Add(couponDiscount)
+			lineValue = lineValue.MulRat(order.TotalAmount.Amount, subtotal.Amount)
+		}
+
+		amount := req.Amount
+		if amount.IsZero() {
+			amount = lineValue
+		}
+		if amount.Currency != lineValue.Currency || amount.Cmp(lineValue) > 0 {
+			http.Error(w, "Refund amount exceeds value of returned items", http.StatusBadRequest)
+			return
+		}
+
+		p, err := findRefundablePayment(paymentRepo, order.ID)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		ret, err = repo.StartRefund(ret.ID, staffID, amount)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		if _, err := provider.Refund(p.ProviderRef, ret.RefundAmount, fmt.Sprintf("return-%d", ret.ID)); err != nil {
+			if _, cancelErr := repo.CancelRefund(ret.ID); cancelErr != nil {
+				log.Printf("return %d: releasing refund: %v", ret.ID, cancelErr)
+			}
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		ret, err = repo.RefundReturn(ret.ID, p.ID)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		writeReturn(w, http.StatusOK, ret)
+	}
+}
+
+// Helper function building the approve and reject handlers
+func reviewReturnHandler(repo infrastructure.ReturnRepository, status domain.ReturnStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ReviewReturnRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		staffID := staff.StaffID(r)
+		if req.ReturnID == 0 || staffID == 0 {
+			http.Error(w, "Return ID and staff ID required", http.StatusBadRequest)
+			return
+		}
+
+		ret, err := repo.ReviewReturn(req.ReturnID, status, staffID, req.Note)
+		if err != nil {
+			writeReturnError(w, err)
+			return
+		}
+
+		writeReturn(w, http.StatusOK, ret)
+	}
+}
+
+// Helper function to find the captured payment a refund is issued against
+func findRefundablePayment(repo infrastructure.PaymentRepository, orderID int64) (*domain.Payment, error) {
+	payments, err := repo.GetPaymentsByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range payments {
+		if p.Status == domain.PaymentStatusCaptured || p.Status == domain.PaymentStatusPartiallyRefunded {
+			return p, nil
+		}
+	}
+
+	return nil, infrastructure.ErrPaymentNotFound
+}
+
+// Helper function to map return errors to HTTP responses
+func writeReturnError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrReturnNotFound),
+		errors.Is(err, infrastructure.ErrOrderItemNotFound),
+		errors.Is(err, infrastructure.ErrOrderNotFound),
+		errors.Is(err, infrastructure.ErrPaymentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrReturnQuantityExceeded),
+		errors.Is(err, infrastructure.ErrRefundExceedsPayment):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, infrastructure.ErrOrderNotReturnable),
+		errors.Is(err, infrastructure.ErrInvalidReturnTransition),
+		errors.Is(err, infrastructure.ErrInvalidPaymentTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to write a return request as JSON
+func writeReturn(w http.ResponseWriter, status int, ret *domain.ReturnRequest) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(buildReturnResponse(ret))
+}
+
+// Helper function to write a list of return requests as JSON
+func writeReturns(w http.ResponseWriter, returns []*domain.ReturnRequest) {
+	response := make([]ReturnResponse, 0, len(returns))
+	for _, ret := range returns {
+		response = append(response, buildReturnResponse(ret))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Helper function to build return response
+func buildReturnResponse(ret *domain.ReturnRequest) ReturnResponse {
+	return ReturnResponse{
+		ID:           ret.ID,
+		OrderID:      ret.OrderID,
+		OrderItemID:  ret.OrderItemID,
+		UserID:       ret.UserID,
+		Quantity:     ret.Quantity,
+		Reason:       string(ret.Reason),
+		Comment:      ret.Comment,
+		Status:       string(ret.Status),
+		RefundAmount: ret.RefundAmount,
+		StaffNote:    ret.StaffNote,
+		CreatedAt:    ret.CreatedAt,
+		UpdatedAt:    ret.UpdatedAt,
+	}
+}
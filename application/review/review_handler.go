@@ -0,0 +1,297 @@
+package application
+
+import (
+	staff "ecommerce-go/application/staff"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultReviewPageSize = 20
+	maxReviewPageSize     = 100
+)
+
+type CreateReviewRequest struct {
+	UserID    int64  `json:"user_id"`
+	ProductID int64  `json:"product_id"`
+	Rating    int    `json:"rating"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+}
+
+type ModerateReviewRequest struct {
+	ReviewID int64  `json:"review_id"`
+	Note     string `json:"note"`
+}
+
+type ReviewResponse struct {
+	ID               int64     `json:"id"`
+	ProductID        int64     `json:"product_id"`
+	UserID           int64     `json:"user_id"`
+	Rating           int       `json:"rating"`
+	Title            string    `json:"title"`
+	Body             string    `json:"body"`
+	VerifiedPurchase bool      `json:"verified_purchase"`
+	Status           string    `json:"status"`
+	ModerationNote   string    `json:"moderation_note,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type RatingSummaryResponse struct {
+	Average   float64        `json:"average"`
+	Count     int            `json:"count"`
+	Histogram map[string]int `json:"histogram"`
+}
+
+type ProductReviewsResponse struct {
+	ProductID int64                 `json:"product_id"`
+	Summary   RatingSummaryResponse `json:"summary"`
+	Reviews   []ReviewResponse      `json:"reviews"`
+	Sort      string                `json:"sort"`
+	Page      int                   `json:"page"`
+	PageSize  int                   `json:"page_size"`
+	Total     int                   `json:"total"`
+}
+
+// CreateReviewHandler - Submit a product review for moderation
+func CreateReviewHandler(repo infrastructure.ReviewRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req CreateReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.UserID == 0 || req.ProductID == 0 {
+			http.Error(w, "User ID and product ID required", http.StatusBadRequest)
+			return
+		}
+
+		review := &domain.Review{
+			ProductID: req.ProductID,
+			UserID:    req.UserID,
+			Rating:    req.Rating,
+			Title:     req.Title,
+			Body:      req.Body,
+		}
+		review.Normalize()
+		if err := review.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		created, err := repo.CreateReview(review)
+		if err != nil {
+			writeReviewError(w, err)
+			return
+		}
+
+		writeReview(w, http.StatusCreated, created)
+	}
+}
+
+// GetProductReviewsHandler - List a product's approved reviews a page at a
+// time, with its rating summary
+func GetProductReviewsHandler(repo infrastructure.ReviewRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		productID, err := strconv.ParseInt(query.Get("product_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		sort := domain.ReviewSort(query.Get("sort"))
+		if sort == "" {
+			sort = domain.ReviewSortNewest
+		}
+		if !sort.IsValid() {
+			http.Error(w, "Invalid sort", http.StatusBadRequest)
+			return
+		}
+
+		page, err := intParam(query.Get("page"), 1)
+		if err != nil || page < 1 {
+			http.Error(w, "Invalid page", http.StatusBadRequest)
+			return
+		}
+		pageSize, err := intParam(query.Get("page_size"), defaultReviewPageSize)
+		if err != nil || pageSize < 1 || pageSize > maxReviewPageSize {
+			http.Error(w, "Invalid page size", http.StatusBadRequest)
+			return
+		}
+
+		reviews, total, err := repo.GetProductReviews(productID, sort, pageSize, (page-1)*pageSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		summary, err := repo.GetRatingSummary(productID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := ProductReviewsResponse{
+			ProductID: productID,
+			Summary:   buildRatingSummaryResponse(summary),
+			Reviews:   make([]ReviewResponse, 0, len(reviews)),
+			Sort:      string(sort),
+			Page:      page,
+			PageSize:  pageSize,
+			Total:     total,
+		}
+		for _, review := range reviews {
+			reviewResponse := buildReviewResponse(review)
+			// The moderator's note is for staff only
+			reviewResponse.ModerationNote = ""
+			response.Reviews = append(response.Reviews, reviewResponse)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// GetReviewsByStatusHandler - List reviews awaiting moderation (staff only)
+func GetReviewsByStatusHandler(repo infrastructure.ReviewRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		if status == "" {
+			status = string(domain.ReviewStatusPending)
+		}
+
+		reviews, err := repo.GetReviewsByStatus(domain.ReviewStatus(status))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]ReviewResponse, 0, len(reviews))
+		for _, review := range reviews {
+			response = append(response, buildReviewResponse(review))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// ApproveReviewHandler - Publish a review (staff only)
+func ApproveReviewHandler(repo infrastructure.ReviewRepository) http.HandlerFunc {
+	return moderateReviewHandler(repo, domain.ReviewStatusApproved)
+}
+
+// RejectReviewHandler - Hide a review (staff only)
+func RejectReviewHandler(repo infrastructure.ReviewRepository) http.HandlerFunc {
+	return moderateReviewHandler(repo, domain.ReviewStatusRejected)
+}
+
+// Helper function building the approve and reject handlers
+func moderateReviewHandler(repo infrastructure.ReviewRepository, status domain.ReviewStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req ModerateReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		staffID := staff.StaffID(r)
+		if req.ReviewID == 0 || staffID == 0 {
+			http.Error(w, "Review ID and staff ID required", http.StatusBadRequest)
+			return
+		}
+
+		review, err := repo.ModerateReview(req.ReviewID, status, staffID, req.Note)
+		if err != nil {
+			writeReviewError(w, err)
+			return
+		}
+
+		writeReview(w, http.StatusOK, review)
+	}
+}
+
+// Helper function to build the rating summary shown with a product's reviews,
+// keying the histogram by star rating
+func buildRatingSummaryResponse(summary domain.RatingSummary) RatingSummaryResponse {
+	histogram := make(map[string]int, domain.MaxRating)
+	for i, count := range summary.Histogram {
+		histogram[strconv.Itoa(i+1)] = count
+	}
+
+	return RatingSummaryResponse{
+		Average:   summary.Average,
+		Count:     summary.Count,
+		Histogram: histogram,
+	}
+}
+
+// Helper function to parse an optional integer query parameter
+func intParam(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// Helper function to map review errors to HTTP responses
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrReviewNotFound), errors.Is(err, infrastructure.ErrProductNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrReviewExists), errors.Is(err, infrastructure.ErrInvalidReviewTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to write a review as JSON
+func writeReview(w http.ResponseWriter, status int, review *domain.Review) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(buildReviewResponse(review))
+}
+
+// Helper function to build review response
+func buildReviewResponse(review *domain.Review) ReviewResponse {
+	return ReviewResponse{
+		ID:               review.ID,
+		ProductID:        review.ProductID,
+		UserID:           review.UserID,
+		Rating:           review.Rating,
+		Title:            review.Title,
+		Body:             review.Body,
+		VerifiedPurchase: review.VerifiedPurchase,
+		Status:           string(review.Status),
+		ModerationNote:   review.ModerationNote,
+		CreatedAt:        review.CreatedAt,
+	}
+}
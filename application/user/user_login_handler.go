@@ -3,6 +3,7 @@ package application
 import (
 	infrastructure "ecommerce-go/infrastructure/mysql"
 	"encoding/json"
+	"log"
 	"net/http"
 )
 
@@ -22,7 +23,13 @@ type LoginResponse struct {
 	User  interface{} `json:"user"`
 }
 
-func HandleUserLogin(w http.ResponseWriter, r *http.Request, repo *infrastructure.UserRepository) {
+// GuestCartMerger moves the cart a guest built before logging in into their
+// own cart
+type GuestCartMerger interface {
+	MergeGuestCart(w http.ResponseWriter, r *http.Request, userID int64) error
+}
+
+func HandleUserLogin(w http.ResponseWriter, r *http.Request, repo *infrastructure.UserRepository, guestCarts GuestCartMerger) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -47,6 +54,12 @@ func HandleUserLogin(w http.ResponseWriter, r *http.Request, repo *infrastructur
 		return
 	}
 
+	// Merge the cart built before logging in. The guest cart is left as it
+	// was on failure, so the login still goes through.
+	if err := guestCarts.MergeGuestCart(w, r, int64(user.ID)); err != nil {
+		log.Printf("login: merge guest cart into user %d: %v", user.ID, err)
+	}
+
 	// Return response
 	response := LoginResponse{
 		Token: "token-will-be-generated-by-auth-service",
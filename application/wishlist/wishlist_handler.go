@@ -0,0 +1,350 @@
+package application
+
+import (
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type WishlistRequest struct {
+	UserID   int64  `json:"user_id"`
+	Name     string `json:"name"`
+	IsPublic bool   `json:"is_public"`
+}
+
+type DeleteWishlistRequest struct {
+	UserID     int64 `json:"user_id"`
+	WishlistID int64 `json:"wishlist_id"`
+}
+
+type WishlistResponse struct {
+	ID         int64                  `json:"id"`
+	UserID     int64                  `json:"user_id,omitempty"`
+	Name       string                 `json:"name"`
+	Kind       string                 `json:"kind"`
+	IsPublic   bool                   `json:"is_public"`
+	ShareToken string                 `json:"share_token,omitempty"`
+	Items      []WishlistItemResponse `json:"items"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// WishlistItemResponse compares the price an item was added at with the
+// product's current price in the same currency. Products that no longer
+// exist are not available and have no current price.
+type WishlistItemResponse struct {
+	ProductID      int64         `json:"product_id"`
+	Name           string        `json:"name"`
+	Quantity       int           `json:"quantity"`
+	PriceWhenAdded domain.Money  `json:"price_when_added"`
+	CurrentPrice   *domain.Money `json:"current_price,omitempty"`
+	PriceDrop      domain.Money  `json:"price_drop"`
+	PriceDropped   bool          `json:"price_dropped"`
+	Available      bool          `json:"available"`
+	AddedAt        time.Time     `json:"added_at"`
+}
+
+// GetWishlistsHandler - List a user's wishlists, the saved-for-later list first
+func GetWishlistsHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uid, ok := queryID(w, r, "user_id", "User ID")
+		if !ok {
+			return
+		}
+
+		wishlists, err := repo.GetWishlistsByUserID(uid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]WishlistResponse, 0, len(wishlists))
+		for _, wishlist := range wishlists {
+			wishlistResponse, err := buildWishlistResponse(wishlist, productRepo, currencyRepo)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			response = append(response, wishlistResponse)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// GetWishlistHandler - Get one of a user's wishlists
+func GetWishlistHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uid, ok := queryID(w, r, "user_id", "User ID")
+		if !ok {
+			return
+		}
+		id, ok := queryID(w, r, "id", "Wishlist ID")
+		if !ok {
+			return
+		}
+
+		wishlist, err := repo.GetWishlist(uid, id)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		writeWishlist(w, http.StatusOK, wishlist, productRepo, currencyRepo)
+	}
+}
+
+// GetSharedWishlistHandler - Get a public wishlist through its share link
+func GetSharedWishlistHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			http.Error(w, "Share token required", http.StatusBadRequest)
+			return
+		}
+
+		wishlist, err := repo.GetSharedWishlist(token)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		response, err := buildWishlistResponse(wishlist, productRepo, currencyRepo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Visitors do not learn who owns the list
+		response.UserID = 0
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// CreateWishlistHandler - Create a named wishlist for a user
+func CreateWishlistHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req WishlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		wishlist := &domain.Wishlist{UserID: req.UserID, Name: req.Name, IsPublic: req.IsPublic}
+		wishlist.Normalize()
+		if req.UserID == 0 {
+			http.Error(w, "User ID required", http.StatusBadRequest)
+			return
+		}
+		if err := wishlist.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		created, err := repo.CreateWishlist(wishlist)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		writeWishlist(w, http.StatusCreated, created, productRepo, currencyRepo)
+	}
+}
+
+// UpdateWishlistHandler - Rename a wishlist or change who can see it
+func UpdateWishlistHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := queryID(w, r, "id", "Wishlist ID")
+		if !ok {
+			return
+		}
+
+		var req WishlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		wishlist := &domain.Wishlist{Name: req.Name}
+		wishlist.Normalize()
+		if req.UserID == 0 {
+			http.Error(w, "User ID required", http.StatusBadRequest)
+			return
+		}
+		if err := wishlist.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		updated, err := repo.UpdateWishlist(req.UserID, id, wishlist.Name, req.IsPublic)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		writeWishlist(w, http.StatusOK, updated, productRepo, currencyRepo)
+	}
+}
+
+// DeleteWishlistHandler - Delete a wishlist and its items
+func DeleteWishlistHandler(repo infrastructure.WishlistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req DeleteWishlistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.UserID == 0 || req.WishlistID == 0 {
+			http.Error(w, "User ID and wishlist ID required", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.DeleteWishlist(req.UserID, req.WishlistID); err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Helper function to read a required ID query parameter, writing the error
+// response when it is missing or invalid
+func queryID(w http.ResponseWriter, r *http.Request, param, name string) (int64, bool) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		http.Error(w, name+" required", http.StatusBadRequest)
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// Helper function to write a wishlist error with a matching status
+func writeWishlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrWishlistNotFound), errors.Is(err, infrastructure.ErrWishlistItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, infrastructure.ErrWishlistExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Helper function to write a wishlist as JSON
+func writeWishlist(w http.ResponseWriter, status int, wishlist *domain.Wishlist, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) {
+	response, err := buildWishlistResponse(wishlist, productRepo, currencyRepo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Helper function to build a wishlist response, pricing each item at the
+// product's current price in the currency it was added in
+func buildWishlistResponse(wishlist *domain.Wishlist, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) (WishlistResponse, error) {
+	items := make([]WishlistItemResponse, 0, len(wishlist.Items))
+	for _, item := range wishlist.Items {
+		itemResponse, err := buildWishlistItemResponse(item, productRepo, currencyRepo)
+		if err != nil {
+			return WishlistResponse{}, err
+		}
+		items = append(items, itemResponse)
+	}
+
+	response := WishlistResponse{
+		ID:        wishlist.ID,
+		UserID:    wishlist.UserID,
+		Name:      wishlist.Name,
+		Kind:      string(wishlist.Kind),
+		IsPublic:  wishlist.IsPublic,
+		Items:     items,
+		CreatedAt: wishlist.CreatedAt,
+	}
+	if wishlist.IsPublic {
+		response.ShareToken = wishlist.ShareToken
+	}
+	return response, nil
+}
+
+// Helper function to build a wishlist item response
+func buildWishlistItemResponse(item domain.WishlistItem, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) (WishlistItemResponse, error) {
+	response := WishlistItemResponse{
+		ProductID:      item.ProductID,
+		Quantity:       item.Quantity,
+		PriceWhenAdded: item.PriceWhenAdded,
+		PriceDrop:      domain.NewMoney(0, item.PriceWhenAdded.Currency),
+		AddedAt:        item.AddedAt,
+	}
+
+	product, err := productRepo.GetByID(item.ProductID)
+	if err != nil || product == nil {
+		return response, err
+	}
+	response.Name = product.Name
+	if product.IsArchived() {
+		// No longer sold at all
+		return response, nil
+	}
+
+	current, err := currencyRepo.PriceIn(product, item.PriceWhenAdded.Currency)
+	if errors.Is(err, infrastructure.ErrExchangeRateNotFound) {
+		// No longer sold in this currency
+		return response, nil
+	}
+	if err != nil {
+		return response, err
+	}
+
+	response.Available = true
+	response.CurrentPrice = &current
+	response.PriceDrop = item.PriceDrop(current)
+	response.PriceDropped = response.PriceDrop.IsPositive()
+	return response, nil
+}
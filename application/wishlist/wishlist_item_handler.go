@@ -0,0 +1,350 @@
+package application
+
+import (
+	etag "ecommerce-go/application/etag"
+	pricing "ecommerce-go/application/pricing"
+	"ecommerce-go/domain"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type WishlistItemRequest struct {
+	UserID     int64 `json:"user_id"`
+	WishlistID int64 `json:"wishlist_id"`
+	ProductID  int64 `json:"product_id"`
+	Quantity   int   `json:"quantity"`
+}
+
+// SaveForLaterRequest moves a cart line into a wishlist. Without a
+// wishlist ID the line goes to the user's saved-for-later list.
+type SaveForLaterRequest struct {
+	UserID     int64 `json:"user_id"`
+	ProductID  int64 `json:"product_id"`
+	WishlistID int64 `json:"wishlist_id,omitempty"`
+}
+
+type PriceDropResponse struct {
+	WishlistID   int64  `json:"wishlist_id"`
+	WishlistName string `json:"wishlist_name"`
+	WishlistItemResponse
+}
+
+// AddWishlistItemHandler - Add a product to a wishlist at its current price
+func AddWishlistItemHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, currencies pricing.Currencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req WishlistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.Quantity == 0 {
+			req.Quantity = 1
+		}
+		if req.UserID == 0 || req.WishlistID == 0 || req.ProductID == 0 || req.Quantity < 0 {
+			http.Error(w, "Invalid wishlist item data", http.StatusBadRequest)
+			return
+		}
+
+		currency, _, err := currencies.FromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		product, err := productRepo.GetByID(req.ProductID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		if product.IsArchived() {
+			http.Error(w, "Product is no longer available", http.StatusConflict)
+			return
+		}
+
+		// The price the item is added at is what later price drops compare with
+		price, err := currencyRepo.PriceIn(product, currency)
+		if err != nil {
+			writePriceError(w, err)
+			return
+		}
+
+		if _, err := repo.AddWishlistItem(req.UserID, req.WishlistID, req.ProductID, req.Quantity, price); err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		wishlist, err := repo.GetWishlist(req.UserID, req.WishlistID)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		writeWishlist(w, http.StatusOK, wishlist, productRepo, currencyRepo)
+	}
+}
+
+// RemoveWishlistItemHandler - Remove a product from a wishlist
+func RemoveWishlistItemHandler(repo infrastructure.WishlistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req WishlistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.UserID == 0 || req.WishlistID == 0 || req.ProductID == 0 {
+			http.Error(w, "Invalid wishlist item data", http.StatusBadRequest)
+			return
+		}
+
+		if err := repo.RemoveWishlistItem(req.UserID, req.WishlistID, req.ProductID); err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MoveToCartHandler - Move a wishlist item into the user's cart at the
+// product's current price in the cart currency
+func MoveToCartHandler(repo infrastructure.WishlistRepository, cartRepo infrastructure.CartRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository, currencies pricing.Currencies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req WishlistItemRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.UserID == 0 || req.WishlistID == 0 || req.ProductID == 0 {
+			http.Error(w, "Invalid wishlist item data", http.StatusBadRequest)
+			return
+		}
+
+		item, err := repo.GetWishlistItem(req.UserID, req.WishlistID, req.ProductID)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		product, err := productRepo.GetByID(item.ProductID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if product == nil {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		if product.IsArchived() {
+			http.Error(w, "Product is no longer available", http.StatusConflict)
+			return
+		}
+
+		cart, _, err := cartRepo.GetOrCreateCart(req.UserID, currencies.Default)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		price, err := currencyRepo.PriceIn(product, cart.Currency)
+		if err != nil {
+			writePriceError(w, err)
+			return
+		}
+
+		// Add to the cart before removing from the wishlist, so a failure
+		// leaves the item where it was rather than losing it
+		if _, err := cartRepo.AddItemToCart(cart.ID, item.ProductID, item.Quantity, price, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := repo.RemoveWishlistItem(req.UserID, req.WishlistID, req.ProductID); err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		wishlist, err := repo.GetWishlist(req.UserID, req.WishlistID)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		writeWishlist(w, http.StatusOK, wishlist, productRepo, currencyRepo)
+	}
+}
+
+// SaveForLaterHandler - Move a cart line into the user's saved-for-later
+// list, or into one of their wishlists, at the price it had in the cart
+func SaveForLaterHandler(repo infrastructure.WishlistRepository, cartRepo infrastructure.CartRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SaveForLaterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if req.UserID == 0 || req.ProductID == 0 {
+			http.Error(w, "Invalid cart item data", http.StatusBadRequest)
+			return
+		}
+
+		version, err := etag.IfMatch(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+
+		cart, err := cartRepo.GetCartByUserID(req.UserID)
+		if err != nil {
+			writeCartError(w, err)
+			return
+		}
+
+		var line *domain.CartItem
+		for i := range cart.Items {
+			if cart.Items[i].ProductID == req.ProductID {
+				line = &cart.Items[i]
+				break
+			}
+		}
+		if line == nil {
+			writeCartError(w, infrastructure.ErrCartItemNotFound)
+			return
+		}
+
+		var wishlist *domain.Wishlist
+		if req.WishlistID == 0 {
+			wishlist, err = repo.GetSavedForLater(req.UserID)
+		} else {
+			wishlist, err = repo.GetWishlist(req.UserID, req.WishlistID)
+		}
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		_, err = repo.GetWishlistItem(req.UserID, wishlist.ID, line.ProductID)
+		alreadySaved := err == nil
+		if err != nil && !errors.Is(err, infrastructure.ErrWishlistItemNotFound) {
+			writeWishlistError(w, err)
+			return
+		}
+
+		// Save before removing from the cart, so a failure leaves the line
+		// where it was rather than losing it
+		if _, err := repo.AddWishlistItem(req.UserID, wishlist.ID, line.ProductID, line.Quantity, line.Price); err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+		if err := cartRepo.RemoveItemFromCart(cart.ID, line.ProductID, version); err != nil {
+			// Undo the save so the line is not in both places. A product
+			// that was already saved keeps its line with the added quantity.
+			if !alreadySaved {
+				repo.RemoveWishlistItem(req.UserID, wishlist.ID, line.ProductID)
+			}
+			writeCartError(w, err)
+			return
+		}
+
+		wishlist, err = repo.GetWishlist(req.UserID, wishlist.ID)
+		if err != nil {
+			writeWishlistError(w, err)
+			return
+		}
+
+		writeWishlist(w, http.StatusOK, wishlist, productRepo, currencyRepo)
+	}
+}
+
+// GetPriceDropsHandler - List the items in a user's wishlists that are now
+// cheaper than when they were added
+func GetPriceDropsHandler(repo infrastructure.WishlistRepository, productRepo infrastructure.ProductRepository, currencyRepo infrastructure.CurrencyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		uid, ok := queryID(w, r, "user_id", "User ID")
+		if !ok {
+			return
+		}
+
+		wishlists, err := repo.GetWishlistsByUserID(uid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		drops := make([]PriceDropResponse, 0)
+		for _, wishlist := range wishlists {
+			for _, item := range wishlist.Items {
+				itemResponse, err := buildWishlistItemResponse(item, productRepo, currencyRepo)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if !itemResponse.PriceDropped {
+					continue
+				}
+				drops = append(drops, PriceDropResponse{
+					WishlistID:           wishlist.ID,
+					WishlistName:         wishlist.Name,
+					WishlistItemResponse: itemResponse,
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(drops)
+	}
+}
+
+// Helper function to write an error pricing a product in a currency
+func writePriceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, infrastructure.ErrExchangeRateNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// Helper function to write an error finding or changing a cart
+func writeCartError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrVersionConflict):
+		http.Error(w, "Cart was modified by another request", http.StatusPreconditionFailed)
+	case errors.Is(err, infrastructure.ErrCartNotFound), errors.Is(err, infrastructure.ErrCartItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	applicationCart "ecommerce-go/application/cart"
+	applicationCatalog "ecommerce-go/application/catalog"
+	applicationRecommendation "ecommerce-go/application/recommendation"
+	infrastructure "ecommerce-go/infrastructure/mysql"
+	"flag"
+	"io"
+	"log"
+	"os"
+)
+
+// repairCarts runs the repair-carts command, consolidating the duplicate
+// active carts of each user. Run it before migration 0013, which rejects
+// duplicates.
+func repairCarts(args []string, repair applicationCart.CartRepair) {
+	flags := flag.NewFlagSet("repair-carts", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report the carts that would be merged without changing them")
+	flags.Parse(args)
+
+	report, err := repair.Run(*dryRun)
+	if err != nil {
+		log.Fatalf("Error repairing carts: %v", err)
+	}
+	log.Printf("Repaired carts of %d users: %d merged, %d deactivated", report.Users, report.Merged, report.Deactivated)
+}
+
+// importProducts runs the import-products command, creating and updating
+// products from a CSV or JSON Lines file, or standard input for "-"
+func importProducts(args []string, catalog applicationCatalog.Catalog) {
+	flags := flag.NewFlagSet("import-products", flag.ExitOnError)
+	formatName := flags.String("format", "csv", "file format: csv or jsonl")
+	dryRun := flags.Bool("dry-run", false, "validate the file without saving products")
+	staffID := flags.Int64("staff-id", 0, "staff member recorded as making the price changes")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("Usage: import-products [-format csv|jsonl] [-dry-run] [-staff-id ID] FILE")
+	}
+	format, err := applicationCatalog.ParseFormat(*formatName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var input io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Error opening import file: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	report, err := catalog.Import(input, format, *dryRun, *staffID)
+	for _, rowErr := range report.Errors {
+		log.Printf("Row %d %s: %s", rowErr.Row, rowErr.SKU, rowErr.Message)
+	}
+	if err != nil {
+		log.Fatalf("Error importing products: %v", err)
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Checked"
+	}
+	log.Printf("%s %d rows: %d created, %d updated, %d failed", verb, report.Rows, report.Created, report.Updated, report.Failed)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// exportProducts runs the export-products command, writing every product
+// to a file or standard output
+func exportProducts(args []string, catalog applicationCatalog.Catalog) {
+	flags := flag.NewFlagSet("export-products", flag.ExitOnError)
+	formatName := flags.String("format", "csv", "file format: csv or jsonl")
+	outPath := flags.String("o", "-", "output file, or - for standard output")
+	flags.Parse(args)
+
+	format, err := applicationCatalog.ParseFormat(*formatName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	output := os.Stdout
+	if *outPath != "-" {
+		output, err = os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Error creating export file: %v", err)
+		}
+	}
+
+	buffered := bufio.NewWriter(output)
+	if err := catalog.Export(buffered, format); err != nil {
+		log.Fatalf("Error exporting products: %v", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		log.Fatalf("Error exporting products: %v", err)
+	}
+	if err := output.Close(); err != nil {
+		log.Fatalf("Error exporting products: %v", err)
+	}
+}
+
+// generateSlugs runs the generate-slugs command, giving the products created
+// before migration 0023 a slug
+func generateSlugs(productRepo infrastructure.ProductRepository) {
+	n, err := productRepo.GenerateMissingSlugs()
+	if err != nil {
+		log.Fatalf("Error generating slugs: %v", err)
+	}
+	log.Printf("Generated slugs for %d products", n)
+}
+
+// computeRecommendations runs the compute-recommendations command,
+// recomputing the product recommendations once
+func computeRecommendations(recommender applicationRecommendation.Recommender) {
+	if err := recommender.Run(); err != nil {
+		log.Fatalf("Error computing recommendations: %v", err)
+	}
+}
@@ -1,13 +1,27 @@
 package config
 
 import (
+	"ecommerce-go/domain"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 )
 
 type Config struct {
-	DB DatabaseConfig `json:"db"`
+	DB              DatabaseConfig        `json:"db"`
+	Staff           StaffConfig           `json:"staff"`
+	Payment         PaymentConfig         `json:"payment"`
+	Currency        CurrencyConfig        `json:"currency"`
+	Locale          LocaleConfig          `json:"locale"`
+	Tax             TaxConfig             `json:"tax"`
+	Shipping        ShippingConfig        `json:"shipping"`
+	Cart            CartConfig            `json:"cart"`
+	Notify          NotifyConfig          `json:"notifications"`
+	Media           MediaConfig           `json:"media"`
+	Products        ProductsConfig        `json:"products"`
+	Recommendations RecommendationsConfig `json:"recommendations"`
+	Feeds           FeedsConfig           `json:"feeds"`
 }
 
 type DatabaseConfig struct {
@@ -18,6 +32,107 @@ type DatabaseConfig struct {
 	Database string `json:"database"`
 }
 
+type StaffConfig struct {
+	APIKey string `json:"api_key"`
+}
+
+type PaymentConfig struct {
+	Provider      string `json:"provider"`
+	WebhookSecret string `json:"webhook_secret"`
+	// WebhookURL and ConfirmDelaySeconds configure the fake provider's
+	// delayed confirmations
+	WebhookURL          string `json:"webhook_url"`
+	ConfirmDelaySeconds int    `json:"confirm_delay_seconds"`
+}
+
+type CurrencyConfig struct {
+	Default   string   `json:"default"`
+	Supported []string `json:"supported"`
+	RatesFile string   `json:"rates_file"`
+}
+
+type LocaleConfig struct {
+	// Default is the locale of product content as created; Supported lists
+	// the locales translations may be added in
+	Default   string   `json:"default"`
+	Supported []string `json:"supported"`
+}
+
+type TaxConfig struct {
+	RatesFile      string `json:"rates_file"`
+	DefaultCountry string `json:"default_country"`
+	DefaultRegion  string `json:"default_region"`
+}
+
+type ShippingConfig struct {
+	RatesFile string `json:"rates_file"`
+}
+
+type CartConfig struct {
+	// GuestSecret signs the tokens identifying guest carts
+	GuestSecret   string `json:"guest_secret"`
+	GuestCartDays int    `json:"guest_cart_days"`
+	MergeStrategy string `json:"merge_strategy"`
+	// Carts idle for AbandonAfterHours are flagged as abandoned; the check
+	// runs every ExpiryIntervalMinutes
+	AbandonAfterHours     int `json:"abandon_after_hours"`
+	ExpiryIntervalMinutes int `json:"expiry_interval_minutes"`
+}
+
+type NotifyConfig struct {
+	Provider      string `json:"provider"`
+	WebhookURL    string `json:"webhook_url"`
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+type MediaConfig struct {
+	// Provider is "local", storing files below LocalDir, or "s3"
+	Provider string `json:"provider"`
+	LocalDir string `json:"local_dir"`
+	// BaseURL is the path stored files are served below
+	BaseURL     string `json:"base_url"`
+	MaxUploadMB int    `json:"max_upload_mb"`
+	// ThumbnailSizes maps each thumbnail name to its longest side in pixels
+	ThumbnailSizes map[string]int `json:"thumbnail_sizes"`
+	S3             S3Config       `json:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+type ProductsConfig struct {
+	// Archived products nothing references are purged after PurgeAfterDays;
+	// the purge runs every PurgeIntervalMinutes. Zero in either turns it off.
+	PurgeAfterDays       int `json:"purge_after_days"`
+	PurgeIntervalMinutes int `json:"purge_interval_minutes"`
+	// Scheduled price changes are applied every PriceScheduleIntervalMinutes
+	PriceScheduleIntervalMinutes int `json:"price_schedule_interval_minutes"`
+}
+
+type RecommendationsConfig struct {
+	// Recommendations are recomputed every IntervalMinutes; zero turns the
+	// job off, leaving the compute-recommendations command
+	IntervalMinutes int `json:"interval_minutes"`
+	// Limit is the most products recommended of each kind
+	Limit int `json:"limit"`
+	// MinScore is the fewest orders or carts two products must share
+	MinScore int `json:"min_score"`
+}
+
+type FeedsConfig struct {
+	// SiteURL is the storefront address feed links start with
+	SiteURL string `json:"site_url"`
+	Title   string `json:"title"`
+	// Feeds are regenerated every IntervalMinutes; with zero they are only
+	// generated when first requested
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
 // LoadConfig loads the configuration from the config file
 func LoadConfig() (*Config, error) {
 	// Open the config file
@@ -37,3 +152,39 @@ func LoadConfig() (*Config, error) {
 
 	return &config, nil
 }
+
+// exchangeRatesFile is the layout of the exchange rates file: the number of
+// units of each listed currency that one unit of base buys
+type exchangeRatesFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// LoadExchangeRates loads exchange rates from a JSON file
+func LoadExchangeRates(path string) ([]*domain.ExchangeRate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open exchange rates file: %w", err)
+	}
+	defer file.Close()
+
+	var data exchangeRatesFile
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return nil, fmt.Errorf("could not parse exchange rates: %w", err)
+	}
+
+	if !domain.IsCurrencyCode(data.Base) {
+		return nil, fmt.Errorf("invalid base currency %q", data.Base)
+	}
+
+	rates := make([]*domain.ExchangeRate, 0, len(data.Rates))
+	for quote, value := range data.Rates {
+		rate, ok := new(big.Rat).SetString(value)
+		if !domain.IsCurrencyCode(quote) || !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %s: %q", quote, value)
+		}
+		rates = append(rates, &domain.ExchangeRate{Base: data.Base, Quote: quote, Rate: rate})
+	}
+
+	return rates, nil
+}
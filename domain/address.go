@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type AddressType string
+
+const (
+	AddressTypeBilling  AddressType = "billing"
+	AddressTypeShipping AddressType = "shipping"
+)
+
+// IsValid reports whether t is a known address type
+func (t AddressType) IsValid() bool {
+	return t == AddressTypeBilling || t == AddressTypeShipping
+}
+
+// Address is an entry of a user's address book. A user has at most one
+// default billing and one default shipping address.
+type Address struct {
+	ID                int64
+	UserID            int64
+	Name              string
+	Line1             string
+	Line2             string
+	City              string
+	Region            string
+	PostalCode        string
+	Country           string // ISO 3166-1 alpha-2
+	Phone             string
+	IsDefaultBilling  bool
+	IsDefaultShipping bool
+	CreatedAt         time.Time
+}
+
+// Normalize trims the fields and upper cases the country and region codes
+func (a *Address) Normalize() {
+	a.Name = strings.TrimSpace(a.Name)
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.Region = strings.ToUpper(strings.TrimSpace(a.Region))
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Phone = strings.TrimSpace(a.Phone)
+}
+
+// Validate checks that the address can be shipped to
+func (a *Address) Validate() error {
+	switch {
+	case a.Name == "" || a.Line1 == "" || a.City == "":
+		return errors.New("name, first line and city required")
+	case len(a.Country) != 2:
+		return errors.New("country must be a two letter code")
+	}
+	return nil
+}
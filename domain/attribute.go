@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAttribute      = errors.New("invalid attribute")
+	ErrInvalidAttributeValue = errors.New("invalid attribute value")
+)
+
+// maxAttributeValueLength is the longest value the ProductAttribute table holds
+const maxAttributeValueLength = 255
+
+// attributeCodePattern matches attribute codes such as "brand" or "size_eu"
+var attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// AttributeType decides which values an attribute accepts
+type AttributeType string
+
+const (
+	AttributeText    AttributeType = "text"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+)
+
+// IsValid reports whether t is a known attribute type
+func (t AttributeType) IsValid() bool {
+	switch t {
+	case AttributeText, AttributeNumber, AttributeBoolean:
+		return true
+	}
+	return false
+}
+
+// Attribute describes a property products can carry, such as brand or
+// colour, identified by Code. Filterable attributes are offered as facets
+// when listing products.
+type Attribute struct {
+	ID         int64
+	Code       string
+	Name       string
+	Type       AttributeType
+	Filterable bool
+}
+
+// Validate checks the attribute definition
+func (a *Attribute) Validate() error {
+	a.Code = strings.TrimSpace(a.Code)
+	a.Name = strings.TrimSpace(a.Name)
+	if !attributeCodePattern.MatchString(a.Code) {
+		return fmt.Errorf("%w: code must be lowercase letters, digits and underscores", ErrInvalidAttribute)
+	}
+	if a.Name == "" || len(a.Name) > 100 {
+		return fmt.Errorf("%w: name must be 1 to 100 characters", ErrInvalidAttribute)
+	}
+	if !a.Type.IsValid() {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAttribute, a.Type)
+	}
+	return nil
+}
+
+// Normalize checks value against the attribute's type and returns it in
+// canonical form, so equal values compare equal when filtering: numbers
+// without redundant zeros and booleans as "true" or "false"
+func (a *Attribute) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch a.Type {
+	case AttributeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s must be a number", ErrInvalidAttributeValue, a.Code)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case AttributeBoolean:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s must be true or false", ErrInvalidAttributeValue, a.Code)
+		}
+		return strconv.FormatBool(b), nil
+	}
+	if value == "" || len(value) > maxAttributeValueLength {
+		return "", fmt.Errorf("%w: %s must be 1 to %d characters", ErrInvalidAttributeValue, a.Code, maxAttributeValueLength)
+	}
+	return value, nil
+}
+
+// LessValue orders two normalized values of the attribute, numerically for
+// numbers and alphabetically otherwise
+func (a *Attribute) LessValue(x, y string) bool {
+	if a.Type == AttributeNumber {
+		nx, errX := strconv.ParseFloat(x, 64)
+		ny, errY := strconv.ParseFloat(y, 64)
+		if errX == nil && errY == nil {
+			return nx < ny
+		}
+	}
+	return strings.ToLower(x) < strings.ToLower(y)
+}
@@ -1,17 +1,77 @@
 package domain
 
+import "time"
+
+// Cart belongs to a user, or to a guest identified by GuestID until the
+// guest logs in and the cart is merged into the user's. UpdatedAt is the
+// last time the cart's contents changed; a user cart left idle for too long
+// is flagged with AbandonedAt until it changes again. Version is incremented
+// by every change, for optimistic concurrency control.
 type Cart struct {
 	ID          int64
 	UserID      int64
-	TotalAmount float64
+	GuestID     string
+	Currency    string
+	TotalAmount Money
+	CouponCode  string
 	Items       []CartItem
 	IsActive    bool
+	UpdatedAt   time.Time
+	AbandonedAt *time.Time
+	Version     int64
 }
 
 type CartItem struct {
-	ID        int64
-	CartID    int64
-	ProductID int64
-	Quantity  int
-	Price     float64
+	ID          int64
+	CartID      int64
+	ProductID   int64
+	Quantity    int
+	Price       Money
+	TaxClass    string
+	WeightGrams int // per unit
+	UpdatedAt   time.Time
+}
+
+// CartMergeStrategy decides the quantity of a product found in both a guest
+// cart and the user's cart when the two are merged
+type CartMergeStrategy string
+
+const (
+	CartMergeSum    CartMergeStrategy = "sum"
+	CartMergeMax    CartMergeStrategy = "max"
+	CartMergeLatest CartMergeStrategy = "latest"
+)
+
+// IsValid reports whether s is a known merge strategy
+func (s CartMergeStrategy) IsValid() bool {
+	switch s {
+	case CartMergeSum, CartMergeMax, CartMergeLatest:
+		return true
+	}
+	return false
+}
+
+// Merge combines the user's and the guest's line for the same product. The
+// merged line keeps the price of whichever line changed last, as that is the
+// more recent snapshot.
+func (s CartMergeStrategy) Merge(user, guest CartItem) CartItem {
+	merged := user
+	latest := user
+	if guest.UpdatedAt.After(user.UpdatedAt) {
+		latest = guest
+	}
+	merged.Price = latest.Price
+	merged.UpdatedAt = latest.UpdatedAt
+
+	switch s {
+	case CartMergeSum:
+		merged.Quantity = user.Quantity + guest.Quantity
+	case CartMergeMax:
+		if guest.Quantity > merged.Quantity {
+			merged.Quantity = guest.Quantity
+		}
+	case CartMergeLatest:
+		merged.Quantity = latest.Quantity
+	}
+	return merged
 }
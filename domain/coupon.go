@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixedAmount  CouponType = "fixed_amount"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+)
+
+var (
+	ErrCouponInactive      = errors.New("coupon is not active")
+	ErrCouponMinimumNotMet = errors.New("cart is below the coupon minimum order value")
+	ErrCouponNotApplicable = errors.New("coupon does not apply to any item in the cart")
+	ErrCouponUsageExceeded = errors.New("coupon usage limit reached")
+)
+
+// IsValid reports whether t is a known coupon type
+func (t CouponType) IsValid() bool {
+	switch t {
+	case CouponTypePercentage, CouponTypeFixedAmount, CouponTypeFreeShipping:
+		return true
+	}
+	return false
+}
+
+// Coupon is a discount code a customer can apply to their cart. A coupon
+// restricted to products or categories only discounts matching lines.
+type Coupon struct {
+	ID             int64
+	Code           string
+	Type           CouponType
+	PercentOff     int64 // hundredths of a percent, 1250 is 12.5%
+	AmountOff      Money
+	MinOrderValue  Money
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	MaxUses        int // 0 is unlimited
+	MaxUsesPerUser int // 0 is unlimited
+	ProductIDs     []int64
+	Categories     []string
+	IsActive       bool
+	CreatedAt      time.Time
+}
+
+// NormalizeCouponCode returns the canonical, case-insensitive form of a code
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks that the coupon definition is consistent
+func (c *Coupon) Validate() error {
+	switch {
+	case c.Code == "":
+		return errors.New("coupon code required")
+	case !c.Type.IsValid():
+		return fmt.Errorf("invalid coupon type %q", c.Type)
+	case c.Type == CouponTypePercentage && (c.PercentOff <= 0 || c.PercentOff > 10000):
+		return errors.New("percentage must be greater than 0 and at most 100")
+	case c.Type == CouponTypeFixedAmount && !c.AmountOff.IsPositive():
+		return errors.New("fixed amount coupons need a positive amount")
+	case !c.AmountOff.IsZero() && !c.MinOrderValue.IsZero() && c.AmountOff.Currency != c.MinOrderValue.Currency:
+		return errors.New("coupon amounts must share one currency")
+	case c.MinOrderValue.IsNegative():
+		return errors.New("minimum order value cannot be negative")
+	case c.MaxUses < 0 || c.MaxUsesPerUser < 0:
+		return errors.New("usage limits cannot be negative")
+	case c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt):
+		return errors.New("coupon must end after it starts")
+	}
+	return nil
+}
+
+// IsActiveAt reports whether the coupon is enabled and inside its validity window
+func (c *Coupon) IsActiveAt(t time.Time) bool {
+	return c.IsActive && inWindow(c.StartsAt, c.EndsAt, t)
+}
+
+// AppliesTo reports whether a cart line for the product is eligible
+func (c *Coupon) AppliesTo(productID int64, category string) bool {
+	return matchesTargets(c.ProductIDs, c.Categories, productID, category)
+}
+
+// InCurrency returns a copy of the coupon with its amounts converted to
+// currency at rate, the units of currency per unit of the coupon's currency
+func (c *Coupon) InCurrency(currency string, rate *big.Rat) *Coupon {
+	converted := *c
+	if !c.AmountOff.IsZero() {
+		converted.AmountOff = c.AmountOff.Convert(currency, rate)
+	}
+	if !c.MinOrderValue.IsZero() {
+		converted.MinOrderValue = c.MinOrderValue.Convert(currency, rate)
+	}
+	return &converted
+}
+
+// Currency returns the currency the coupon's amounts are expressed in, or
+// "" if it has no amounts
+func (c *Coupon) Currency() string {
+	if !c.AmountOff.IsZero() {
+		return c.AmountOff.Currency
+	}
+	if !c.MinOrderValue.IsZero() {
+		return c.MinOrderValue.Currency
+	}
+	return ""
+}
+
+// Discount returns the amount the coupon takes off cart at time now.
+// categories maps the cart's product IDs to their category and
+// lineDiscounts the discounts already given on each product's line, which
+// the coupon does not discount again. The coupon's amounts must already be
+// in the cart currency. Free shipping coupons discount nothing here;
+// shipping is priced separately.
+func (c *Coupon) Discount(cart *Cart, categories map[int64]string, lineDiscounts map[int64]Money, now time.Time) (Money, error) {
+	zero := NewMoney(0, cart.Currency)
+	if !c.IsActiveAt(now) {
+		return zero, ErrCouponInactive
+	}
+
+	subtotal, eligible := zero, zero
+	for _, item := range cart.Items {
+		line := item.Price.Mul(int64(item.Quantity)).Sub(lineDiscounts[item.ProductID])
+		subtotal = subtotal.Add(line)
+		if c.AppliesTo(item.ProductID, categories[item.ProductID]) {
+			eligible = eligible.Add(line)
+		}
+	}
+
+	if subtotal.Cmp(c.MinOrderValue) < 0 {
+		return zero, fmt.Errorf("%w of %s %s", ErrCouponMinimumNotMet, c.MinOrderValue, c.MinOrderValue.Currency)
+	}
+	if eligible.IsZero() {
+		return zero, ErrCouponNotApplicable
+	}
+
+	switch c.Type {
+	case CouponTypePercentage:
+		return eligible.MulRat(c.PercentOff, 10000).Min(eligible), nil
+	case CouponTypeFixedAmount:
+		return c.AmountOff.Min(eligible), nil
+	}
+	return zero, nil
+}
+
+// ParsePercent parses a percentage such as "12.5" into hundredths of a percent
+func ParsePercent(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return ratToMinor(r, 2)
+}
+
+// FormatPercent formats hundredths of a percent as a decimal without
+// trailing zeros, e.g. "12.5"
+func FormatPercent(hundredths int64) string {
+	sign := ""
+	if hundredths < 0 {
+		sign = "-"
+	}
+	abs := absInt64(hundredths)
+	if abs%100 == 0 {
+		return fmt.Sprintf("%s%d", sign, abs/100)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100), "0")
+}
+
+// inWindow reports whether t lies in [start, end); nil bounds are open
+func inWindow(start, end *time.Time, t time.Time) bool {
+	if start != nil && t.Before(*start) {
+		return false
+	}
+	if end != nil && !t.Before(*end) {
+		return false
+	}
+	return true
+}
+
+// matchesTargets reports whether a product is among productIDs or in one of
+// categories. Empty targets match every product.
+func matchesTargets(productIDs []int64, categories []string, productID int64, category string) bool {
+	if len(productIDs) == 0 && len(categories) == 0 {
+		return true
+	}
+	for _, id := range productIDs {
+		if id == productID {
+			return true
+		}
+	}
+	for _, cat := range categories {
+		if category != "" && strings.EqualFold(cat, category) {
+			return true
+		}
+	}
+	return false
+}
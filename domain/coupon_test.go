@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCouponDiscount(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+	usd := func(s string) Money { return MustParseMoney(s, "USD") }
+
+	// Two shirts at 10.00 and a mug at 5.55
+	cart := &Cart{
+		Currency: "USD",
+		Items: []CartItem{
+			{ProductID: 1, Quantity: 2, Price: usd("10.00")},
+			{ProductID: 2, Quantity: 1, Price: usd("5.55")},
+		},
+	}
+	categories := map[int64]string{1: "Shirts", 2: "Mugs"}
+
+	tests := []struct {
+		name          string
+		coupon        Coupon
+		lineDiscounts map[int64]Money
+		want          Money
+		wantErr       error
+	}{
+		{
+			name:   "percentage of the whole cart",
+			coupon: Coupon{Type: CouponTypePercentage, PercentOff: 1000, IsActive: true},
+			want:   usd("2.56"), // 10% of 25.55 rounds half up
+		},
+		{
+			name:   "fractional percentage",
+			coupon: Coupon{Type: CouponTypePercentage, PercentOff: 1250, IsActive: true},
+			want:   usd("3.19"), // 12.5% of 25.55 is 3.19375
+		},
+		{
+			name:   "percentage limited to a category",
+			coupon: Coupon{Type: CouponTypePercentage, PercentOff: 5000, Categories: []string{"mugs"}, IsActive: true},
+			want:   usd("2.78"), // half of 5.55 rounds half away from zero
+		},
+		{
+			name:   "percentage limited to a product",
+			coupon: Coupon{Type: CouponTypePercentage, PercentOff: 10000, ProductIDs: []int64{1}, IsActive: true},
+			want:   usd("20.00"),
+		},
+		{
+			name:   "fixed amount",
+			coupon: Coupon{Type: CouponTypeFixedAmount, AmountOff: usd("5.00"), IsActive: true},
+			want:   usd("5.00"),
+		},
+		{
+			name:   "fixed amount capped at the eligible lines",
+			coupon: Coupon{Type: CouponTypeFixedAmount, AmountOff: usd("50.00"), ProductIDs: []int64{2}, IsActive: true},
+			want:   usd("5.55"),
+		},
+		{
+			name:          "skips what promotions already took off",
+			coupon:        Coupon{Type: CouponTypePercentage, PercentOff: 1000, ProductIDs: []int64{1}, IsActive: true},
+			lineDiscounts: map[int64]Money{1: usd("4.00")},
+			want:          usd("1.60"),
+		},
+		{
+			name:   "free shipping takes nothing off the goods",
+			coupon: Coupon{Type: CouponTypeFreeShipping, IsActive: true},
+			want:   usd("0"),
+		},
+		{
+			name:   "minimum met exactly",
+			coupon: Coupon{Type: CouponTypeFixedAmount, AmountOff: usd("1.00"), MinOrderValue: usd("25.55"), IsActive: true},
+			want:   usd("1.00"),
+		},
+		{
+			name:    "minimum not met",
+			coupon:  Coupon{Type: CouponTypeFixedAmount, AmountOff: usd("1.00"), MinOrderValue: usd("25.56"), IsActive: true},
+			wantErr: ErrCouponMinimumNotMet,
+		},
+		{
+			name:          "minimum counts the discounted subtotal",
+			coupon:        Coupon{Type: CouponTypeFixedAmount, AmountOff: usd("1.00"), MinOrderValue: usd("25.00"), IsActive: true},
+			lineDiscounts: map[int64]Money{2: usd("1.00")},
+			wantErr:       ErrCouponMinimumNotMet,
+		},
+		{
+			name:    "no matching line",
+			coupon:  Coupon{Type: CouponTypePercentage, PercentOff: 1000, Categories: []string{"Hats"}, IsActive: true},
+			wantErr: ErrCouponNotApplicable,
+		},
+		{
+			name:    "disabled",
+			coupon:  Coupon{Type: CouponTypePercentage, PercentOff: 1000},
+			wantErr: ErrCouponInactive,
+		},
+		{
+			name:    "not started",
+			coupon:  Coupon{Type: CouponTypePercentage, PercentOff: 1000, StartsAt: &after, IsActive: true},
+			wantErr: ErrCouponInactive,
+		},
+		{
+			name:    "ended",
+			coupon:  Coupon{Type: CouponTypePercentage, PercentOff: 1000, EndsAt: &now, IsActive: true},
+			wantErr: ErrCouponInactive,
+		},
+		{
+			name:   "inside its window",
+			coupon: Coupon{Type: CouponTypeFixedAmount, AmountOff: usd("1.00"), StartsAt: &before, EndsAt: &after, IsActive: true},
+			want:   usd("1.00"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.coupon.Discount(cart, categories, tt.lineDiscounts, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s %s, want %s %s", got, got.Currency, tt.want, tt.want.Currency)
+			}
+		})
+	}
+}
+
+func TestCouponValidate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		coupon  Coupon
+		wantErr bool
+	}{
+		{"percentage", Coupon{Code: "TEN", Type: CouponTypePercentage, PercentOff: 1000}, false},
+		{"whole cart free", Coupon{Code: "ALL", Type: CouponTypePercentage, PercentOff: 10000}, false},
+		{"over 100 percent", Coupon{Code: "ALL", Type: CouponTypePercentage, PercentOff: 10001}, true},
+		{"zero percent", Coupon{Code: "NONE", Type: CouponTypePercentage}, true},
+		{"fixed amount", Coupon{Code: "FIVE", Type: CouponTypeFixedAmount, AmountOff: NewMoney(500, "USD")}, false},
+		{"fixed without amount", Coupon{Code: "FIVE", Type: CouponTypeFixedAmount}, true},
+		{"free shipping", Coupon{Code: "SHIP", Type: CouponTypeFreeShipping}, false},
+		{"no code", Coupon{Type: CouponTypeFreeShipping}, true},
+		{"unknown type", Coupon{Code: "X", Type: "bogo"}, true},
+		{"mixed currencies", Coupon{Code: "FIVE", Type: CouponTypeFixedAmount, AmountOff: NewMoney(500, "USD"), MinOrderValue: NewMoney(2000, "EUR")}, true},
+		{"negative minimum", Coupon{Code: "SHIP", Type: CouponTypeFreeShipping, MinOrderValue: NewMoney(-1, "USD")}, true},
+		{"negative usage limit", Coupon{Code: "SHIP", Type: CouponTypeFreeShipping, MaxUsesPerUser: -1}, true},
+		{"ends after it starts", Coupon{Code: "SHIP", Type: CouponTypeFreeShipping, StartsAt: &now, EndsAt: &later}, false},
+		{"ends when it starts", Coupon{Code: "SHIP", Type: CouponTypeFreeShipping, StartsAt: &now, EndsAt: &now}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.coupon.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCouponInCurrency(t *testing.T) {
+	coupon := &Coupon{
+		Type:          CouponTypeFixedAmount,
+		AmountOff:     NewMoney(1000, "USD"),
+		MinOrderValue: NewMoney(5000, "USD"),
+	}
+
+	converted := coupon.InCurrency("JPY", big.NewRat(14955, 100))
+	if want := NewMoney(1496, "JPY"); converted.AmountOff != want {
+		t.Errorf("amount off: got %+v, want %+v", converted.AmountOff, want)
+	}
+	if want := NewMoney(7478, "JPY"); converted.MinOrderValue != want {
+		t.Errorf("minimum: got %+v, want %+v", converted.MinOrderValue, want)
+	}
+	if coupon.AmountOff.Currency != "USD" {
+		t.Errorf("original coupon changed to %s", coupon.AmountOff.Currency)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		text string
+	}{
+		{"12.5", 1250, "12.5"},
+		{"10", 1000, "10"},
+		{"0.125", 13, "0.13"},
+		{"100", 10000, "100"},
+		{"7.05", 705, "7.05"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePercent(tt.in)
+		if err != nil {
+			t.Errorf("ParsePercent(%q): unexpected error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePercent(%q): got %d, want %d", tt.in, got, tt.want)
+		}
+		if text := FormatPercent(got); text != tt.text {
+			t.Errorf("FormatPercent(%d): got %q, want %q", got, text, tt.text)
+		}
+	}
+
+	if _, err := ParsePercent("ten"); err == nil {
+		t.Error("ParsePercent(\"ten\"): expected an error")
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"math/big"
+	"time"
+)
+
+// ExchangeRate says how many units of Quote one unit of Base buys
+type ExchangeRate struct {
+	Base      string
+	Quote     string
+	Rate      *big.Rat
+	UpdatedAt time.Time
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 code
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
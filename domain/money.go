@@ -0,0 +1,373 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when an amount arrives without a currency code
+const DefaultCurrency = "USD"
+
+var (
+	ErrInvalidAmount    = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// decimalPattern matches plain decimal numbers such as "12", "-0.5" or ".25"
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// currencyExponents lists currencies whose minor unit is not a hundredth
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "ISK": 0, "JPY": 0, "KRW": 0, "PYG": 0, "VND": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimal places of a currency
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Money is an exact amount held as an integer count of the currency's minor
+// units (cents for USD). The zero value is zero in no particular currency
+// and adopts the currency of whatever it is combined with.
+//
+// Whenever an amount has to be rounded to minor units, halves are rounded
+// away from zero (commercial rounding), so 0.125 USD becomes 0.13 USD.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney creates Money from an amount in minor units
+func NewMoney(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "12.34" or "-0.5" in the given
+// currency. Digits beyond the currency's precision are rounded.
+func ParseMoney(s, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	minor, err := ratToMinor(r, CurrencyExponent(currency))
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", err, s)
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics on error. It is meant for
+// constants in code.
+func MustParseMoney(s, currency string) Money {
+	m, err := ParseMoney(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// String formats the amount as a plain decimal, e.g. "12.34"
+func (m Money) String() string {
+	exp := CurrencyExponent(m.currency())
+	if exp == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absInt64(amount), 10)
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// IsNegative reports whether the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o. It panics if both carry different currencies.
+func (m Money) Add(o Money) Money {
+	currency := m.mustMatch(o)
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}
+}
+
+// Sub returns m - o. It panics if both carry different currencies.
+func (m Money) Sub(o Money) Money {
+	currency := m.mustMatch(o)
+	return Money{Amount: m.Amount - o.Amount, Currency: currency}
+}
+
+// Cmp compares m and o and returns -1, 0 or +1. It panics if both carry
+// different currencies.
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	}
+	return 0
+}
+
+// Mul returns m multiplied by a whole quantity
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// MulRat returns m multiplied by num/den, rounded to minor units
+func (m Money) MulRat(num, den int64) Money {
+	r := new(big.Rat).SetFrac(big.NewInt(m.Amount), big.NewInt(1))
+	r.Mul(r, big.NewRat(num, den))
+	minor, _ := ratToMinor(r, 0)
+	return Money{Amount: minor, Currency: m.Currency}
+}
+
+// Convert returns m expressed in another currency, where rate is the number
+// of units of to per unit of m's currency. The result is rounded to minor
+// units of to.
+func (m Money) Convert(to string, rate *big.Rat) Money {
+	if to == m.Currency {
+		return m
+	}
+
+	major := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(CurrencyExponent(m.currency())))
+	major.Mul(major, rate)
+	minor, _ := ratToMinor(major, CurrencyExponent(to))
+	return Money{Amount: minor, Currency: to}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Min returns the smaller of m and o
+func (m Money) Min(o Money) Money {
+	if m.Cmp(o) <= 0 {
+		return m
+	}
+	return o
+}
+
+// Allocate splits m across weights in proportion to them. The last
+// positive weight takes the rounding remainder so the parts add up to m.
+func (m Money) Allocate(weights []Money) []Money {
+	parts := make([]Money, len(weights))
+	total := NewMoney(0, m.Currency)
+	last := -1
+	for i, w := range weights {
+		parts[i] = NewMoney(0, m.Currency)
+		if w.IsPositive() {
+			total = total.Add(w)
+			last = i
+		}
+	}
+	if last < 0 {
+		return parts
+	}
+
+	allocated := NewMoney(0, m.Currency)
+	for i, w := range weights {
+		if !w.IsPositive() {
+			continue
+		}
+		if i == last {
+			parts[i] = m.Sub(allocated)
+			break
+		}
+		parts[i] = m.MulRat(w.Amount, total.Amount)
+		allocated = allocated.Add(parts[i])
+	}
+	return parts
+}
+
+// MarshalJSON encodes Money as {"amount":"12.34","currency":"USD"}; the
+// amount is a string so clients never parse it as a binary float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.currency()})
+}
+
+// UnmarshalJSON accepts the object form written by MarshalJSON as well as a
+// bare decimal string or number, taken to be in the currency already set on
+// m or else DefaultCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '{' {
+		var obj struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		amount, err := jsonDecimal(obj.Amount)
+		if err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(amount, obj.Currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	amount, err := jsonDecimal(data)
+	if err != nil {
+		return err
+	}
+	parsed, err := ParseMoney(amount, m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column. The currency is left as is, falling back to
+// DefaultCurrency, since it is stored in a separate column.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		s = "0"
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	parsed, err := ParseMoney(s, m.currency())
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value writes the amount as a decimal string for DECIMAL columns
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// currency returns the currency code, defaulting to DefaultCurrency
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// mustMatch returns the common currency of m and o. A zero amount without a
+// currency matches anything.
+func (m Money) mustMatch(o Money) string {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency
+	}
+	panic(fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency))
+}
+
+// jsonDecimal extracts the decimal text of a JSON string or number literal
+// without passing it through float64
+func jsonDecimal(data []byte) (string, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+	}
+	return n.String(), nil
+}
+
+// ratToMinor scales r by 10^exp and rounds half away from zero to an int64
+func ratToMinor(r *big.Rat, exp int) (int64, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(exp)))
+
+	num := new(big.Int).Abs(scaled.Num())
+	quo, rem := new(big.Int).QuoRem(num, scaled.Denom(), new(big.Int))
+	// Round up when the remainder is at least half the denominator
+	if rem.Lsh(rem, 1).Cmp(scaled.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if scaled.Sign() < 0 {
+		quo.Neg(quo)
+	}
+
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+	return quo.Int64(), nil
+}
+
+// pow10 returns 10^exp
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// absInt64 returns |v| as a uint64, valid for math.MinInt64 too
+func absInt64(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
@@ -0,0 +1,212 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"12.34", "USD", NewMoney(1234, "USD"), false},
+		{"12", "USD", NewMoney(1200, "USD"), false},
+		{".25", "EUR", NewMoney(25, "EUR"), false},
+		{" 7.5 ", "USD", NewMoney(750, "USD"), false},
+		{"1.5", "", NewMoney(150, DefaultCurrency), false},
+		// Halves round away from zero
+		{"0.125", "USD", NewMoney(13, "USD"), false},
+		{"-0.125", "USD", NewMoney(-13, "USD"), false},
+		{"0.124", "USD", NewMoney(12, "USD"), false},
+		{"0.005", "USD", NewMoney(1, "USD"), false},
+		{"0.0049", "USD", NewMoney(0, "USD"), false},
+		// Rounding follows the currency's precision
+		{"12.5", "JPY", NewMoney(13, "JPY"), false},
+		{"12.4", "JPY", NewMoney(12, "JPY"), false},
+		{"1.2345", "KWD", NewMoney(1235, "KWD"), false},
+		{"", "USD", Money{}, true},
+		{"abc", "USD", Money{}, true},
+		{"1e3", "USD", Money{}, true},
+		{"1,000.00", "USD", Money{}, true},
+		{"99999999999999999999", "USD", Money{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, tt.currency)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("ParseMoney(%q, %q): got error %v, want ErrInvalidAmount", tt.in, tt.currency, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %q): unexpected error %v", tt.in, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q, %q): got %+v, want %+v", tt.in, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{NewMoney(1234, "USD"), "12.34"},
+		{NewMoney(5, "USD"), "0.05"},
+		{NewMoney(-5, "USD"), "-0.05"},
+		{NewMoney(0, "EUR"), "0.00"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(1, "KWD"), "0.001"},
+		{Money{}, "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	usd := func(minor int64) Money { return NewMoney(minor, "USD") }
+
+	tests := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"add", usd(150).Add(usd(275)), usd(425)},
+		{"sub below zero", usd(150).Sub(usd(275)), usd(-125)},
+		{"zero adopts currency", Money{}.Add(usd(99)), usd(99)},
+		{"adds zero without currency", usd(99).Sub(Money{}), usd(99)},
+		{"mul", usd(333).Mul(3), usd(999)},
+		{"mulrat rounds half up", usd(5).MulRat(1, 2), usd(3)},
+		{"mulrat rounds half away from zero", usd(-5).MulRat(1, 2), usd(-3)},
+		{"mulrat rounds down", usd(100).MulRat(1, 3), usd(33)},
+		{"neg", usd(42).Neg(), usd(-42)},
+		{"min", usd(42).Min(usd(41)), usd(41)},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMoneyCurrencyMismatch(t *testing.T) {
+	usd := NewMoney(100, "USD")
+	eur := NewMoney(100, "EUR")
+
+	tests := []struct {
+		name string
+		op   func()
+	}{
+		{"add", func() { usd.Add(eur) }},
+		{"sub", func() { usd.Sub(eur) }},
+		{"cmp", func() { usd.Cmp(eur) }},
+		{"min", func() { usd.Min(eur) }},
+		{"nonzero without currency", func() { usd.Add(Money{Amount: 1}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrCurrencyMismatch) {
+					t.Errorf("got panic %v, want ErrCurrencyMismatch", err)
+				}
+			}()
+			tt.op()
+		})
+	}
+}
+
+func TestMoneyConvert(t *testing.T) {
+	tests := []struct {
+		m    Money
+		to   string
+		rate *big.Rat
+		want Money
+	}{
+		{NewMoney(1000, "USD"), "EUR", big.NewRat(92, 100), NewMoney(920, "EUR")},
+		{NewMoney(1, "USD"), "EUR", big.NewRat(1, 2), NewMoney(1, "EUR")},
+		{NewMoney(999, "USD"), "JPY", big.NewRat(1495, 10), NewMoney(1494, "JPY")},
+		{NewMoney(1500, "JPY"), "USD", big.NewRat(1, 150), NewMoney(1000, "USD")},
+		{NewMoney(1234, "USD"), "USD", big.NewRat(2, 1), NewMoney(1234, "USD")},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Convert(tt.to, tt.rate); got != tt.want {
+			t.Errorf("%+v to %s at %s: got %+v, want %+v", tt.m, tt.to, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	usd := func(minor int64) Money { return NewMoney(minor, "USD") }
+
+	tests := []struct {
+		name    string
+		m       Money
+		weights []Money
+		want    []Money
+	}{
+		{"proportional", usd(100), []Money{usd(300), usd(100)}, []Money{usd(75), usd(25)}},
+		{"remainder to last", usd(100), []Money{usd(1), usd(1), usd(1)}, []Money{usd(33), usd(33), usd(34)}},
+		{"skips zero weights", usd(100), []Money{usd(1), usd(0)}, []Money{usd(100), usd(0)}},
+		{"no positive weight", usd(100), []Money{usd(0), usd(-5)}, []Money{usd(0), usd(0)}},
+	}
+
+	for _, tt := range tests {
+		got := tt.m.Allocate(tt.weights)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %d parts, want %d", tt.name, len(got), len(tt.want))
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: part %d: got %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+	}{
+		{`{"amount":"12.34","currency":"EUR"}`, "", NewMoney(1234, "EUR")},
+		{`{"amount":12.345,"currency":"USD"}`, "", NewMoney(1235, "USD")},
+		{`"9.99"`, "GBP", NewMoney(999, "GBP")},
+		{`0.1`, "", NewMoney(10, DefaultCurrency)},
+	}
+
+	for _, tt := range tests {
+		got := Money{Currency: tt.currency}
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("%s: unexpected error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	data, err := json.Marshal(NewMoney(-5, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"-0.05","currency":"USD"}`; string(data) != want {
+		t.Errorf("marshal: got %s, want %s", data, want)
+	}
+}
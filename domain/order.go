@@ -0,0 +1,89 @@
+package domain
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusFulfilled OrderStatus = "fulfilled"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+// orderTransitions lists, for every status, the statuses an order may move to.
+// Cancelled and refunded are terminal.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusFulfilled, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusFulfilled: {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// IsValid reports whether s is a known order status
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an order in status s may move to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type Order struct {
+	ID               int64
+	UserID           int64
+	CartID           int64
+	Currency         string
+	TotalAmount      Money
+	DiscountAmount   Money
+	CouponCode       string
+	TaxAmount        Money // within TotalAmount when PricesIncludeTax, added to it otherwise
+	PricesIncludeTax bool
+	TaxCountry       string
+	TaxRegion        string
+	ShippingMethod   string
+	ShippingAmount   Money
+	ShippingAddress  *Address
+	BillingAddress   *Address
+	RefundedAmount   Money
+	Status           OrderStatus
+	CreatedAt        time.Time
+	Items            []OrderItem
+}
+
+type OrderItem struct {
+	ID               int64
+	OrderID          int64
+	ProductID        int64
+	ProductName      string
+	Quantity         int
+	Price            Money
+	DiscountAmount   Money
+	TaxAmount        Money
+	TaxRate          string // percentage
+	ReturnedQuantity int
+}
+
+// OrderStatusChange is an audit record of a single status transition
+type OrderStatusChange struct {
+	ID         int64
+	OrderID    int64
+	FromStatus OrderStatus
+	ToStatus   OrderStatus
+	ChangedBy  int64
+	Note       string
+	ChangedAt  time.Time
+}
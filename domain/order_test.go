@@ -0,0 +1,62 @@
+package domain
+
+import "testing"
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from OrderStatus
+		to   OrderStatus
+		want bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusFulfilled, false},
+		{OrderStatusPending, OrderStatusRefunded, false},
+		{OrderStatusPaid, OrderStatusFulfilled, true},
+		{OrderStatusPaid, OrderStatusCancelled, true},
+		{OrderStatusPaid, OrderStatusRefunded, true},
+		{OrderStatusPaid, OrderStatusPending, false},
+		{OrderStatusFulfilled, OrderStatusShipped, true},
+		{OrderStatusFulfilled, OrderStatusRefunded, true},
+		{OrderStatusFulfilled, OrderStatusCancelled, false},
+		{OrderStatusShipped, OrderStatusDelivered, true},
+		{OrderStatusShipped, OrderStatusRefunded, false},
+		{OrderStatusDelivered, OrderStatusRefunded, true},
+		{OrderStatusDelivered, OrderStatusShipped, false},
+		// Cancelled and refunded are terminal
+		{OrderStatusCancelled, OrderStatusPending, false},
+		{OrderStatusCancelled, OrderStatusPaid, false},
+		{OrderStatusRefunded, OrderStatusPaid, false},
+		{OrderStatusRefunded, OrderStatusRefunded, false},
+		// No status moves to itself
+		{OrderStatusPaid, OrderStatusPaid, false},
+		// Unknown statuses go nowhere
+		{OrderStatus("lost"), OrderStatusPaid, false},
+		{OrderStatusPending, OrderStatus("lost"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestOrderStatusIsValid(t *testing.T) {
+	tests := []struct {
+		status OrderStatus
+		want   bool
+	}{
+		{OrderStatusPending, true},
+		{OrderStatusDelivered, true},
+		{OrderStatusRefunded, true},
+		{OrderStatus(""), false},
+		{OrderStatus("Paid"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.IsValid(); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
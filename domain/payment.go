@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending           PaymentStatus = "pending"
+	PaymentStatusAuthorized        PaymentStatus = "authorized"
+	PaymentStatusCaptured          PaymentStatus = "captured"
+	PaymentStatusVoided            PaymentStatus = "voided"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+	PaymentStatusDeclined          PaymentStatus = "declined"
+	PaymentStatusFailed            PaymentStatus = "failed"
+)
+
+// paymentTransitions lists, for every status, the statuses a payment may move
+// to. Pending covers providers that confirm asynchronously via webhook.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending:           {PaymentStatusAuthorized, PaymentStatusCaptured, PaymentStatusDeclined, PaymentStatusFailed},
+	PaymentStatusAuthorized:        {PaymentStatusCaptured, PaymentStatusVoided, PaymentStatusFailed},
+	PaymentStatusCaptured:          {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusPartiallyRefunded: {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusVoided:            {},
+	PaymentStatusRefunded:          {},
+	PaymentStatusDeclined:          {},
+	PaymentStatusFailed:            {},
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Payment is a payment intent for an order held at a payment provider
+type Payment struct {
+	ID             int64
+	OrderID        int64
+	Provider       string
+	ProviderRef    string
+	IdempotencyKey string
+	Amount         Money
+	RefundedAmount Money
+	Currency       string
+	Status         PaymentStatus
+	Message        string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type PaymentRefundStatus string
+
+const (
+	PaymentRefundStatusPending   PaymentRefundStatus = "pending"
+	PaymentRefundStatusCompleted PaymentRefundStatus = "completed"
+	PaymentRefundStatusReleased  PaymentRefundStatus = "released"
+)
+
+// PaymentRefund is a refund of a payment, reserved while the payment provider
+// is asked to return the money
+type PaymentRefund struct {
+	ID             int64
+	PaymentID      int64
+	IdempotencyKey string
+	Amount         Money
+	Status         PaymentRefundStatus
+	CreatedAt      time.Time
+}
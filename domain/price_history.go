@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrScheduleStartInPast = errors.New("scheduled price must start in the future")
+	ErrScheduleEndsBefore  = errors.New("scheduled price must end after it starts")
+)
+
+// PriceChangeSource tells what changed a product's price
+type PriceChangeSource string
+
+const (
+	PriceChangeManual    PriceChangeSource = "manual"
+	PriceChangeImport    PriceChangeSource = "import"
+	PriceChangeScheduled PriceChangeSource = "scheduled"
+)
+
+// PriceChange is an audit record of a change to a product's base price.
+// ChangedBy is the staff member responsible, or zero when unknown. Changes
+// made by a price schedule carry its ScheduleID.
+type PriceChange struct {
+	ID         int64
+	ProductID  int64
+	OldPrice   Money
+	NewPrice   Money
+	Source     PriceChangeSource
+	ScheduleID int64
+	ChangedBy  int64
+	ChangedAt  time.Time
+}
+
+type PriceScheduleStatus string
+
+const (
+	PriceSchedulePending   PriceScheduleStatus = "pending"
+	PriceScheduleActive    PriceScheduleStatus = "active"
+	PriceScheduleCompleted PriceScheduleStatus = "completed"
+	PriceScheduleCancelled PriceScheduleStatus = "cancelled"
+)
+
+// PriceSchedule changes a product's price to Price at StartsAt. With EndsAt
+// it is a sale: at EndsAt the price returns to RevertPrice, the price the
+// product had when the sale started, unless the price was changed again in
+// the meantime. Without EndsAt the change is permanent.
+type PriceSchedule struct {
+	ID          int64
+	ProductID   int64
+	Price       Money
+	StartsAt    time.Time
+	EndsAt      *time.Time
+	RevertPrice *Money
+	Status      PriceScheduleStatus
+	CreatedBy   int64
+	CreatedAt   time.Time
+}
+
+// Validate checks the schedule's window relative to now
+func (s *PriceSchedule) Validate(now time.Time) error {
+	if !s.Price.IsPositive() {
+		return ErrInvalidAmount
+	}
+	if !s.StartsAt.After(now) {
+		return ErrScheduleStartInPast
+	}
+	if s.EndsAt != nil && !s.EndsAt.After(s.StartsAt) {
+		return ErrScheduleEndsBefore
+	}
+	return nil
+}
@@ -1,8 +1,34 @@
 package domain
 
+import "time"
+
+// UnchangedStock is the Stock of a product update that keeps the product's
+// current stock count
+const UnchangedStock = -1
+
+// Product's Version is incremented by every change, for optimistic
+// concurrency control. SKU identifies the product in catalog imports and
+// exports; products created one by one may have none. Slug is the
+// product's name in URLs, generated from Name. Deleting a product
+// archives it, setting DeletedAt, so carts and orders referencing it keep
+// working until it is purged.
 type Product struct {
 	ID          int64
+	SKU         string
+	Slug        string
 	Name        string
-	Price       float64
+	Price       Money
 	Description string
+	Category    string
+	TaxClass    string
+	WeightGrams int
+	Stock       int
+	Version     int64
+	DeletedAt   *time.Time
+}
+
+// IsArchived reports whether the product was deleted and can no longer be
+// bought
+func (p *Product) IsArchived() bool {
+	return p.DeletedAt != nil
 }
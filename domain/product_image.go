@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// ProductImage is an uploaded image of a product. Key locates the original
+// in blob storage; images are shown in ascending Position.
+type ProductImage struct {
+	ID          int64
+	ProductID   int64
+	Key         string
+	ContentType string
+	Width       int
+	Height      int
+	SizeBytes   int64
+	AltText     string
+	Position    int
+	Thumbnails  []ImageThumbnail
+	CreatedAt   time.Time
+}
+
+// ImageThumbnail is a scaled down copy of a product image, generated for
+// each configured size name such as "small"
+type ImageThumbnail struct {
+	Size   string
+	Key    string
+	Width  int
+	Height int
+}
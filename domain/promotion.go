@@ -0,0 +1,318 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+type PromotionType string
+
+const (
+	// PromotionBuyXGetY discounts GetQuantity units of a line for every
+	// BuyQuantity units bought, by PercentOff (100% makes them free)
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+	// PromotionVolumeTier discounts matching lines by the percentage of the
+	// highest tier reached by their combined quantity
+	PromotionVolumeTier PromotionType = "volume_tier"
+	// PromotionBundle sells one of each product in ProductIDs together for
+	// BundlePrice
+	PromotionBundle PromotionType = "bundle"
+	// PromotionSale discounts matching lines by PercentOff
+	PromotionSale PromotionType = "sale"
+)
+
+// IsValid reports whether t is a known promotion type
+func (t PromotionType) IsValid() bool {
+	switch t {
+	case PromotionBuyXGetY, PromotionVolumeTier, PromotionBundle, PromotionSale:
+		return true
+	}
+	return false
+}
+
+// Promotion is a rule applied to carts automatically. Promotions are
+// evaluated from the highest Priority down. A non-stackable promotion only
+// applies to lines no other promotion has discounted, and once applied no
+// further promotion discounts those lines; stackable promotions combine with
+// each other.
+type Promotion struct {
+	ID          int64
+	Name        string
+	Type        PromotionType
+	Priority    int
+	Stackable   bool
+	StartsAt    *time.Time
+	EndsAt      *time.Time
+	ProductIDs  []int64
+	Categories  []string
+	BuyQuantity int
+	GetQuantity int
+	PercentOff  int64 // hundredths of a percent
+	Tiers       []PromotionTier
+	BundlePrice Money
+	IsActive    bool
+	CreatedAt   time.Time
+}
+
+// PromotionTier is one step of a volume discount
+type PromotionTier struct {
+	MinQuantity int
+	PercentOff  int64 // hundredths of a percent
+}
+
+// AppliedPromotion explains a discount a promotion gave on one cart line
+type AppliedPromotion struct {
+	PromotionID int64
+	Name        string
+	Description string
+	Discount    Money
+}
+
+// PromotionResult is the outcome of running the promotions over a cart
+type PromotionResult struct {
+	Lines         map[int64][]AppliedPromotion // by product ID
+	LineDiscounts map[int64]Money              // by product ID
+	Total         Money
+}
+
+// Validate checks that the promotion definition is consistent
+func (p *Promotion) Validate() error {
+	switch {
+	case p.Name == "":
+		return errors.New("promotion name required")
+	case !p.Type.IsValid():
+		return fmt.Errorf("invalid promotion type %q", p.Type)
+	case p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt):
+		return errors.New("promotion must end after it starts")
+	}
+
+	switch p.Type {
+	case PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return errors.New("buy and get quantities must be positive")
+		}
+		if p.PercentOff <= 0 || p.PercentOff > 10000 {
+			return errors.New("percentage must be greater than 0 and at most 100")
+		}
+	case PromotionSale:
+		if p.PercentOff <= 0 || p.PercentOff > 10000 {
+			return errors.New("percentage must be greater than 0 and at most 100")
+		}
+	case PromotionVolumeTier:
+		if len(p.Tiers) == 0 {
+			return errors.New("volume promotions need at least one tier")
+		}
+		for _, tier := range p.Tiers {
+			if tier.MinQuantity <= 0 || tier.PercentOff <= 0 || tier.PercentOff > 10000 {
+				return errors.New("tiers need a positive quantity and a percentage up to 100")
+			}
+		}
+	case PromotionBundle:
+		if len(p.ProductIDs) < 2 || len(p.Categories) > 0 {
+			return errors.New("bundles need at least two products and no categories")
+		}
+		if !p.BundlePrice.IsPositive() {
+			return errors.New("bundles need a positive price")
+		}
+	}
+	return nil
+}
+
+// IsActiveAt reports whether the promotion is enabled and running at t
+func (p *Promotion) IsActiveAt(t time.Time) bool {
+	return p.IsActive && inWindow(p.StartsAt, p.EndsAt, t)
+}
+
+// AppliesTo reports whether a cart line for the product is targeted
+func (p *Promotion) AppliesTo(productID int64, category string) bool {
+	return matchesTargets(p.ProductIDs, p.Categories, productID, category)
+}
+
+// InCurrency returns a copy of the promotion with its bundle price
+// converted to currency at rate
+func (p *Promotion) InCurrency(currency string, rate *big.Rat) *Promotion {
+	converted := *p
+	if !p.BundlePrice.IsZero() {
+		converted.BundlePrice = p.BundlePrice.Convert(currency, rate)
+	}
+	return &converted
+}
+
+// Describe returns a customer-facing summary of the promotion's rule
+func (p *Promotion) Describe() string {
+	switch p.Type {
+	case PromotionBuyXGetY:
+		if p.PercentOff == 10000 {
+			return fmt.Sprintf("Buy %d, get %d free", p.BuyQuantity, p.GetQuantity)
+		}
+		return fmt.Sprintf("Buy %d, get %d at %s%% off", p.BuyQuantity, p.GetQuantity, FormatPercent(p.PercentOff))
+	case PromotionVolumeTier:
+		tiers := p.sortedTiers()
+		return fmt.Sprintf("Up to %s%% off when buying %d or more", FormatPercent(tiers[len(tiers)-1].PercentOff), tiers[0].MinQuantity)
+	case PromotionBundle:
+		return fmt.Sprintf("Bundle of %d products for %s %s", len(p.ProductIDs), p.BundlePrice, p.BundlePrice.Currency)
+	case PromotionSale:
+		return fmt.Sprintf("%s%% off", FormatPercent(p.PercentOff))
+	}
+	return p.Name
+}
+
+// ApplyPromotions runs promotions over cart at time now. categories maps the
+// cart's product IDs to their category, and promotion amounts must already
+// be in the cart currency.
+func ApplyPromotions(cart *Cart, promotions []*Promotion, categories map[int64]string, now time.Time) *PromotionResult {
+	result := &PromotionResult{
+		Lines:         make(map[int64][]AppliedPromotion),
+		LineDiscounts: make(map[int64]Money),
+		Total:         NewMoney(0, cart.Currency),
+	}
+
+	ordered := make([]*Promotion, len(promotions))
+	copy(ordered, promotions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	// Lines discounted by a non-stackable promotion take no further discounts
+	exclusive := make(map[int64]bool)
+
+	for _, p := range ordered {
+		if !p.IsActiveAt(now) {
+			continue
+		}
+
+		var lines []CartItem
+		for _, item := range cart.Items {
+			if !p.AppliesTo(item.ProductID, categories[item.ProductID]) || exclusive[item.ProductID] {
+				continue
+			}
+			if !p.Stackable && len(result.Lines[item.ProductID]) > 0 {
+				continue
+			}
+			lines = append(lines, item)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		discounts := p.lineDiscounts(lines, cart.Currency)
+		for _, item := range lines {
+			discount, ok := discounts[item.ProductID]
+			if !ok {
+				continue
+			}
+
+			// Never discount a line below zero
+			remaining := item.Price.Mul(int64(item.Quantity)).Sub(result.LineDiscounts[item.ProductID])
+			discount = discount.Min(remaining)
+			if !discount.IsPositive() {
+				continue
+			}
+
+			result.Lines[item.ProductID] = append(result.Lines[item.ProductID], AppliedPromotion{
+				PromotionID: p.ID,
+				Name:        p.Name,
+				Description: p.Describe(),
+				Discount:    discount,
+			})
+			result.LineDiscounts[item.ProductID] = result.LineDiscounts[item.ProductID].Add(discount)
+			result.Total = result.Total.Add(discount)
+			if !p.Stackable {
+				exclusive[item.ProductID] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// lineDiscounts computes the promotion's discount on each of the eligible
+// lines, keyed by product ID
+func (p *Promotion) lineDiscounts(lines []CartItem, currency string) map[int64]Money {
+	discounts := make(map[int64]Money)
+
+	switch p.Type {
+	case PromotionSale:
+		for _, item := range lines {
+			discounts[item.ProductID] = item.Price.Mul(int64(item.Quantity)).MulRat(p.PercentOff, 10000)
+		}
+
+	case PromotionBuyXGetY:
+		for _, item := range lines {
+			groups := item.Quantity / (p.BuyQuantity + p.GetQuantity)
+			if groups > 0 {
+				discounts[item.ProductID] = item.Price.Mul(int64(groups*p.GetQuantity)).MulRat(p.PercentOff, 10000)
+			}
+		}
+
+	case PromotionVolumeTier:
+		quantity := 0
+		for _, item := range lines {
+			quantity += item.Quantity
+		}
+		var percent int64
+		for _, tier := range p.sortedTiers() {
+			if quantity >= tier.MinQuantity {
+				percent = tier.PercentOff
+			}
+		}
+		if percent > 0 {
+			for _, item := range lines {
+				discounts[item.ProductID] = item.Price.Mul(int64(item.Quantity)).MulRat(percent, 10000)
+			}
+		}
+
+	case PromotionBundle:
+		byProduct := make(map[int64]CartItem, len(lines))
+		for _, item := range lines {
+			byProduct[item.ProductID] = item
+		}
+
+		// The number of complete bundles is limited by the scarcest product
+		bundles := -1
+		regular := NewMoney(0, currency)
+		for _, id := range p.ProductIDs {
+			item, ok := byProduct[id]
+			if !ok {
+				return discounts
+			}
+			if bundles < 0 || item.Quantity < bundles {
+				bundles = item.Quantity
+			}
+			regular = regular.Add(item.Price)
+		}
+
+		saving := regular.Sub(p.BundlePrice)
+		if bundles <= 0 || !saving.IsPositive() {
+			return discounts
+		}
+
+		// Share the saving across the bundle's lines by unit price; the last
+		// line takes the rounding remainder
+		allocated := NewMoney(0, currency)
+		for i, id := range p.ProductIDs {
+			share := saving.MulRat(byProduct[id].Price.Amount, regular.Amount)
+			if i == len(p.ProductIDs)-1 {
+				share = saving.Sub(allocated)
+			}
+			allocated = allocated.Add(share)
+			discounts[id] = share.Mul(int64(bundles))
+		}
+	}
+
+	return discounts
+}
+
+// sortedTiers returns the tiers by ascending minimum quantity
+func (p *Promotion) sortedTiers() []PromotionTier {
+	tiers := make([]PromotionTier, len(p.Tiers))
+	copy(tiers, p.Tiers)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinQuantity < tiers[j].MinQuantity })
+	return tiers
+}
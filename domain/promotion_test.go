@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+	usd := func(s string) Money { return MustParseMoney(s, "USD") }
+
+	// Three shirts at 10.00, a mug at 5.55 and two hats at 7.00
+	cart := &Cart{
+		Currency: "USD",
+		Items: []CartItem{
+			{ProductID: 1, Quantity: 3, Price: usd("10.00")},
+			{ProductID: 2, Quantity: 1, Price: usd("5.55")},
+			{ProductID: 3, Quantity: 2, Price: usd("7.00")},
+		},
+	}
+	categories := map[int64]string{1: "Shirts", 2: "Mugs", 3: "Hats"}
+
+	sale := func(id int64, percent int64, priority int, stackable bool, productIDs ...int64) *Promotion {
+		return &Promotion{ID: id, Name: "Sale", Type: PromotionSale, PercentOff: percent, Priority: priority,
+			Stackable: stackable, ProductIDs: productIDs, IsActive: true}
+	}
+
+	tests := []struct {
+		name       string
+		promotions []*Promotion
+		// want lists the discount and the IDs of the promotions applied, in
+		// order, for every discounted product
+		want map[int64]appliedLine
+	}{
+		{
+			name:       "sale rounds each line half up",
+			promotions: []*Promotion{sale(1, 1000, 0, false)},
+			want: map[int64]appliedLine{
+				1: {usd("3.00"), []int64{1}},
+				2: {usd("0.56"), []int64{1}},
+				3: {usd("1.40"), []int64{1}},
+			},
+		},
+		{
+			name: "buy two get one free",
+			promotions: []*Promotion{{ID: 1, Name: "3 for 2", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1,
+				PercentOff: 10000, ProductIDs: []int64{1, 3}, IsActive: true}},
+			want: map[int64]appliedLine{
+				1: {usd("10.00"), []int64{1}},
+			},
+		},
+		{
+			name: "buy two get one half off",
+			promotions: []*Promotion{{ID: 1, Name: "Half off", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1,
+				PercentOff: 5000, IsActive: true}},
+			want: map[int64]appliedLine{
+				1: {usd("5.00"), []int64{1}},
+			},
+		},
+		{
+			name: "volume tier reached by combined quantity",
+			promotions: []*Promotion{{ID: 1, Name: "Volume", Type: PromotionVolumeTier, Categories: []string{"Shirts", "Hats"},
+				Tiers: []PromotionTier{{MinQuantity: 5, PercentOff: 1000}, {MinQuantity: 2, PercentOff: 500}}, IsActive: true}},
+			want: map[int64]appliedLine{
+				1: {usd("3.00"), []int64{1}},
+				3: {usd("1.40"), []int64{1}},
+			},
+		},
+		{
+			name: "volume tier not reached",
+			promotions: []*Promotion{{ID: 1, Name: "Volume", Type: PromotionVolumeTier,
+				Tiers: []PromotionTier{{MinQuantity: 10, PercentOff: 1000}}, IsActive: true}},
+			want: map[int64]appliedLine{},
+		},
+		{
+			name: "bundle saving split by unit price",
+			promotions: []*Promotion{{ID: 1, Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 2},
+				BundlePrice: usd("12.00"), IsActive: true}},
+			// 3.55 saved on one bundle; the mug takes the rounding remainder
+			want: map[int64]appliedLine{
+				1: {usd("2.28"), []int64{1}},
+				2: {usd("1.27"), []int64{1}},
+			},
+		},
+		{
+			name: "bundle counts complete sets only",
+			promotions: []*Promotion{{ID: 1, Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 3},
+				BundlePrice: usd("15.00"), IsActive: true}},
+			// Two sets of a shirt and a hat, each 2.00 off
+			want: map[int64]appliedLine{
+				1: {usd("2.36"), []int64{1}},
+				3: {usd("1.64"), []int64{1}},
+			},
+		},
+		{
+			name: "bundle missing a product",
+			promotions: []*Promotion{{ID: 1, Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 4},
+				BundlePrice: usd("5.00"), IsActive: true}},
+			want: map[int64]appliedLine{},
+		},
+		{
+			name: "bundle dearer than its products",
+			promotions: []*Promotion{{ID: 1, Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 2},
+				BundlePrice: usd("20.00"), IsActive: true}},
+			want: map[int64]appliedLine{},
+		},
+		{
+			name:       "stackable promotions combine",
+			promotions: []*Promotion{sale(1, 1000, 1, true, 3), sale(2, 1000, 2, true, 3)},
+			want: map[int64]appliedLine{
+				3: {usd("2.80"), []int64{2, 1}},
+			},
+		},
+		{
+			name:       "non-stackable promotion takes its lines",
+			promotions: []*Promotion{sale(1, 1000, 2, false), sale(2, 2000, 1, true)},
+			want: map[int64]appliedLine{
+				1: {usd("3.00"), []int64{1}},
+				2: {usd("0.56"), []int64{1}},
+				3: {usd("1.40"), []int64{1}},
+			},
+		},
+		{
+			name:       "non-stackable promotion skips discounted lines",
+			promotions: []*Promotion{sale(1, 1000, 2, true, 1), sale(2, 5000, 1, false)},
+			want: map[int64]appliedLine{
+				1: {usd("3.00"), []int64{1}},
+				2: {usd("2.78"), []int64{2}},
+				3: {usd("7.00"), []int64{2}},
+			},
+		},
+		{
+			name:       "equal priorities apply in ID order",
+			promotions: []*Promotion{sale(5, 2000, 1, false, 2), sale(3, 1000, 1, false, 2)},
+			want: map[int64]appliedLine{
+				2: {usd("0.56"), []int64{3}},
+			},
+		},
+		{
+			name:       "never below zero",
+			promotions: []*Promotion{sale(1, 10000, 2, true, 1), sale(2, 5000, 1, true, 1)},
+			want: map[int64]appliedLine{
+				1: {usd("30.00"), []int64{1}},
+			},
+		},
+		{
+			name: "inactive and ended promotions are ignored",
+			promotions: []*Promotion{
+				{ID: 1, Name: "Off", Type: PromotionSale, PercentOff: 1000},
+				{ID: 2, Name: "Over", Type: PromotionSale, PercentOff: 1000, EndsAt: &ended, IsActive: true},
+			},
+			want: map[int64]appliedLine{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ApplyPromotions(cart, tt.promotions, categories, now)
+
+			total := usd("0")
+			for productID, want := range tt.want {
+				total = total.Add(want.discount)
+				if got := result.LineDiscounts[productID]; got != want.discount {
+					t.Errorf("product %d: got discount %s, want %s", productID, got, want.discount)
+				}
+
+				applied := result.Lines[productID]
+				if len(applied) != len(want.promotionIDs) {
+					t.Errorf("product %d: got %d promotions, want %v", productID, len(applied), want.promotionIDs)
+					continue
+				}
+				for i, a := range applied {
+					if a.PromotionID != want.promotionIDs[i] {
+						t.Errorf("product %d: promotion %d: got ID %d, want %d", productID, i, a.PromotionID, want.promotionIDs[i])
+					}
+				}
+			}
+			for productID, got := range result.LineDiscounts {
+				if _, ok := tt.want[productID]; !ok {
+					t.Errorf("product %d: unexpected discount %s", productID, got)
+				}
+			}
+			if result.Total != total {
+				t.Errorf("total: got %s, want %s", result.Total, total)
+			}
+		})
+	}
+}
+
+// appliedLine is the expected outcome of the promotions on one cart line
+type appliedLine struct {
+	discount     Money
+	promotionIDs []int64
+}
+
+func TestPromotionDescribe(t *testing.T) {
+	tests := []struct {
+		promotion Promotion
+		want      string
+	}{
+		{Promotion{Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, PercentOff: 10000}, "Buy 2, get 1 free"},
+		{Promotion{Type: PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1, PercentOff: 5000}, "Buy 1, get 1 at 50% off"},
+		{Promotion{Type: PromotionVolumeTier, Tiers: []PromotionTier{{10, 1500}, {3, 500}}}, "Up to 15% off when buying 3 or more"},
+		{Promotion{Type: PromotionBundle, ProductIDs: []int64{1, 2, 3}, BundlePrice: NewMoney(2500, "EUR")}, "Bundle of 3 products for 25.00 EUR"},
+		{Promotion{Type: PromotionSale, PercentOff: 1250}, "12.5% off"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.promotion.Describe(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.promotion.Type, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion Promotion
+		wantErr   bool
+	}{
+		{"sale", Promotion{Name: "Sale", Type: PromotionSale, PercentOff: 1000}, false},
+		{"sale over 100 percent", Promotion{Name: "Sale", Type: PromotionSale, PercentOff: 10001}, true},
+		{"no name", Promotion{Type: PromotionSale, PercentOff: 1000}, true},
+		{"unknown type", Promotion{Name: "X", Type: "mystery"}, true},
+		{"buy x get y", Promotion{Name: "3 for 2", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, PercentOff: 10000}, false},
+		{"buy nothing", Promotion{Name: "3 for 2", Type: PromotionBuyXGetY, GetQuantity: 1, PercentOff: 10000}, true},
+		{"tiers", Promotion{Name: "Volume", Type: PromotionVolumeTier, Tiers: []PromotionTier{{5, 1000}}}, false},
+		{"no tiers", Promotion{Name: "Volume", Type: PromotionVolumeTier}, true},
+		{"empty tier", Promotion{Name: "Volume", Type: PromotionVolumeTier, Tiers: []PromotionTier{{0, 1000}}}, true},
+		{"bundle", Promotion{Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 2}, BundlePrice: NewMoney(100, "USD")}, false},
+		{"bundle of one", Promotion{Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1}, BundlePrice: NewMoney(100, "USD")}, true},
+		{"bundle by category", Promotion{Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 2}, Categories: []string{"Hats"}, BundlePrice: NewMoney(100, "USD")}, true},
+		{"free bundle", Promotion{Name: "Bundle", Type: PromotionBundle, ProductIDs: []int64{1, 2}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.promotion.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+// RecommendationKind is the signal a product recommendation is drawn from
+type RecommendationKind string
+
+const (
+	// RecommendationBoughtTogether pairs products ordered together
+	RecommendationBoughtTogether RecommendationKind = "bought_together"
+	// RecommendationAlsoViewed pairs products put in the same cart, which
+	// stand in for browsing sessions as product views are not recorded
+	RecommendationAlsoViewed RecommendationKind = "also_viewed"
+)
+
+// RecommendationKinds lists every kind of recommendation
+var RecommendationKinds = []RecommendationKind{RecommendationBoughtTogether, RecommendationAlsoViewed}
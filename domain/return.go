@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusRefunding ReturnStatus = "refunding"
+	ReturnStatusRefunded  ReturnStatus = "refunded"
+)
+
+// returnTransitions lists, for every status, the statuses a return may move
+// to. A refund is claimed by moving to refunding before the provider is
+// called; it is retried from refunding and goes back to received if the
+// provider refuses it.
+var returnTransitions = map[ReturnStatus][]ReturnStatus{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected},
+	ReturnStatusApproved:  {ReturnStatusReceived},
+	ReturnStatusReceived:  {ReturnStatusRefunding},
+	ReturnStatusRefunding: {ReturnStatusRefunding, ReturnStatusRefunded, ReturnStatusReceived},
+	ReturnStatusRejected:  {},
+	ReturnStatusRefunded:  {},
+}
+
+// CanTransitionTo reports whether a return in status s may move to next
+func (s ReturnStatus) CanTransitionTo(next ReturnStatus) bool {
+	for _, allowed := range returnTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+type ReturnReason string
+
+const (
+	ReturnReasonDamaged        ReturnReason = "damaged"
+	ReturnReasonWrongItem      ReturnReason = "wrong_item"
+	ReturnReasonNotAsDescribed ReturnReason = "not_as_described"
+	ReturnReasonNoLongerNeeded ReturnReason = "no_longer_needed"
+	ReturnReasonOther          ReturnReason = "other"
+)
+
+// IsValid reports whether r is a known return reason
+func (r ReturnReason) IsValid() bool {
+	switch r {
+	case ReturnReasonDamaged, ReturnReasonWrongItem, ReturnReasonNotAsDescribed,
+		ReturnReasonNoLongerNeeded, ReturnReasonOther:
+		return true
+	}
+	return false
+}
+
+// ReturnRequest (RMA) asks to send back some quantity of one order line
+type ReturnRequest struct {
+	ID           int64
+	OrderID      int64
+	OrderItemID  int64
+	UserID       int64
+	Quantity     int
+	Reason       ReturnReason
+	Comment      string
+	Status       ReturnStatus
+	RefundAmount Money
+	ReviewedBy   int64
+	StaffNote    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "testing"
+
+func TestReturnStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from ReturnStatus
+		to   ReturnStatus
+		want bool
+	}{
+		{ReturnStatusRequested, ReturnStatusApproved, true},
+		{ReturnStatusRequested, ReturnStatusRejected, true},
+		{ReturnStatusRequested, ReturnStatusReceived, false},
+		{ReturnStatusApproved, ReturnStatusReceived, true},
+		{ReturnStatusApproved, ReturnStatusRejected, false},
+		// A received return is only refunded by claiming it first
+		{ReturnStatusReceived, ReturnStatusRefunding, true},
+		{ReturnStatusReceived, ReturnStatusRefunded, false},
+		// A claimed refund is retried, completed or given back
+		{ReturnStatusRefunding, ReturnStatusRefunding, true},
+		{ReturnStatusRefunding, ReturnStatusRefunded, true},
+		{ReturnStatusRefunding, ReturnStatusReceived, true},
+		{ReturnStatusRefunding, ReturnStatusApproved, false},
+		// Rejected and refunded are terminal
+		{ReturnStatusRejected, ReturnStatusApproved, false},
+		{ReturnStatusRefunded, ReturnStatusRefunding, false},
+		{ReturnStatusRefunded, ReturnStatusRefunded, false},
+		// Unknown statuses go nowhere
+		{ReturnStatus("lost"), ReturnStatusReceived, false},
+		{ReturnStatusRequested, ReturnStatus("lost"), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
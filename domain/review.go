@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+// reviewTransitions lists, for every status, the statuses a review may move
+// to. Moderators may change their minds, so a decided review can be
+// reversed.
+var reviewTransitions = map[ReviewStatus][]ReviewStatus{
+	ReviewStatusPending:  {ReviewStatusApproved, ReviewStatusRejected},
+	ReviewStatusApproved: {ReviewStatusRejected},
+	ReviewStatusRejected: {ReviewStatusApproved},
+}
+
+// CanTransitionTo reports whether a review in status s may move to next
+func (s ReviewStatus) CanTransitionTo(next ReviewStatus) bool {
+	for _, allowed := range reviewTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is a user's rating of a product. VerifiedPurchase is set when the
+// user has a paid order containing the product at the time of reviewing.
+// Reviews are only shown once a moderator approves them.
+type Review struct {
+	ID               int64
+	ProductID        int64
+	UserID           int64
+	Rating           int
+	Title            string
+	Body             string
+	VerifiedPurchase bool
+	Status           ReviewStatus
+	ModeratedBy      int64
+	ModerationNote   string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Normalize trims surrounding whitespace from the title and body
+func (r *Review) Normalize() {
+	r.Title = strings.TrimSpace(r.Title)
+	r.Body = strings.TrimSpace(r.Body)
+}
+
+// Validate checks the rating is in range and the title and body fit
+func (r *Review) Validate() error {
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		return errors.New("rating must be between 1 and 5")
+	}
+	if r.Title == "" || len(r.Title) > 200 {
+		return errors.New("title must be between 1 and 200 characters")
+	}
+	if len(r.Body) > 5000 {
+		return errors.New("body must be at most 5000 characters")
+	}
+	return nil
+}
+
+// RatingSummary aggregates a product's approved reviews. Histogram counts
+// the reviews for each rating, from 1 star at index 0 to 5 stars at index 4.
+type RatingSummary struct {
+	Average   float64
+	Count     int
+	Histogram [MaxRating]int
+}
+
+// Add counts n reviews with rating into the summary. The average is rounded
+// to two decimal places.
+func (s *RatingSummary) Add(rating, n int) {
+	if rating < MinRating || rating > MaxRating || n <= 0 {
+		return
+	}
+	s.Histogram[rating-1] += n
+	s.Count += n
+
+	total := 0
+	for i, count := range s.Histogram {
+		total += (i + 1) * count
+	}
+	s.Average = math.Round(float64(total)*100/float64(s.Count)) / 100
+}
+
+// ReviewSort orders a product's reviews
+type ReviewSort string
+
+const (
+	ReviewSortNewest     ReviewSort = "newest"
+	ReviewSortOldest     ReviewSort = "oldest"
+	ReviewSortRatingHigh ReviewSort = "rating_high"
+	ReviewSortRatingLow  ReviewSort = "rating_low"
+)
+
+// IsValid reports whether s is a known review sort
+func (s ReviewSort) IsValid() bool {
+	switch s {
+	case ReviewSortNewest, ReviewSortOldest, ReviewSortRatingHigh, ReviewSortRatingLow:
+		return true
+	}
+	return false
+}
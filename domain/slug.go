@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxSlugLength is the longest slug generated from a name, leaving room in
+// the Slug column for a numeric suffix
+const MaxSlugLength = 120
+
+// slugFallback is the slug of names without any usable character
+const slugFallback = "product"
+
+// slugTransliterations spells common accented Latin letters in ASCII
+var slugTransliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a", 'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e", 'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i", 'ł': "l", 'ľ': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Slugify turns a product name into the lowercase, hyphen separated form
+// used in product URLs, such as "cafe-creme-200g" for "Café Crème 200g".
+// Accented Latin letters are spelled without accents and other characters
+// separate words.
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, c := range strings.ToLower(name) {
+		var part string
+		switch {
+		case c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+			part = string(c)
+		case slugTransliterations[c] != "":
+			part = slugTransliterations[c]
+		default:
+			pendingHyphen = b.Len() > 0
+			continue
+		}
+		if pendingHyphen {
+			part = "-" + part
+		}
+		if b.Len()+len(part) > MaxSlugLength {
+			break
+		}
+		pendingHyphen = false
+		b.WriteString(part)
+	}
+
+	if b.Len() == 0 {
+		return slugFallback
+	}
+	return b.String()
+}
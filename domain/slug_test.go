@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Café Crème 200g", "cafe-creme-200g"},
+		{"  Hello,  World!  ", "hello-world"},
+		{"100% Cotton T-Shirt (XL)", "100-cotton-t-shirt-xl"},
+		{"--Ærøskøbing--", "aeroskobing"},
+		{"Straße", "strasse"},
+		{"Żółć", "zolc"},
+		{"Ünïcödé & Co.", "unicode-co"},
+		{"日本茶", "product"},
+		{"", "product"},
+		{strings.Repeat("a", 130), strings.Repeat("a", MaxSlugLength)},
+		// A word that does not fit is left out whole, with its hyphen
+		{strings.Repeat("abc ", 40), strings.TrimSuffix(strings.Repeat("abc-", 30), "-")},
+		{strings.Repeat("a", 119) + " b", strings.Repeat("a", 119)},
+		{strings.Repeat("a", 118) + " b", strings.Repeat("a", 118) + "-b"},
+		// A transliteration is not split to fit
+		{strings.Repeat("a", 119) + "ß", strings.Repeat("a", 119)},
+	}
+
+	for _, tt := range tests {
+		got := Slugify(tt.name)
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.name, got, tt.want)
+		}
+		if len(got) > MaxSlugLength {
+			t.Errorf("%q: got %d characters, want at most %d", tt.name, len(got), MaxSlugLength)
+		}
+	}
+}
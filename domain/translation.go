@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// ProductTranslation is a product's name and description in one locale
+// other than the default one, which the Product itself holds
+type ProductTranslation struct {
+	ProductID   int64
+	Locale      string
+	Name        string
+	Description string
+}
+
+// NormalizeLocale returns a locale tag such as "pt_br" or "PT-BR" in the
+// canonical form "pt-BR": a 2 or 3 letter language with an optional 2
+// letter or 3 digit region
+func NormalizeLocale(tag string) (string, error) {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	if len(parts) > 2 || !isLetters(parts[0], 2, 3) {
+		return "", ErrInvalidLocale
+	}
+	locale := strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		switch {
+		case isLetters(parts[1], 2, 2):
+			locale += "-" + strings.ToUpper(parts[1])
+		case isDigits(parts[1], 3):
+			locale += "-" + parts[1]
+		default:
+			return "", ErrInvalidLocale
+		}
+	}
+	return locale, nil
+}
+
+// BaseLocale returns the language of a locale, "pt" for "pt-BR"
+func BaseLocale(locale string) string {
+	if i := strings.IndexByte(locale, '-'); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// Localize replaces the product's name and description with the first
+// translation in locales that has them, each field on its own, keeping the
+// default content where no translation has it. It returns the locale the
+// name was taken from, or "" for the default content.
+func (p *Product) Localize(translations map[string]*ProductTranslation, locales []string) string {
+	nameLocale := ""
+	nameSet, descriptionSet := false, false
+	for _, locale := range locales {
+		t, ok := translations[locale]
+		if !ok {
+			continue
+		}
+		if !nameSet && t.Name != "" {
+			p.Name, nameLocale, nameSet = t.Name, locale, true
+		}
+		if !descriptionSet && t.Description != "" {
+			p.Description, descriptionSet = t.Description, true
+		}
+	}
+	return nameLocale
+}
+
+func isLetters(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type WishlistKind string
+
+const (
+	WishlistKindWishlist      WishlistKind = "wishlist"
+	WishlistKindSavedForLater WishlistKind = "saved"
+)
+
+// SavedForLaterName is the name of each user's saved-for-later list
+const SavedForLaterName = "Saved for later"
+
+// Wishlist is a named list of products a user keeps outside the cart. A
+// public wishlist can be read by anyone holding its ShareToken. Each user
+// also has one saved-for-later list receiving items moved out of the cart.
+type Wishlist struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Kind       WishlistKind
+	IsPublic   bool
+	ShareToken string
+	Items      []WishlistItem
+	CreatedAt  time.Time
+}
+
+// WishlistItem remembers the product's price when it was added, in the
+// currency it was shown in, to detect price drops
+type WishlistItem struct {
+	ID             int64
+	WishlistID     int64
+	ProductID      int64
+	Quantity       int
+	PriceWhenAdded Money
+	AddedAt        time.Time
+}
+
+// Normalize trims the wishlist's name
+func (w *Wishlist) Normalize() {
+	w.Name = strings.TrimSpace(w.Name)
+}
+
+// Validate checks the wishlist's name
+func (w *Wishlist) Validate() error {
+	if w.Name == "" || len(w.Name) > 100 {
+		return errors.New("wishlist name must be 1 to 100 characters")
+	}
+	return nil
+}
+
+// PriceDrop returns how much cheaper current is than the price when the item
+// was added, or zero if it is not. Prices in another currency never drop.
+func (i WishlistItem) PriceDrop(current Money) Money {
+	zero := NewMoney(0, i.PriceWhenAdded.Currency)
+	if current.Currency != i.PriceWhenAdded.Currency || current.Cmp(i.PriceWhenAdded) >= 0 {
+		return zero
+	}
+	return i.PriceWhenAdded.Sub(current)
+}
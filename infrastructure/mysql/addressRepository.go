@@ -0,0 +1,242 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+const addressColumns = "ID, UserID, Name, Line1, Line2, City, Region, PostalCode, Country, Phone, IsDefaultBilling, " +
+	"IsDefaultShipping, CreatedAt"
+
+// AddressRepository defines operations for the users' address books
+type AddressRepository interface {
+	CreateAddress(address *domain.Address) (*domain.Address, error)
+	GetAddressByID(userID, id int64) (*domain.Address, error)
+	GetAddressesByUserID(userID int64) ([]*domain.Address, error)
+	GetDefaultAddress(userID int64, addressType domain.AddressType) (*domain.Address, error)
+	UpdateAddress(address *domain.Address) (*domain.Address, error)
+	DeleteAddress(userID, id int64) error
+	SetDefaultAddress(userID, id int64, addressType domain.AddressType) (*domain.Address, error)
+}
+
+// addressRepo is the concrete implementation
+type addressRepo struct {
+	db Repository
+}
+
+// NewAddressRepository creates a new AddressRepository
+func NewAddressRepository(db Repository) AddressRepository {
+	return &addressRepo{db: db}
+}
+
+// CreateAddress adds an address to a user's address book. The user's first
+// address becomes their default billing and shipping address.
+func (r *addressRepo) CreateAddress(address *domain.Address) (*domain.Address, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM Address WHERE UserID = ? FOR UPDATE", address.UserID).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		address.IsDefaultBilling = true
+		address.IsDefaultShipping = true
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO Address (UserID, Name, Line1, Line2, City, Region, PostalCode, Country, Phone)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		address.UserID, address.Name, address.Line1, address.Line2, address.City, address.Region,
+		address.PostalCode, address.Country, address.Phone,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if address.IsDefaultBilling {
+		if err := setDefaultAddress(tx, address.UserID, id, domain.AddressTypeBilling); err != nil {
+			return nil, err
+		}
+	}
+	if address.IsDefaultShipping {
+		if err := setDefaultAddress(tx, address.UserID, id, domain.AddressTypeShipping); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetAddressByID(address.UserID, id)
+}
+
+// GetAddressByID retrieves one of a user's addresses
+func (r *addressRepo) GetAddressByID(userID, id int64) (*domain.Address, error) {
+	return loadAddress(r.db, userID, id)
+}
+
+// GetAddressesByUserID retrieves a user's address book, defaults first
+func (r *addressRepo) GetAddressesByUserID(userID int64) ([]*domain.Address, error) {
+	rows, err := r.db.Query(
+		"SELECT "+addressColumns+" FROM Address WHERE UserID = ? ORDER BY IsDefaultShipping DESC, IsDefaultBilling DESC, ID",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make([]*domain.Address, 0)
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// GetDefaultAddress retrieves a user's default billing or shipping address
+func (r *addressRepo) GetDefaultAddress(userID int64, addressType domain.AddressType) (*domain.Address, error) {
+	column, err := defaultColumn(addressType)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := scanAddress(r.db.QueryRow("SELECT "+addressColumns+" FROM Address WHERE UserID = ? AND "+column+" = true", userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	return address, err
+}
+
+// UpdateAddress replaces the fields of an address. Default flags are only
+// changed through SetDefaultAddress.
+func (r *addressRepo) UpdateAddress(address *domain.Address) (*domain.Address, error) {
+	result, err := r.db.Exec(
+		`UPDATE Address SET Name = ?, Line1 = ?, Line2 = ?, City = ?, Region = ?, PostalCode = ?, Country = ?, Phone = ?
+		 WHERE ID = ? AND UserID = ?`,
+		address.Name, address.Line1, address.Line2, address.City, address.Region, address.PostalCode,
+		address.Country, address.Phone, address.ID, address.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// Nothing changed or the address does not belong to the user
+		if _, err := r.GetAddressByID(address.UserID, address.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.GetAddressByID(address.UserID, address.ID)
+}
+
+// DeleteAddress removes an address from a user's address book
+func (r *addressRepo) DeleteAddress(userID, id int64) error {
+	result, err := r.db.Exec("DELETE FROM Address WHERE ID = ? AND UserID = ?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+// SetDefaultAddress makes an address the user's default of the given type
+func (r *addressRepo) SetDefaultAddress(userID, id int64, addressType domain.AddressType) (*domain.Address, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := loadAddress(tx, userID, id); err != nil {
+		return nil, err
+	}
+	if err := setDefaultAddress(tx, userID, id, addressType); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetAddressByID(userID, id)
+}
+
+// setDefaultAddress moves the user's default flag of a type to id within tx
+func setDefaultAddress(tx *sql.Tx, userID, id int64, addressType domain.AddressType) error {
+	column, err := defaultColumn(addressType)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE Address SET "+column+" = (ID = ?) WHERE UserID = ?", id, userID)
+	return err
+}
+
+// defaultColumn returns the column flagging the default address of a type
+func defaultColumn(addressType domain.AddressType) (string, error) {
+	switch addressType {
+	case domain.AddressTypeBilling:
+		return "IsDefaultBilling", nil
+	case domain.AddressTypeShipping:
+		return "IsDefaultShipping", nil
+	}
+	return "", fmt.Errorf("invalid address type %q", addressType)
+}
+
+// loadAddress reads one of a user's addresses through q
+func loadAddress(q queryer, userID, id int64) (*domain.Address, error) {
+	address, err := scanAddress(q.QueryRow("SELECT "+addressColumns+" FROM Address WHERE ID = ? AND UserID = ?", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	return address, err
+}
+
+// scanAddress reads an address row selected with addressColumns
+func scanAddress(row rowScanner) (*domain.Address, error) {
+	address := &domain.Address{}
+	err := row.Scan(
+		&address.ID, &address.UserID, &address.Name, &address.Line1, &address.Line2, &address.City, &address.Region,
+		&address.PostalCode, &address.Country, &address.Phone, &address.IsDefaultBilling, &address.IsDefaultShipping,
+		&address.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
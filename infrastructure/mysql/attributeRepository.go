@@ -0,0 +1,164 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrAttributeNotFound = errors.New("attribute not found")
+	ErrAttributeExists   = errors.New("an attribute with this code already exists")
+)
+
+const attributeColumns = "ID, Code, Name, Type, Filterable"
+
+// AttributeRepository defines operations for product attributes
+type AttributeRepository interface {
+	CreateAttribute(attribute *domain.Attribute) (*domain.Attribute, error)
+	GetAttributes() ([]*domain.Attribute, error)
+	DeleteAttribute(code string) error
+	SetProductAttributes(productID int64, values map[string]string) error
+	GetProductAttributes(productIDs []int64) (map[int64]map[string]string, error)
+}
+
+// attributeRepo is the concrete implementation
+type attributeRepo struct {
+	db Repository
+}
+
+// NewAttributeRepository creates a new AttributeRepository
+func NewAttributeRepository(db Repository) AttributeRepository {
+	return &attributeRepo{db: db}
+}
+
+// CreateAttribute defines a new attribute
+func (r *attributeRepo) CreateAttribute(attribute *domain.Attribute) (*domain.Attribute, error) {
+	result, err := r.db.Exec(
+		"INSERT INTO Attribute (Code, Name, Type, Filterable) VALUES (?, ?, ?, ?)",
+		attribute.Code, attribute.Name, string(attribute.Type), attribute.Filterable,
+	)
+	if isDuplicateKey(err) {
+		return nil, fmt.Errorf("%w: %s", ErrAttributeExists, attribute.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	created := *attribute
+	created.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetAttributes retrieves every attribute definition by name
+func (r *attributeRepo) GetAttributes() ([]*domain.Attribute, error) {
+	rows, err := r.db.Query("SELECT " + attributeColumns + " FROM Attribute ORDER BY Name, Code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attributes := make([]*domain.Attribute, 0)
+	for rows.Next() {
+		a := &domain.Attribute{}
+		var attributeType string
+		if err := rows.Scan(&a.ID, &a.Code, &a.Name, &attributeType, &a.Filterable); err != nil {
+			return nil, err
+		}
+		a.Type = domain.AttributeType(attributeType)
+		attributes = append(attributes, a)
+	}
+	return attributes, rows.Err()
+}
+
+// DeleteAttribute removes an attribute and every product's value for it
+func (r *attributeRepo) DeleteAttribute(code string) error {
+	result, err := r.db.Exec("DELETE FROM Attribute WHERE Code = ?", code)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAttributeNotFound
+	}
+	return nil
+}
+
+// SetProductAttributes replaces a product's attribute values with values,
+// keyed by attribute code. The values must already be normalized.
+func (r *attributeRepo) SetProductAttributes(productID int64, values map[string]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow("SELECT ID FROM Product WHERE ID = ? FOR UPDATE", productID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM ProductAttribute WHERE ProductID = ?", productID); err != nil {
+		return err
+	}
+	for code, value := range values {
+		result, err := tx.Exec(
+			"INSERT INTO ProductAttribute (ProductID, AttributeID, Value) SELECT ?, ID, ? FROM Attribute WHERE Code = ?",
+			productID, value, code,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			if err == nil {
+				err = fmt.Errorf("%w: %s", ErrAttributeNotFound, code)
+			}
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetProductAttributes retrieves the attribute values of each product,
+// keyed by product ID and then attribute code
+func (r *attributeRepo) GetProductAttributes(productIDs []int64) (map[int64]map[string]string, error) {
+	values := make(map[int64]map[string]string, len(productIDs))
+	if len(productIDs) == 0 {
+		return values, nil
+	}
+
+	in, args := inClause(productIDs)
+	rows, err := r.db.Query(
+		"SELECT pa.ProductID, a.Code, pa.Value FROM ProductAttribute pa JOIN Attribute a ON a.ID = pa.AttributeID WHERE pa.ProductID IN "+in,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int64
+		var code, value string
+		if err := rows.Scan(&productID, &code, &value); err != nil {
+			return nil, err
+		}
+		if values[productID] == nil {
+			values[productID] = make(map[string]string)
+		}
+		values[productID][code] = value
+	}
+	return values, rows.Err()
+}
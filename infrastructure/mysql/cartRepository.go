@@ -4,21 +4,54 @@ import (
     "database/sql"
     "ecommerce-go/domain"
     "errors"
+    "fmt"
+    "time"
+)
+
+var (
+    ErrActiveCartExists = errors.New("user already has an active cart")
+    ErrCartItemNotFound = errors.New("item not found in cart")
 )
 
 // CartRepository defines CRUD operations for Cart
 type CartRepository interface {
-    CreateCart(userID int64) (*domain.Cart, error)
+    CreateCart(userID int64, currency string) (*domain.Cart, error)
+    CreateGuestCart(guestID string, currency string) (*domain.Cart, error)
+    GetCartByID(cartID int64) (*domain.Cart, error)
     GetCartByUserID(userID int64) (*domain.Cart, error)
-    AddItemToCart(cartID int64, productID int64, quantity int, price float64) (*domain.CartItem, error)
-    RemoveItemFromCart(cartID int64, productID int64) error
-    UpdateCartItem(cartID int64, productID int64, quantity int) (*domain.CartItem, error)
+    GetCartByGuestID(guestID string) (*domain.Cart, error)
+    GetOrCreateCart(userID int64, currency string) (*domain.Cart, bool, error)
+    MergeGuestCart(guestID string, userID int64, strategy domain.CartMergeStrategy) (*domain.Cart, error)
+    MergeCarts(sourceID, targetID int64, strategy domain.CartMergeStrategy) (*domain.Cart, error)
+    GetDuplicateActiveCarts() (map[int64][]int64, error)
+    DeactivateCart(cartID int64) error
+    AddItemToCart(cartID int64, productID int64, quantity int, price domain.Money, version int64) (*domain.CartItem, error)
+    RemoveItemFromCart(cartID int64, productID int64, version int64) error
+    UpdateCartItem(cartID int64, productID int64, quantity int, version int64) (*domain.CartItem, error)
     GetCartItems(cartID int64) ([]*domain.CartItem, error)
-    ClearCart(cartID int64) error
+    ClearCart(cartID int64, version int64) error
     DeleteCart(cartID int64) error
     UpdateCartTotal(cartID int64) error
+    RepriceCart(cartID int64, currency string, prices map[int64]domain.Money, version int64) error
+    SetCartCoupon(cartID int64, code string, version int64) error
+    ExpireGuestCarts(idle time.Duration) (int64, error)
+    MarkAbandonedCarts(idle time.Duration) ([]*domain.Cart, error)
 }
 
+// cartColumns is the column list read by scanCart; guest carts have no user
+// and user carts no guest ID
+const cartColumns = "ID, COALESCE(UserID, 0), COALESCE(GuestID, ''), Currency, TotalAmount, CouponCode, IsActive, " +
+    "UpdatedAt, AbandonedAt, Version"
+
+// cartTouched records a change to a cart's contents, which also takes it out
+// of the abandoned carts and moves it to a new version
+const cartTouched = "UpdatedAt = CURRENT_TIMESTAMP, AbandonedAt = NULL, Version = Version + 1"
+
+// cartItemColumns selects a cart item with its cart's currency and its
+// product's tax class and weight
+const cartItemColumns = "ci.ID, ci.CartID, ci.ProductID, ci.Quantity, ci.Price, c.Currency, COALESCE(p.TaxClass, ''), " +
+    "COALESCE(p.WeightGrams, 0), ci.UpdatedAt"
+
 // cartRepo is the concrete implementation
 type cartRepo struct {
     db Repository
@@ -29,10 +62,14 @@ func NewCartRepository(db Repository) CartRepository {
     return &cartRepo{db: db}
 }
 
-// CreateCart creates a new cart for a user
-func (r *cartRepo) CreateCart(userID int64) (*domain.Cart, error) {
-    query := "INSERT INTO Cart (UserID, TotalAmount, IsActive) VALUES (?, 0.00, true)"
-    result, err := r.db.Exec(query, userID)
+// CreateCart creates a new cart for a user, priced in currency. A user has
+// at most one active cart.
+func (r *cartRepo) CreateCart(userID int64, currency string) (*domain.Cart, error) {
+    query := "INSERT INTO Cart (UserID, Currency, TotalAmount, IsActive) VALUES (?, ?, 0.00, true)"
+    result, err := r.db.Exec(query, userID, currency)
+    if isDuplicateKey(err) {
+        return nil, ErrActiveCartExists
+    }
     if err != nil {
         return nil, err
     }
@@ -45,82 +82,174 @@ func (r *cartRepo) CreateCart(userID int64) (*domain.Cart, error) {
     return &domain.Cart{
         ID:          cartID,
         UserID:      userID,
-        TotalAmount: 0,
+        Currency:    currency,
+        TotalAmount: domain.NewMoney(0, currency),
         Items:       make([]domain.CartItem, 0),
         IsActive:    true,
+        UpdatedAt:   time.Now(),
+        Version:     1,
     }, nil
 }
 
-// GetCartByUserID retrieves a cart by user ID
-func (r *cartRepo) GetCartByUserID(userID int64) (*domain.Cart, error) {
-    var cart domain.Cart
+// CreateGuestCart creates a new cart for a guest, priced in currency
+func (r *cartRepo) CreateGuestCart(guestID string, currency string) (*domain.Cart, error) {
+    query := "INSERT INTO Cart (GuestID, Currency, TotalAmount, IsActive) VALUES (?, ?, 0.00, true)"
+    result, err := r.db.Exec(query, guestID, currency)
+    if err != nil {
+        return nil, err
+    }
 
-    query := "SELECT ID, UserID, TotalAmount, IsActive FROM Cart WHERE UserID = ? AND IsActive = true"
-    err := r.db.QueryRow(query, userID).Scan(&cart.ID, &cart.UserID, &cart.TotalAmount, &cart.IsActive)
+    cartID, err := result.LastInsertId()
+    if err != nil {
+        return nil, err
+    }
 
-    if err == sql.ErrNoRows {
-        return nil, errors.New("cart not found")
+    return &domain.Cart{
+        ID:          cartID,
+        GuestID:     guestID,
+        Currency:    currency,
+        TotalAmount: domain.NewMoney(0, currency),
+        Items:       make([]domain.CartItem, 0),
+        IsActive:    true,
+        UpdatedAt:   time.Now(),
+        Version:     1,
+    }, nil
+}
+
+// GetOrCreateCart retrieves the user's active cart, creating an empty one in
+// currency if there is none, and reports whether it was created
+func (r *cartRepo) GetOrCreateCart(userID int64, currency string) (*domain.Cart, bool, error) {
+    cart, err := r.GetCartByUserID(userID)
+    if !errors.Is(err, ErrCartNotFound) {
+        return cart, false, err
+    }
+
+    cart, err = r.CreateCart(userID, currency)
+    if errors.Is(err, ErrActiveCartExists) {
+        // Created concurrently by another request
+        cart, err = r.GetCartByUserID(userID)
+        return cart, false, err
     }
     if err != nil {
-        return nil, err
+        return nil, false, err
     }
+    return cart, true, nil
+}
 
-    // Get cart items
-    items, err := r.GetCartItems(cart.ID)
+// GetCartByID retrieves an active cart by its ID
+func (r *cartRepo) GetCartByID(cartID int64) (*domain.Cart, error) {
+    return r.getCart("ID = ?", cartID)
+}
+
+// GetCartByUserID retrieves a cart by user ID
+func (r *cartRepo) GetCartByUserID(userID int64) (*domain.Cart, error) {
+    return r.getCart("UserID = ?", userID)
+}
+
+// GetCartByGuestID retrieves a guest's cart
+func (r *cartRepo) GetCartByGuestID(guestID string) (*domain.Cart, error) {
+    return r.getCart("GuestID = ?", guestID)
+}
+
+// AddItemToCart adds a product to cart, or adds quantity to the product's
+// line at its original price if it is already in the cart
+func (r *cartRepo) AddItemToCart(cartID int64, productID int64, quantity int, price domain.Money, version int64) (*domain.CartItem, error) {
+    err := r.changeCart(cartID, version, func(tx *sql.Tx) error {
+        result, err := tx.Exec("UPDATE CartItem SET Quantity = Quantity + ? WHERE CartID = ? AND ProductID = ?", quantity, cartID, productID)
+        if err != nil {
+            return err
+        }
+        rowsAffected, err := result.RowsAffected()
+        if err != nil || rowsAffected > 0 {
+            return err
+        }
+
+        _, err = tx.Exec("INSERT INTO CartItem (CartID, ProductID, Quantity, Price) VALUES (?, ?, ?, ?)", cartID, productID, quantity, price)
+        return err
+    })
     if err != nil {
         return nil, err
     }
 
-    cart.Items = *convertCartItemsToSlice(items)
-    return &cart, nil
+    return r.getCartItem(cartID, productID)
 }
 
-// AddItemToCart adds a product to cart
-func (r *cartRepo) AddItemToCart(cartID int64, productID int64, quantity int, price float64) (*domain.CartItem, error) {
-    // Check if item already exists
-    query := "SELECT ID, Quantity FROM CartItem WHERE CartID = ? AND ProductID = ?"
-    var itemID int64
-    var existingQty int
-
-    err := r.db.QueryRow(query, cartID, productID).Scan(&itemID, &existingQty)
+// RemoveItemFromCart removes a product from cart
+func (r *cartRepo) RemoveItemFromCart(cartID int64, productID int64, version int64) error {
+    return r.changeCart(cartID, version, func(tx *sql.Tx) error {
+        result, err := tx.Exec("DELETE FROM CartItem WHERE CartID = ? AND ProductID = ?", cartID, productID)
+        if err != nil {
+            return err
+        }
+        return checkCartItemFound(result)
+    })
+}
 
-    if err == nil {
-        // Item exists, update quantity
-        return r.UpdateCartItem(cartID, productID, existingQty+quantity)
+// UpdateCartItem updates the quantity of a cart item
+func (r *cartRepo) UpdateCartItem(cartID int64, productID int64, quantity int, version int64) (*domain.CartItem, error) {
+    if quantity <= 0 {
+        return nil, errors.New("quantity must be greater than 0")
     }
 
-    if err != sql.ErrNoRows {
+    err := r.changeCart(cartID, version, func(tx *sql.Tx) error {
+        result, err := tx.Exec("UPDATE CartItem SET Quantity = ? WHERE CartID = ? AND ProductID = ?", quantity, cartID, productID)
+        if err != nil {
+            return err
+        }
+        return checkCartItemFound(result)
+    })
+    if err != nil {
         return nil, err
     }
 
-    // Item doesn't exist, insert new
-    insertQuery := "INSERT INTO CartItem (CartID, ProductID, Quantity, Price) VALUES (?, ?, ?, ?)"
-    result, err := r.db.Exec(insertQuery, cartID, productID, quantity, price)
+    return r.getCartItem(cartID, productID)
+}
+
+// GetCartItems retrieves all items in a cart
+func (r *cartRepo) GetCartItems(cartID int64) ([]*domain.CartItem, error) {
+    query := "SELECT " + cartItemColumns + " FROM CartItem ci JOIN Cart c ON c.ID = ci.CartID LEFT JOIN Product p ON p.ID = ci.ProductID WHERE ci.CartID = ?"
+    rows, err := r.db.Query(query, cartID)
     if err != nil {
         return nil, err
     }
+    defer rows.Close()
 
-    itemID, err = result.LastInsertId()
-    if err != nil {
+    var items []*domain.CartItem
+    for rows.Next() {
+        item, err := scanCartItem(rows)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+    }
+
+    if err := rows.Err(); err != nil {
         return nil, err
     }
 
-    // Update cart total
-    r.UpdateCartTotal(cartID)
+    return items, nil
+}
 
-    return &domain.CartItem{
-        ID:        itemID,
-        CartID:    cartID,
-        ProductID: productID,
-        Quantity:  quantity,
-        Price:     price,
-    }, nil
+// ClearCart removes all items from a cart
+func (r *cartRepo) ClearCart(cartID int64, version int64) error {
+    return r.changeCart(cartID, version, func(tx *sql.Tx) error {
+        _, err := tx.Exec("DELETE FROM CartItem WHERE CartID = ?", cartID)
+        return err
+    })
 }
 
-// RemoveItemFromCart removes a product from cart
-func (r *cartRepo) RemoveItemFromCart(cartID int64, productID int64) error {
-    query := "DELETE FROM CartItem WHERE CartID = ? AND ProductID = ?"
-    result, err := r.db.Exec(query, cartID, productID)
+// DeleteCart deletes a cart
+func (r *cartRepo) DeleteCart(cartID int64) error {
+    // First delete all cart items
+    query := "DELETE FROM CartItem WHERE CartID = ?"
+    _, err := r.db.Exec(query, cartID)
+    if err != nil {
+        return err
+    }
+
+    // Then delete the cart
+    deleteQuery := "DELETE FROM Cart WHERE ID = ?"
+    result, err := r.db.Exec(deleteQuery, cartID)
     if err != nil {
         return err
     }
@@ -131,133 +260,440 @@ func (r *cartRepo) RemoveItemFromCart(cartID int64, productID int64) error {
     }
 
     if rowsAffected == 0 {
-        return errors.New("item not found in cart")
+        return errors.New("cart not found")
     }
 
-    // Update cart total
-    r.UpdateCartTotal(cartID)
-
     return nil
 }
 
-// UpdateCartItem updates the quantity of a cart item
-func (r *cartRepo) UpdateCartItem(cartID int64, productID int64, quantity int) (*domain.CartItem, error) {
-    if quantity <= 0 {
-        return nil, errors.New("quantity must be greater than 0")
+// UpdateCartTotal updates the total amount of a cart
+func (r *cartRepo) UpdateCartTotal(cartID int64) error {
+    // Summed in SQL so the DECIMAL total keeps the cart currency's precision
+    query := "UPDATE Cart SET TotalAmount = (SELECT COALESCE(SUM(Quantity * Price), 0) FROM CartItem WHERE CartID = ?), " +
+        cartTouched + " WHERE ID = ?"
+    _, err := r.db.Exec(query, cartID, cartID)
+    return err
+}
+
+// RepriceCart switches a cart to another currency, replacing each item's
+// price with the one given for its product. Unless version is zero the cart
+// must still be at that version.
+func (r *cartRepo) RepriceCart(cartID int64, currency string, prices map[int64]domain.Money, version int64) error {
+    for productID, price := range prices {
+        if price.Currency != currency {
+            return fmt.Errorf("price of product %d is in %s, not %s", productID, price.Currency, currency)
+        }
     }
 
-    query := "UPDATE CartItem SET Quantity = ? WHERE CartID = ? AND ProductID = ?"
-    result, err := r.db.Exec(query, quantity, cartID, productID)
+    return r.changeCart(cartID, version, func(tx *sql.Tx) error {
+        for productID, price := range prices {
+            _, err := tx.Exec("UPDATE CartItem SET Price = ? WHERE CartID = ? AND ProductID = ?", price, cartID, productID)
+            if err != nil {
+                return err
+            }
+        }
+
+        _, err := tx.Exec("UPDATE Cart SET Currency = ? WHERE ID = ?", currency, cartID)
+        return err
+    })
+}
+
+// MergeGuestCart moves a guest's cart into the user's active cart. Products
+// in both carts are combined by strategy, the guest's coupon is kept if the
+// user's cart has none, and the guest cart is deleted. Without an active
+// user cart the guest cart simply becomes the user's. Both carts must be in
+// the same currency.
+func (r *cartRepo) MergeGuestCart(guestID string, userID int64, strategy domain.CartMergeStrategy) (*domain.Cart, error) {
+    tx, err := r.db.Begin()
     if err != nil {
         return nil, err
     }
+    defer tx.Rollback()
 
-    rowsAffected, err := result.RowsAffected()
+    guest, err := lockCart(tx, "GuestID = ?", guestID)
     if err != nil {
         return nil, err
     }
 
-    if rowsAffected == 0 {
-        return nil, errors.New("item not found in cart")
+    user, err := lockCart(tx, "UserID = ?", userID)
+    if errors.Is(err, ErrCartNotFound) {
+        // Nothing to merge with: hand the guest cart over
+        _, err = tx.Exec("UPDATE Cart SET UserID = ?, GuestID = NULL, "+cartTouched+" WHERE ID = ?", userID, guest.ID)
+        if err != nil {
+            return nil, err
+        }
+        if err := tx.Commit(); err != nil {
+            return nil, err
+        }
+        return r.GetCartByID(guest.ID)
+    }
+    if err != nil {
+        return nil, err
     }
 
-    // Get updated item
-    var item domain.CartItem
-    getQuery := "SELECT ID, CartID, ProductID, Quantity, Price FROM CartItem WHERE CartID = ? AND ProductID = ?"
-    err = r.db.QueryRow(getQuery, cartID, productID).Scan(&item.ID, &item.CartID, &item.ProductID, &item.Quantity, &item.Price)
+    if err := mergeCarts(tx, guest, user, strategy); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    return r.GetCartByID(user.ID)
+}
+
+// MergeCarts moves the active cart sourceID into the active cart targetID
+// the way MergeGuestCart does, deleting the source cart
+func (r *cartRepo) MergeCarts(sourceID, targetID int64, strategy domain.CartMergeStrategy) (*domain.Cart, error) {
+    tx, err := r.db.Begin()
     if err != nil {
         return nil, err
     }
+    defer tx.Rollback()
 
-    // Update cart total
-    r.UpdateCartTotal(cartID)
+    source, err := lockCart(tx, "ID = ?", sourceID)
+    if err != nil {
+        return nil, err
+    }
+    target, err := lockCart(tx, "ID = ?", targetID)
+    if err != nil {
+        return nil, err
+    }
 
-    return &item, nil
+    if err := mergeCarts(tx, source, target, strategy); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    return r.GetCartByID(targetID)
 }
 
-// GetCartItems retrieves all items in a cart
-func (r *cartRepo) GetCartItems(cartID int64) ([]*domain.CartItem, error) {
-    query := "SELECT ID, CartID, ProductID, Quantity, Price FROM CartItem WHERE CartID = ?"
-    rows, err := r.db.Query(query, cartID)
+// GetDuplicateActiveCarts lists the users with more than one active cart,
+// mapping each to the IDs of those carts, most recently changed first
+func (r *cartRepo) GetDuplicateActiveCarts() (map[int64][]int64, error) {
+    rows, err := r.db.Query(
+        `SELECT c.UserID, c.ID FROM Cart c
+         JOIN (SELECT UserID FROM Cart WHERE IsActive = true AND UserID IS NOT NULL GROUP BY UserID HAVING COUNT(*) > 1) d
+           ON d.UserID = c.UserID
+         WHERE c.IsActive = true
+         ORDER BY c.UserID, c.UpdatedAt DESC, c.ID DESC`,
+    )
     if err != nil {
         return nil, err
     }
     defer rows.Close()
 
-    var items []*domain.CartItem
+    duplicates := make(map[int64][]int64)
     for rows.Next() {
-        var item domain.CartItem
-        err := rows.Scan(&item.ID, &item.CartID, &item.ProductID, &item.Quantity, &item.Price)
-        if err != nil {
+        var userID, cartID int64
+        if err := rows.Scan(&userID, &cartID); err != nil {
             return nil, err
         }
-        items = append(items, &item)
+        duplicates[userID] = append(duplicates[userID], cartID)
     }
 
-    if err := rows.Err(); err != nil {
+    return duplicates, rows.Err()
+}
+
+// DeactivateCart retires an active cart without deleting it
+func (r *cartRepo) DeactivateCart(cartID int64) error {
+    result, err := r.db.Exec("UPDATE Cart SET IsActive = false WHERE ID = ? AND IsActive = true", cartID)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+    if rowsAffected == 0 {
+        return ErrCartNotFound
+    }
+    return nil
+}
+
+// SetCartCoupon applies a coupon code to a cart; an empty code removes it
+func (r *cartRepo) SetCartCoupon(cartID int64, code string, version int64) error {
+    return r.changeCart(cartID, version, func(tx *sql.Tx) error {
+        _, err := tx.Exec("UPDATE Cart SET CouponCode = ? WHERE ID = ?", code, cartID)
+        return err
+    })
+}
+
+// ExpireGuestCarts deletes the guest carts whose contents have not changed
+// for idle, returning how many were removed
+func (r *cartRepo) ExpireGuestCarts(idle time.Duration) (int64, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    ids, err := lockCartIDs(tx, "GuestID IS NOT NULL AND UpdatedAt < NOW() - INTERVAL ? SECOND", int64(idle.Seconds()))
+    if err != nil || len(ids) == 0 {
+        return 0, err
+    }
+
+    in, args := inClause(ids)
+    if _, err := tx.Exec("DELETE FROM CartItem WHERE CartID IN "+in, args...); err != nil {
+        return 0, err
+    }
+    result, err := tx.Exec("DELETE FROM Cart WHERE ID IN "+in, args...)
+    if err != nil {
+        return 0, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
+
+// MarkAbandonedCarts flags the active user carts holding items whose
+// contents have not changed for idle and returns them with their items. Each
+// cart is returned once; it is flagged again only after it changes and goes
+// idle anew.
+func (r *cartRepo) MarkAbandonedCarts(idle time.Duration) ([]*domain.Cart, error) {
+    tx, err := r.db.Begin()
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    ids, err := lockCartIDs(tx,
+        "UserID IS NOT NULL AND IsActive = true AND AbandonedAt IS NULL AND UpdatedAt < NOW() - INTERVAL ? SECOND "+
+            "AND EXISTS (SELECT 1 FROM CartItem WHERE CartItem.CartID = Cart.ID)",
+        int64(idle.Seconds()),
+    )
+    if err != nil || len(ids) == 0 {
         return nil, err
     }
 
-    return items, nil
+    // UpdatedAt is kept as is: flagging a cart does not change its contents
+    in, args := inClause(ids)
+    if _, err := tx.Exec("UPDATE Cart SET AbandonedAt = NOW(), UpdatedAt = UpdatedAt WHERE ID IN "+in, args...); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+
+    carts := make([]*domain.Cart, 0, len(ids))
+    for _, id := range ids {
+        cart, err := r.GetCartByID(id)
+        if errors.Is(err, ErrCartNotFound) {
+            // Checked out since it was flagged
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        carts = append(carts, cart)
+    }
+    return carts, nil
 }
 
-// ClearCart removes all items from a cart
-func (r *cartRepo) ClearCart(cartID int64) error {
-    query := "DELETE FROM CartItem WHERE CartID = ?"
-    _, err := r.db.Exec(query, cartID)
+// Helper function to lock the carts matching a condition within tx
+func lockCartIDs(tx *sql.Tx, condition string, args ...interface{}) ([]int64, error) {
+    rows, err := tx.Query("SELECT ID FROM Cart WHERE "+condition+" FOR UPDATE", args...)
     if err != nil {
-        return err
+        return nil, err
     }
+    defer rows.Close()
 
-    // Reset cart total
-    updateQuery := "UPDATE Cart SET TotalAmount = 0.00 WHERE ID = ?"
-    _, err = r.db.Exec(updateQuery, cartID)
-    return err
+    var ids []int64
+    for rows.Next() {
+        var id int64
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+    return ids, rows.Err()
 }
 
-// DeleteCart deletes a cart
-func (r *cartRepo) DeleteCart(cartID int64) error {
-    // First delete all cart items
-    query := "DELETE FROM CartItem WHERE CartID = ?"
-    _, err := r.db.Exec(query, cartID)
+// Helper function to change a cart within a transaction. Unless version is
+// zero the cart must still be at that version. Afterwards the cart's total
+// is recalculated and its version incremented.
+func (r *cartRepo) changeCart(cartID int64, version int64, change func(tx *sql.Tx) error) error {
+    tx, err := r.db.Begin()
     if err != nil {
         return err
     }
+    defer tx.Rollback()
 
-    // Then delete the cart
-    deleteQuery := "DELETE FROM Cart WHERE ID = ?"
-    result, err := r.db.Exec(deleteQuery, cartID)
+    var current int64
+    err = tx.QueryRow("SELECT Version FROM Cart WHERE ID = ? AND IsActive = true FOR UPDATE", cartID).Scan(&current)
+    if errors.Is(err, sql.ErrNoRows) {
+        return ErrCartNotFound
+    }
     if err != nil {
         return err
     }
+    if version != 0 && version != current {
+        return ErrVersionConflict
+    }
 
-    rowsAffected, err := result.RowsAffected()
+    if err := change(tx); err != nil {
+        return err
+    }
+
+    _, err = tx.Exec("UPDATE Cart SET TotalAmount = (SELECT COALESCE(SUM(Quantity * Price), 0) FROM CartItem WHERE CartID = ?), "+cartTouched+" WHERE ID = ?", cartID, cartID)
     if err != nil {
         return err
     }
 
+    return tx.Commit()
+}
+
+// Helper function to read one item of a cart
+func (r *cartRepo) getCartItem(cartID int64, productID int64) (*domain.CartItem, error) {
+    query := "SELECT " + cartItemColumns + " FROM CartItem ci JOIN Cart c ON c.ID = ci.CartID LEFT JOIN Product p ON p.ID = ci.ProductID WHERE ci.CartID = ? AND ci.ProductID = ?"
+    return scanCartItem(r.db.QueryRow(query, cartID, productID))
+}
+
+// Helper function to check that a change to a cart item matched a row
+func checkCartItemFound(result sql.Result) error {
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
     if rowsAffected == 0 {
-        return errors.New("cart not found")
+        return ErrCartItemNotFound
     }
-
     return nil
 }
 
-// UpdateCartTotal updates the total amount of a cart
-func (r *cartRepo) UpdateCartTotal(cartID int64) error {
-    query := "SELECT COALESCE(SUM(Quantity * Price), 0) FROM CartItem WHERE CartID = ?"
-    var total float64
+// Helper function to read an active cart matching a condition, with its items
+func (r *cartRepo) getCart(condition string, args ...interface{}) (*domain.Cart, error) {
+    cart, err := scanCart(r.db.QueryRow("SELECT "+cartColumns+" FROM Cart WHERE "+condition+" AND IsActive = true", args...))
+    if err == sql.ErrNoRows {
+        return nil, ErrCartNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    // Get cart items
+    items, err := r.GetCartItems(cart.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    cart.Items = *convertCartItemsToSlice(items)
+    return cart, nil
+}
+
+// lockedCart is the part of a cart read by lockCart
+type lockedCart struct {
+    ID       int64
+    Currency string
+    Coupon   string
+}
 
-    err := r.db.QueryRow(query, cartID).Scan(&total)
+// Helper function to lock the active cart matching a condition within tx
+func lockCart(tx *sql.Tx, condition string, args ...interface{}) (lockedCart, error) {
+    var cart lockedCart
+    err := tx.QueryRow(
+        "SELECT ID, Currency, CouponCode FROM Cart WHERE "+condition+" AND IsActive = true FOR UPDATE", args...,
+    ).Scan(&cart.ID, &cart.Currency, &cart.Coupon)
+    if errors.Is(err, sql.ErrNoRows) {
+        return cart, ErrCartNotFound
+    }
+    return cart, err
+}
+
+// Helper function to merge the items of source into target within tx.
+// Products in both carts are combined by strategy, the source's coupon is
+// kept if target has none, and source is deleted.
+func mergeCarts(tx *sql.Tx, source, target lockedCart, strategy domain.CartMergeStrategy) error {
+    if source.Currency != target.Currency {
+        return fmt.Errorf("%w: cart %d in %s, cart %d in %s", domain.ErrCurrencyMismatch, source.ID, source.Currency, target.ID, target.Currency)
+    }
+
+    targetItems, err := lockCartItems(tx, target.ID, target.Currency)
     if err != nil {
         return err
     }
+    sourceItems, err := lockCartItems(tx, source.ID, source.Currency)
+    if err != nil {
+        return err
+    }
+
+    existing := make(map[int64]domain.CartItem, len(targetItems))
+    for _, item := range targetItems {
+        existing[item.ProductID] = item
+    }
 
-    updateQuery := "UPDATE Cart SET TotalAmount = ? WHERE ID = ?"
-    _, err = r.db.Exec(updateQuery, total, cartID)
+    for _, sourceItem := range sourceItems {
+        targetItem, ok := existing[sourceItem.ProductID]
+        if !ok {
+            _, err = tx.Exec("UPDATE CartItem SET CartID = ? WHERE ID = ?", target.ID, sourceItem.ID)
+            if err != nil {
+                return err
+            }
+            continue
+        }
+
+        merged := strategy.Merge(targetItem, sourceItem)
+        _, err = tx.Exec(
+            "UPDATE CartItem SET Quantity = ?, Price = ?, UpdatedAt = ? WHERE ID = ?",
+            merged.Quantity, merged.Price, merged.UpdatedAt, targetItem.ID,
+        )
+        if err != nil {
+            return err
+        }
+    }
+
+    if target.Coupon == "" && source.Coupon != "" {
+        _, err = tx.Exec("UPDATE Cart SET CouponCode = ? WHERE ID = ?", source.Coupon, target.ID)
+        if err != nil {
+            return err
+        }
+    }
+
+    _, err = tx.Exec("DELETE FROM CartItem WHERE CartID = ?", source.ID)
+    if err != nil {
+        return err
+    }
+    _, err = tx.Exec("DELETE FROM Cart WHERE ID = ?", source.ID)
+    if err != nil {
+        return err
+    }
+
+    _, err = tx.Exec("UPDATE Cart SET TotalAmount = (SELECT COALESCE(SUM(Quantity * Price), 0) FROM CartItem WHERE CartID = ?), "+cartTouched+" WHERE ID = ?", target.ID, target.ID)
     return err
 }
 
+// Helper function to lock the items of a cart within tx
+func lockCartItems(tx *sql.Tx, cartID int64, currency string) ([]domain.CartItem, error) {
+    rows, err := tx.Query("SELECT ID, ProductID, Quantity, Price, UpdatedAt FROM CartItem WHERE CartID = ? FOR UPDATE", cartID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var items []domain.CartItem
+    for rows.Next() {
+        item := domain.CartItem{CartID: cartID}
+        var price string
+        if err := rows.Scan(&item.ID, &item.ProductID, &item.Quantity, &price, &item.UpdatedAt); err != nil {
+            return nil, err
+        }
+        item.Price, err = domain.ParseMoney(price, currency)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+    }
+
+    return items, rows.Err()
+}
+
 // Helper function to convert cart items
 func convertCartItemsToSlice(items []*domain.CartItem) *[]domain.CartItem {
     result := make([]domain.CartItem, 0)
@@ -265,4 +701,44 @@ func convertCartItemsToSlice(items []*domain.CartItem) *[]domain.CartItem {
         result = append(result, *item)
     }
     return &result
-}
\ No newline at end of file
+}
+
+// Helper function to scan a cart item selected with cartItemColumns
+func scanCartItem(row rowScanner) (*domain.CartItem, error) {
+    var item domain.CartItem
+    var price, currency string
+    err := row.Scan(&item.ID, &item.CartID, &item.ProductID, &item.Quantity, &price, &currency, &item.TaxClass, &item.WeightGrams, &item.UpdatedAt)
+    if err != nil {
+        return nil, err
+    }
+
+    item.Price, err = domain.ParseMoney(price, currency)
+    if err != nil {
+        return nil, err
+    }
+    return &item, nil
+}
+
+// Helper function to scan a cart selected with cartColumns
+func scanCart(row rowScanner) (*domain.Cart, error) {
+    var cart domain.Cart
+    var total string
+    var abandonedAt sql.NullTime
+    err := row.Scan(
+        &cart.ID, &cart.UserID, &cart.GuestID, &cart.Currency, &total, &cart.CouponCode, &cart.IsActive,
+        &cart.UpdatedAt, &abandonedAt, &cart.Version,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    if abandonedAt.Valid {
+        cart.AbandonedAt = &abandonedAt.Time
+    }
+
+    cart.TotalAmount, err = domain.ParseMoney(total, cart.Currency)
+    if err != nil {
+        return nil, err
+    }
+    return &cart, nil
+}
@@ -0,0 +1,327 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCouponNotFound = errors.New("coupon not found")
+	ErrCouponExists   = errors.New("coupon code already exists")
+)
+
+const couponColumns = "ID, Code, Type, PercentOff, AmountOff, MinOrderValue, Currency, StartsAt, EndsAt, " +
+	"MaxUses, MaxUsesPerUser, IsActive, CreatedAt"
+
+// CouponRepository defines operations for coupons and their redemptions
+type CouponRepository interface {
+	CreateCoupon(coupon *domain.Coupon) (*domain.Coupon, error)
+	GetCouponByCode(code string) (*domain.Coupon, error)
+	GetCoupons() ([]*domain.Coupon, error)
+	SetCouponActive(id int64, active bool) error
+	CartDiscount(cart *domain.Cart, lineDiscounts map[int64]domain.Money) (*domain.Coupon, domain.Money, error)
+}
+
+// couponRepo is the concrete implementation
+type couponRepo struct {
+	db Repository
+}
+
+// NewCouponRepository creates a new CouponRepository
+func NewCouponRepository(db Repository) CouponRepository {
+	return &couponRepo{db: db}
+}
+
+// CreateCoupon stores a coupon together with its product and category restrictions
+func (r *couponRepo) CreateCoupon(coupon *domain.Coupon) (*domain.Coupon, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO Coupon (Code, Type, PercentOff, AmountOff, MinOrderValue, Currency, StartsAt, EndsAt, MaxUses, MaxUsesPerUser, IsActive)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		coupon.Code, coupon.Type, domain.FormatPercent(coupon.PercentOff), coupon.AmountOff, coupon.MinOrderValue,
+		coupon.Currency(), coupon.StartsAt, coupon.EndsAt, coupon.MaxUses, coupon.MaxUsesPerUser, coupon.IsActive,
+	)
+	if isDuplicateKey(err) {
+		return nil, fmt.Errorf("%w: %s", ErrCouponExists, coupon.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, productID := range coupon.ProductIDs {
+		_, err := tx.Exec("INSERT IGNORE INTO CouponProduct (CouponID, ProductID) VALUES (?, ?)", id, productID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, category := range coupon.Categories {
+		_, err := tx.Exec("INSERT IGNORE INTO CouponCategory (CouponID, Category) VALUES (?, ?)", id, category)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetCouponByCode(coupon.Code)
+}
+
+// GetCouponByCode retrieves a coupon by its code
+func (r *couponRepo) GetCouponByCode(code string) (*domain.Coupon, error) {
+	return loadCoupon(r.db, "SELECT "+couponColumns+" FROM Coupon WHERE Code = ?", domain.NormalizeCouponCode(code))
+}
+
+// GetCoupons retrieves all coupons, newest first
+func (r *couponRepo) GetCoupons() ([]*domain.Coupon, error) {
+	rows, err := r.db.Query("SELECT " + couponColumns + " FROM Coupon ORDER BY ID DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := make([]*domain.Coupon, 0)
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, coupon := range coupons {
+		if err := loadCouponRestrictions(r.db, coupon); err != nil {
+			return nil, err
+		}
+	}
+
+	return coupons, nil
+}
+
+// SetCouponActive enables or disables a coupon
+func (r *couponRepo) SetCouponActive(id int64, active bool) error {
+	result, err := r.db.Exec("UPDATE Coupon SET IsActive = ? WHERE ID = ?", active, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		var exists bool
+		err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM Coupon WHERE ID = ?)", id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrCouponNotFound
+		}
+	}
+	return nil
+}
+
+// CartDiscount evaluates the coupon applied to a cart and returns it with
+// the discount it currently gives on top of lineDiscounts, the promotion
+// discounts per product. A cart without a coupon gets a zero discount and a
+// nil coupon.
+func (r *couponRepo) CartDiscount(cart *domain.Cart, lineDiscounts map[int64]domain.Money) (*domain.Coupon, domain.Money, error) {
+	if cart.CouponCode == "" {
+		return nil, domain.NewMoney(0, cart.Currency), nil
+	}
+
+	coupon, err := r.GetCouponByCode(cart.CouponCode)
+	if err != nil {
+		return nil, domain.NewMoney(0, cart.Currency), err
+	}
+
+	discount, err := evaluateCoupon(r.db, r.db, coupon, cart, lineDiscounts)
+	return coupon, discount, err
+}
+
+// queryer is satisfied by both Repository and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// evaluateCoupon checks the usage limits of coupon for the cart's user and
+// computes its discount on the cart after lineDiscounts. Usage is counted
+// through q so checkout can count inside its transaction; exchange rates
+// are read through db.
+func evaluateCoupon(q queryer, db Repository, coupon *domain.Coupon, cart *domain.Cart, lineDiscounts map[int64]domain.Money) (domain.Money, error) {
+	zero := domain.NewMoney(0, cart.Currency)
+
+	var total, byUser int
+	err := q.QueryRow(
+		"SELECT COUNT(*), COALESCE(SUM(UserID = ?), 0) FROM CouponRedemption WHERE CouponID = ?",
+		cart.UserID, coupon.ID,
+	).Scan(&total, &byUser)
+	if err != nil {
+		return zero, err
+	}
+	if coupon.MaxUses > 0 && total >= coupon.MaxUses {
+		return zero, domain.ErrCouponUsageExceeded
+	}
+	if coupon.MaxUsesPerUser > 0 && byUser >= coupon.MaxUsesPerUser {
+		return zero, fmt.Errorf("%w for this customer", domain.ErrCouponUsageExceeded)
+	}
+
+	if currency := coupon.Currency(); currency != "" && currency != cart.Currency {
+		rate, err := (&currencyRepo{db: db}).GetRate(currency, cart.Currency)
+		if err != nil {
+			return zero, err
+		}
+		coupon = coupon.InCurrency(cart.Currency, rate)
+	}
+
+	categories, err := productCategories(q, cart.Items)
+	if err != nil {
+		return zero, err
+	}
+
+	return coupon.Discount(cart, categories, lineDiscounts, time.Now())
+}
+
+// productCategories maps the products of the given cart items to their category
+func productCategories(q queryer, items []domain.CartItem) (map[int64]string, error) {
+	categories := make(map[int64]string, len(items))
+	if len(items) == 0 {
+		return categories, nil
+	}
+
+	placeholders := make([]string, 0, len(items))
+	args := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		placeholders = append(placeholders, "?")
+		args = append(args, item.ProductID)
+	}
+
+	rows, err := q.Query("SELECT ID, Category FROM Product WHERE ID IN ("+strings.Join(placeholders, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var category string
+		if err := rows.Scan(&id, &category); err != nil {
+			return nil, err
+		}
+		categories[id] = category
+	}
+
+	return categories, rows.Err()
+}
+
+// loadCoupon reads a single coupon selected with couponColumns, including
+// its restrictions
+func loadCoupon(q queryer, query string, args ...interface{}) (*domain.Coupon, error) {
+	coupon, err := scanCoupon(q.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadCouponRestrictions(q, coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// loadCouponRestrictions reads the products and categories a coupon is limited to
+func loadCouponRestrictions(q queryer, coupon *domain.Coupon) error {
+	rows, err := q.Query("SELECT ProductID FROM CouponProduct WHERE CouponID = ? ORDER BY ProductID", coupon.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		coupon.ProductIDs = append(coupon.ProductIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query("SELECT Category FROM CouponCategory WHERE CouponID = ? ORDER BY Category", coupon.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return err
+		}
+		coupon.Categories = append(coupon.Categories, category)
+	}
+	return rows.Err()
+}
+
+// scanCoupon reads a coupon row selected with couponColumns
+func scanCoupon(row rowScanner) (*domain.Coupon, error) {
+	coupon := &domain.Coupon{}
+	var percent, amountOff, minOrder, currency string
+	var startsAt, endsAt sql.NullTime
+	err := row.Scan(
+		&coupon.ID, &coupon.Code, &coupon.Type, &percent, &amountOff, &minOrder, &currency, &startsAt, &endsAt,
+		&coupon.MaxUses, &coupon.MaxUsesPerUser, &coupon.IsActive, &coupon.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if startsAt.Valid {
+		coupon.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		coupon.EndsAt = &endsAt.Time
+	}
+
+	coupon.PercentOff, err = domain.ParsePercent(percent)
+	if err != nil {
+		return nil, err
+	}
+	// Percentage coupons without a minimum have no currency and stay
+	// compatible with carts in any currency
+	if currency == "" {
+		return coupon, nil
+	}
+	coupon.AmountOff, err = domain.ParseMoney(amountOff, currency)
+	if err != nil {
+		return nil, err
+	}
+	coupon.MinOrderValue, err = domain.ParseMoney(minOrder, currency)
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
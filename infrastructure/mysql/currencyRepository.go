@@ -0,0 +1,215 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+// CurrencyRepository defines operations for exchange rates and per-currency
+// product price lists
+type CurrencyRepository interface {
+	GetRate(from, to string) (*big.Rat, error)
+	GetRates() ([]*domain.ExchangeRate, error)
+	SaveRates(rates []*domain.ExchangeRate) error
+	GetProductPrices(productID int64) ([]domain.Money, error)
+	SetProductPrice(productID int64, price domain.Money) error
+	DeleteProductPrice(productID int64, currency string) error
+	PriceIn(product *domain.Product, currency string) (domain.Money, error)
+}
+
+// currencyRepo is the concrete implementation
+type currencyRepo struct {
+	db Repository
+}
+
+// NewCurrencyRepository creates a new CurrencyRepository
+func NewCurrencyRepository(db Repository) CurrencyRepository {
+	return &currencyRepo{db: db}
+}
+
+// GetRate returns how many units of to one unit of from buys. A direct rate
+// is preferred, then the inverse of the opposite rate, then a cross rate
+// through a base currency quoted against both.
+func (r *currencyRepo) GetRate(from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	var rate string
+	err := r.db.QueryRow("SELECT Rate FROM ExchangeRate WHERE Base = ? AND Quote = ?", from, to).Scan(&rate)
+	if err == nil {
+		return parseRate(rate)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	err = r.db.QueryRow("SELECT Rate FROM ExchangeRate WHERE Base = ? AND Quote = ?", to, from).Scan(&rate)
+	if err == nil {
+		inverse, err := parseRate(rate)
+		if err != nil {
+			return nil, err
+		}
+		return inverse.Inv(inverse), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var fromRate, toRate string
+	err = r.db.QueryRow(
+		`SELECT a.Rate, b.Rate FROM ExchangeRate a
+		 JOIN ExchangeRate b ON b.Base = a.Base
+		 WHERE a.Quote = ? AND b.Quote = ? LIMIT 1`, from, to,
+	).Scan(&fromRate, &toRate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrExchangeRateNotFound, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := parseRate(fromRate)
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseRate(toRate)
+	if err != nil {
+		return nil, err
+	}
+	return b.Quo(b, a), nil
+}
+
+// GetRates retrieves all stored exchange rates
+func (r *currencyRepo) GetRates() ([]*domain.ExchangeRate, error) {
+	rows, err := r.db.Query("SELECT Base, Quote, Rate, UpdatedAt FROM ExchangeRate ORDER BY Base, Quote")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]*domain.ExchangeRate, 0)
+	for rows.Next() {
+		rate := &domain.ExchangeRate{}
+		var value string
+		if err := rows.Scan(&rate.Base, &rate.Quote, &value, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rate.Rate, err = parseRate(value)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// SaveRates inserts or replaces exchange rates in one transaction
+func (r *currencyRepo) SaveRates(rates []*domain.ExchangeRate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		_, err := tx.Exec(
+			`INSERT INTO ExchangeRate (Base, Quote, Rate) VALUES (?, ?, ?)
+			 ON DUPLICATE KEY UPDATE Rate = VALUES(Rate), UpdatedAt = CURRENT_TIMESTAMP`,
+			rate.Base, rate.Quote, rate.Rate.FloatString(8),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetProductPrices retrieves the explicit price list entries of a product
+func (r *currencyRepo) GetProductPrices(productID int64) ([]domain.Money, error) {
+	rows, err := r.db.Query("SELECT Price, Currency FROM ProductPrice WHERE ProductID = ? ORDER BY Currency", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make([]domain.Money, 0)
+	for rows.Next() {
+		var amount, currency string
+		if err := rows.Scan(&amount, &currency); err != nil {
+			return nil, err
+		}
+		price, err := domain.ParseMoney(amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// SetProductPrice sets the explicit price of a product in one currency
+func (r *currencyRepo) SetProductPrice(productID int64, price domain.Money) error {
+	_, err := r.db.Exec(
+		"INSERT INTO ProductPrice (ProductID, Currency, Price) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE Price = VALUES(Price)",
+		productID, price.Currency, price,
+	)
+	return err
+}
+
+// DeleteProductPrice removes a price list entry so the converted price applies
+func (r *currencyRepo) DeleteProductPrice(productID int64, currency string) error {
+	_, err := r.db.Exec("DELETE FROM ProductPrice WHERE ProductID = ? AND Currency = ?", productID, currency)
+	return err
+}
+
+// PriceIn returns the price of a product in currency: its own price if it is
+// in that currency, else its price list entry, else its price converted at
+// the stored exchange rate
+func (r *currencyRepo) PriceIn(product *domain.Product, currency string) (domain.Money, error) {
+	if product.Price.Currency == currency {
+		return product.Price, nil
+	}
+
+	var amount string
+	err := r.db.QueryRow(
+		"SELECT Price FROM ProductPrice WHERE ProductID = ? AND Currency = ?", product.ID, currency,
+	).Scan(&amount)
+	if err == nil {
+		return domain.ParseMoney(amount, currency)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return domain.Money{}, err
+	}
+
+	rate, err := r.GetRate(product.Price.Currency, currency)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return product.Price.Convert(currency, rate), nil
+}
+
+// parseRate parses a DECIMAL exchange rate
+func parseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", value)
+	}
+	return rate, nil
+}
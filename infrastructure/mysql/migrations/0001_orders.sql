@@ -0,0 +1,23 @@
+-- Checkout: product stock, orders and order items
+
+ALTER TABLE Product ADD COLUMN Stock INT NOT NULL DEFAULT 0;
+
+CREATE TABLE Orders (
+    ID          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    UserID      BIGINT NOT NULL,
+    CartID      BIGINT NOT NULL,
+    TotalAmount DECIMAL(10, 2) NOT NULL,
+    Status      VARCHAR(32) NOT NULL,
+    CreatedAt   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_orders_user (UserID)
+);
+
+CREATE TABLE OrderItem (
+    ID          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    OrderID     BIGINT NOT NULL,
+    ProductID   BIGINT NOT NULL,
+    ProductName VARCHAR(255) NOT NULL,
+    Quantity    INT NOT NULL,
+    Price       DECIMAL(10, 2) NOT NULL,
+    FOREIGN KEY (OrderID) REFERENCES Orders(ID)
+);
@@ -0,0 +1,12 @@
+-- Order lifecycle: audit history of status transitions
+
+CREATE TABLE OrderStatusHistory (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    OrderID    BIGINT NOT NULL,
+    FromStatus VARCHAR(32) NOT NULL,
+    ToStatus   VARCHAR(32) NOT NULL,
+    ChangedBy  BIGINT NOT NULL,
+    Note       VARCHAR(512) NOT NULL DEFAULT '',
+    ChangedAt  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (OrderID) REFERENCES Orders(ID)
+);
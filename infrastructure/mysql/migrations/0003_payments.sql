@@ -0,0 +1,27 @@
+-- Payments: idempotent payment intents and processed webhook events
+
+CREATE TABLE Payment (
+    ID             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    OrderID        BIGINT NOT NULL,
+    Provider       VARCHAR(32) NOT NULL,
+    ProviderRef    VARCHAR(128) NOT NULL DEFAULT '',
+    IdempotencyKey VARCHAR(128) NOT NULL,
+    Amount         DECIMAL(10, 2) NOT NULL,
+    RefundedAmount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    Currency       CHAR(3) NOT NULL,
+    Status         VARCHAR(32) NOT NULL,
+    Message        VARCHAR(255) NOT NULL DEFAULT '',
+    CreatedAt      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_payment_idempotency (IdempotencyKey),
+    INDEX idx_payment_provider_ref (Provider, ProviderRef),
+    FOREIGN KEY (OrderID) REFERENCES Orders(ID)
+);
+
+CREATE TABLE PaymentWebhookEvent (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    Provider   VARCHAR(32) NOT NULL,
+    EventID    VARCHAR(128) NOT NULL,
+    ReceivedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_webhook_event (Provider, EventID)
+);
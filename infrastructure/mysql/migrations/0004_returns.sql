@@ -0,0 +1,24 @@
+-- Returns: return requests (RMA) per order line and refunded totals
+
+ALTER TABLE Orders ADD COLUMN RefundedAmount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE OrderItem ADD COLUMN ReturnedQuantity INT NOT NULL DEFAULT 0;
+
+CREATE TABLE ReturnRequest (
+    ID           BIGINT AUTO_INCREMENT PRIMARY KEY,
+    OrderID      BIGINT NOT NULL,
+    OrderItemID  BIGINT NOT NULL,
+    UserID       BIGINT NOT NULL,
+    Quantity     INT NOT NULL,
+    Reason       VARCHAR(32) NOT NULL,
+    Comment      VARCHAR(1024) NOT NULL DEFAULT '',
+    Status       VARCHAR(32) NOT NULL,
+    RefundAmount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    ReviewedBy   BIGINT NOT NULL DEFAULT 0,
+    StaffNote    VARCHAR(512) NOT NULL DEFAULT '',
+    CreatedAt    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_return_status (Status),
+    FOREIGN KEY (OrderID) REFERENCES Orders(ID),
+    FOREIGN KEY (OrderItemID) REFERENCES OrderItem(ID)
+);
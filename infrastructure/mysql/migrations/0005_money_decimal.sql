@@ -0,0 +1,20 @@
+-- Money: store every amount as an exact DECIMAL wide enough for currencies
+-- with up to four minor digits
+
+ALTER TABLE Product MODIFY Price DECIMAL(19, 4) NOT NULL;
+
+ALTER TABLE Cart MODIFY TotalAmount DECIMAL(19, 4) NOT NULL DEFAULT 0;
+
+ALTER TABLE CartItem MODIFY Price DECIMAL(19, 4) NOT NULL;
+
+ALTER TABLE Orders
+    MODIFY TotalAmount DECIMAL(19, 4) NOT NULL,
+    MODIFY RefundedAmount DECIMAL(19, 4) NOT NULL DEFAULT 0;
+
+ALTER TABLE OrderItem MODIFY Price DECIMAL(19, 4) NOT NULL;
+
+ALTER TABLE Payment
+    MODIFY Amount DECIMAL(19, 4) NOT NULL,
+    MODIFY RefundedAmount DECIMAL(19, 4) NOT NULL DEFAULT 0;
+
+ALTER TABLE ReturnRequest MODIFY RefundAmount DECIMAL(19, 4) NOT NULL DEFAULT 0;
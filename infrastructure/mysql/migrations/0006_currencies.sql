@@ -0,0 +1,26 @@
+-- Currencies: the currency of prices, carts and orders, stored exchange
+-- rates and per-currency product price lists
+
+ALTER TABLE Product ADD COLUMN Currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE Cart ADD COLUMN Currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE Orders ADD COLUMN Currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+CREATE TABLE ExchangeRate (
+    ID        BIGINT AUTO_INCREMENT PRIMARY KEY,
+    Base      CHAR(3) NOT NULL,
+    Quote     CHAR(3) NOT NULL,
+    Rate      DECIMAL(19, 8) NOT NULL,
+    UpdatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_exchange_rate (Base, Quote)
+);
+
+CREATE TABLE ProductPrice (
+    ID        BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ProductID BIGINT NOT NULL,
+    Currency  CHAR(3) NOT NULL,
+    Price     DECIMAL(19, 4) NOT NULL,
+    UNIQUE KEY uq_product_price (ProductID, Currency),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE
+);
@@ -0,0 +1,54 @@
+-- Coupons: discount codes with validity windows, usage limits and
+-- product/category restrictions, applied to carts and redeemed at checkout
+
+ALTER TABLE Product ADD COLUMN Category VARCHAR(128) NOT NULL DEFAULT '';
+
+ALTER TABLE Cart ADD COLUMN CouponCode VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE Orders
+    ADD COLUMN DiscountAmount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN CouponCode VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE TABLE Coupon (
+    ID             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    Code           VARCHAR(64) NOT NULL,
+    Type           VARCHAR(32) NOT NULL,
+    PercentOff     DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    AmountOff      DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    MinOrderValue  DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    Currency       VARCHAR(3) NOT NULL DEFAULT '',
+    StartsAt       DATETIME NULL,
+    EndsAt         DATETIME NULL,
+    MaxUses        INT NOT NULL DEFAULT 0,
+    MaxUsesPerUser INT NOT NULL DEFAULT 0,
+    IsActive       BOOLEAN NOT NULL DEFAULT true,
+    CreatedAt      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_coupon_code (Code)
+);
+
+CREATE TABLE CouponProduct (
+    CouponID  BIGINT NOT NULL,
+    ProductID BIGINT NOT NULL,
+    PRIMARY KEY (CouponID, ProductID),
+    FOREIGN KEY (CouponID) REFERENCES Coupon(ID)
+);
+
+CREATE TABLE CouponCategory (
+    CouponID BIGINT NOT NULL,
+    Category VARCHAR(128) NOT NULL,
+    PRIMARY KEY (CouponID, Category),
+    FOREIGN KEY (CouponID) REFERENCES Coupon(ID)
+);
+
+CREATE TABLE CouponRedemption (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    CouponID   BIGINT NOT NULL,
+    UserID     BIGINT NOT NULL,
+    OrderID    BIGINT NOT NULL,
+    Amount     DECIMAL(19, 4) NOT NULL,
+    Currency   CHAR(3) NOT NULL,
+    RedeemedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_coupon_redemption_user (CouponID, UserID),
+    FOREIGN KEY (CouponID) REFERENCES Coupon(ID),
+    FOREIGN KEY (OrderID) REFERENCES Orders(ID)
+);
@@ -0,0 +1,44 @@
+-- Promotions: rule-based discounts applied to carts automatically, and the
+-- promotion discount given on each order line
+
+ALTER TABLE OrderItem ADD COLUMN DiscountAmount DECIMAL(19, 4) NOT NULL DEFAULT 0;
+
+CREATE TABLE Promotion (
+    ID          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    Name        VARCHAR(255) NOT NULL,
+    Type        VARCHAR(32) NOT NULL,
+    Priority    INT NOT NULL DEFAULT 0,
+    Stackable   BOOLEAN NOT NULL DEFAULT false,
+    StartsAt    DATETIME NULL,
+    EndsAt      DATETIME NULL,
+    BuyQuantity INT NOT NULL DEFAULT 0,
+    GetQuantity INT NOT NULL DEFAULT 0,
+    PercentOff  DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    BundlePrice DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    Currency    VARCHAR(3) NOT NULL DEFAULT '',
+    IsActive    BOOLEAN NOT NULL DEFAULT true,
+    CreatedAt   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_promotion_active (IsActive, StartsAt, EndsAt)
+);
+
+CREATE TABLE PromotionProduct (
+    PromotionID BIGINT NOT NULL,
+    ProductID   BIGINT NOT NULL,
+    PRIMARY KEY (PromotionID, ProductID),
+    FOREIGN KEY (PromotionID) REFERENCES Promotion(ID)
+);
+
+CREATE TABLE PromotionCategory (
+    PromotionID BIGINT NOT NULL,
+    Category    VARCHAR(128) NOT NULL,
+    PRIMARY KEY (PromotionID, Category),
+    FOREIGN KEY (PromotionID) REFERENCES Promotion(ID)
+);
+
+CREATE TABLE PromotionTier (
+    PromotionID BIGINT NOT NULL,
+    MinQuantity INT NOT NULL,
+    PercentOff  DECIMAL(5, 2) NOT NULL,
+    PRIMARY KEY (PromotionID, MinQuantity),
+    FOREIGN KEY (PromotionID) REFERENCES Promotion(ID)
+);
@@ -0,0 +1,15 @@
+-- Tax: a tax class per product, the tax charged on each order line and the
+-- jurisdiction and totals of each order's tax. Order line discounts now
+-- include the line's share of the coupon discount.
+
+ALTER TABLE Product ADD COLUMN TaxClass VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE OrderItem
+    ADD COLUMN TaxAmount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN TaxRate DECIMAL(7, 4) NOT NULL DEFAULT 0;
+
+ALTER TABLE Orders
+    ADD COLUMN TaxAmount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN PricesIncludeTax BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN TaxCountry CHAR(2) NOT NULL DEFAULT '',
+    ADD COLUMN TaxRegion VARCHAR(8) NOT NULL DEFAULT '';
@@ -0,0 +1,42 @@
+-- Shipping: product weights, the users' address books, and the shipping
+-- method, charge and addresses of each order
+
+ALTER TABLE Product ADD COLUMN WeightGrams INT NOT NULL DEFAULT 0;
+
+CREATE TABLE Address (
+    ID                BIGINT AUTO_INCREMENT PRIMARY KEY,
+    UserID            BIGINT NOT NULL,
+    Name              VARCHAR(255) NOT NULL,
+    Line1             VARCHAR(255) NOT NULL,
+    Line2             VARCHAR(255) NOT NULL DEFAULT '',
+    City              VARCHAR(128) NOT NULL,
+    Region            VARCHAR(8) NOT NULL DEFAULT '',
+    PostalCode        VARCHAR(32) NOT NULL DEFAULT '',
+    Country           CHAR(2) NOT NULL,
+    Phone             VARCHAR(32) NOT NULL DEFAULT '',
+    IsDefaultBilling  BOOLEAN NOT NULL DEFAULT false,
+    IsDefaultShipping BOOLEAN NOT NULL DEFAULT false,
+    CreatedAt         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_address_user (UserID)
+);
+
+ALTER TABLE Orders
+    ADD COLUMN ShippingMethod VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN ShippingAmount DECIMAL(19, 4) NOT NULL DEFAULT 0;
+
+-- Addresses are copied so editing the address book leaves past orders alone
+CREATE TABLE OrderAddress (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    OrderID    BIGINT NOT NULL,
+    Type       VARCHAR(16) NOT NULL,
+    Name       VARCHAR(255) NOT NULL,
+    Line1      VARCHAR(255) NOT NULL,
+    Line2      VARCHAR(255) NOT NULL DEFAULT '',
+    City       VARCHAR(128) NOT NULL,
+    Region     VARCHAR(8) NOT NULL DEFAULT '',
+    PostalCode VARCHAR(32) NOT NULL DEFAULT '',
+    Country    CHAR(2) NOT NULL,
+    Phone      VARCHAR(32) NOT NULL DEFAULT '',
+    UNIQUE KEY uq_order_address (OrderID, Type),
+    FOREIGN KEY (OrderID) REFERENCES Orders(ID)
+);
@@ -0,0 +1,10 @@
+-- Guest carts: carts owned by a signed guest token instead of a user, merged
+-- into the user's cart on login. Item timestamps decide the "latest" merge.
+
+ALTER TABLE Cart
+    MODIFY UserID BIGINT NULL,
+    ADD COLUMN GuestID VARCHAR(64) NULL AFTER UserID,
+    ADD UNIQUE KEY uq_cart_guest (GuestID);
+
+ALTER TABLE CartItem
+    ADD COLUMN UpdatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
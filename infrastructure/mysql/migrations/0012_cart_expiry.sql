@@ -0,0 +1,7 @@
+-- Cart expiry: when each cart's contents last changed, and when an idle user
+-- cart was flagged as abandoned
+
+ALTER TABLE Cart
+    ADD COLUMN UpdatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    ADD COLUMN AbandonedAt DATETIME NULL,
+    ADD INDEX idx_cart_activity (IsActive, UpdatedAt);
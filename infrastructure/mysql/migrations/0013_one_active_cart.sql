@@ -0,0 +1,8 @@
+-- One active cart per user. MySQL has no partial unique indexes, so the
+-- user of each active cart is copied into a generated column that is NULL
+-- for inactive and guest carts. Consolidate existing duplicates with the
+-- repair-carts command before applying this migration.
+
+ALTER TABLE Cart
+    ADD COLUMN ActiveUserID BIGINT AS (IF(IsActive, UserID, NULL)) STORED,
+    ADD UNIQUE KEY uq_cart_active_user (ActiveUserID);
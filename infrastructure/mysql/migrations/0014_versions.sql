@@ -0,0 +1,6 @@
+-- Optimistic concurrency: a version on products and carts, incremented by
+-- every change and exposed as the ETag checked against If-Match
+
+ALTER TABLE Product ADD COLUMN Version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE Cart ADD COLUMN Version BIGINT NOT NULL DEFAULT 1;
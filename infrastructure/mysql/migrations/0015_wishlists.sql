@@ -0,0 +1,28 @@
+-- Wishlists: named product lists per user, shareable through a token, and
+-- each user's saved-for-later list. Items keep the price they were added at
+-- to detect price drops.
+
+CREATE TABLE Wishlist (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    UserID     BIGINT NOT NULL,
+    Name       VARCHAR(100) NOT NULL,
+    Kind       VARCHAR(16) NOT NULL DEFAULT 'wishlist',
+    IsPublic   BOOLEAN NOT NULL DEFAULT false,
+    ShareToken VARCHAR(64) NULL,
+    CreatedAt  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_wishlist_name (UserID, Name),
+    UNIQUE KEY uq_wishlist_share (ShareToken)
+);
+
+CREATE TABLE WishlistItem (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    WishlistID BIGINT NOT NULL,
+    ProductID  BIGINT NOT NULL,
+    Quantity   INT NOT NULL DEFAULT 1,
+    Price      DECIMAL(19, 4) NOT NULL,
+    Currency   CHAR(3) NOT NULL,
+    AddedAt    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_wishlist_item (WishlistID, ProductID),
+    INDEX idx_wishlist_item_product (ProductID),
+    FOREIGN KEY (WishlistID) REFERENCES Wishlist(ID)
+);
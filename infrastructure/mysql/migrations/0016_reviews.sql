@@ -0,0 +1,20 @@
+-- Reviews: product ratings by users, flagged as verified purchases when the
+-- user has bought the product, and shown once approved by a moderator
+
+CREATE TABLE Review (
+    ID               BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ProductID        BIGINT NOT NULL,
+    UserID           BIGINT NOT NULL,
+    Rating           TINYINT NOT NULL,
+    Title            VARCHAR(200) NOT NULL,
+    Body             TEXT NOT NULL,
+    VerifiedPurchase BOOLEAN NOT NULL DEFAULT false,
+    Status           VARCHAR(32) NOT NULL,
+    ModeratedBy      BIGINT NOT NULL DEFAULT 0,
+    ModerationNote   VARCHAR(512) NOT NULL DEFAULT '',
+    CreatedAt        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt        DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_review_user_product (UserID, ProductID),
+    INDEX idx_review_product_status (ProductID, Status, CreatedAt),
+    INDEX idx_review_status (Status, CreatedAt)
+);
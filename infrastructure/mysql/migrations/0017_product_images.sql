@@ -0,0 +1,28 @@
+-- Product images: uploaded originals in blob storage, ordered per product,
+-- and the thumbnails generated from them in each configured size
+
+CREATE TABLE ProductImage (
+    ID          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ProductID   BIGINT NOT NULL,
+    BlobKey     VARCHAR(255) NOT NULL,
+    ContentType VARCHAR(64) NOT NULL,
+    Width       INT NOT NULL,
+    Height      INT NOT NULL,
+    SizeBytes   BIGINT NOT NULL,
+    AltText     VARCHAR(255) NOT NULL DEFAULT '',
+    Position    INT NOT NULL,
+    CreatedAt   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_product_image_key (BlobKey),
+    INDEX idx_product_image_product (ProductID, Position)
+);
+
+CREATE TABLE ProductImageThumbnail (
+    ID      BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ImageID BIGINT NOT NULL,
+    Size    VARCHAR(32) NOT NULL,
+    BlobKey VARCHAR(255) NOT NULL,
+    Width   INT NOT NULL,
+    Height  INT NOT NULL,
+    UNIQUE KEY uq_product_image_thumbnail (ImageID, Size),
+    FOREIGN KEY (ImageID) REFERENCES ProductImage(ID)
+);
@@ -0,0 +1,6 @@
+-- Product SKU: the merchandisers' identifier for a product, matched when the
+-- catalog is imported
+
+ALTER TABLE Product
+    ADD COLUMN SKU VARCHAR(64) NULL,
+    ADD UNIQUE KEY uq_product_sku (SKU);
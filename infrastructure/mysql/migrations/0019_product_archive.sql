@@ -0,0 +1,6 @@
+-- Product archive: deleting a product sets DeletedAt instead of removing the
+-- row, which the purge job removes once nothing references it
+
+ALTER TABLE Product
+    ADD COLUMN DeletedAt DATETIME NULL,
+    ADD INDEX idx_product_deleted (DeletedAt);
@@ -0,0 +1,33 @@
+-- Price history: every change to a product's base price with who made it,
+-- and price changes scheduled ahead, such as sales with a start and an end
+
+CREATE TABLE PriceSchedule (
+    ID          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ProductID   BIGINT NOT NULL,
+    Price       DECIMAL(19, 4) NOT NULL,
+    Currency    CHAR(3) NOT NULL,
+    StartsAt    DATETIME NOT NULL,
+    EndsAt      DATETIME NULL,
+    RevertPrice DECIMAL(19, 4) NULL,
+    Status      VARCHAR(16) NOT NULL DEFAULT 'pending',
+    CreatedBy   BIGINT NOT NULL DEFAULT 0,
+    CreatedAt   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_price_schedule_product (ProductID, StartsAt),
+    INDEX idx_price_schedule_due (Status, StartsAt),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE
+);
+
+CREATE TABLE PriceHistory (
+    ID          BIGINT AUTO_INCREMENT PRIMARY KEY,
+    ProductID   BIGINT NOT NULL,
+    OldPrice    DECIMAL(19, 4) NOT NULL,
+    OldCurrency CHAR(3) NOT NULL,
+    NewPrice    DECIMAL(19, 4) NOT NULL,
+    NewCurrency CHAR(3) NOT NULL,
+    Source      VARCHAR(16) NOT NULL,
+    ScheduleID  BIGINT NULL,
+    ChangedBy   BIGINT NOT NULL DEFAULT 0,
+    ChangedAt   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_price_history_product (ProductID, ChangedAt),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE
+);
@@ -0,0 +1,22 @@
+-- Product attributes: typed properties such as brand or colour defined once,
+-- and each product's value for them, used to filter and facet listings
+
+CREATE TABLE Attribute (
+    ID         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    Code       VARCHAR(64) NOT NULL,
+    Name       VARCHAR(100) NOT NULL,
+    Type       VARCHAR(16) NOT NULL,
+    Filterable BOOLEAN NOT NULL DEFAULT true,
+    CreatedAt  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_attribute_code (Code)
+);
+
+CREATE TABLE ProductAttribute (
+    ProductID   BIGINT NOT NULL,
+    AttributeID BIGINT NOT NULL,
+    Value       VARCHAR(255) NOT NULL,
+    PRIMARY KEY (ProductID, AttributeID),
+    INDEX idx_product_attribute_value (AttributeID, Value),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE,
+    FOREIGN KEY (AttributeID) REFERENCES Attribute(ID) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Product translations: names and descriptions in locales other than the
+-- default one held by Product, with full-text indexes for localized search
+
+CREATE TABLE ProductTranslation (
+    ProductID   BIGINT NOT NULL,
+    Locale      VARCHAR(16) NOT NULL,
+    Name        VARCHAR(255) NOT NULL DEFAULT '',
+    Description TEXT NOT NULL,
+    UpdatedAt   DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (ProductID, Locale),
+    INDEX idx_product_translation_locale (Locale),
+    FULLTEXT INDEX ft_product_translation (Name, Description),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE
+);
+
+ALTER TABLE Product
+    ADD FULLTEXT INDEX ft_product (Name, Description);
@@ -0,0 +1,15 @@
+-- Product slugs: unique URL names generated from product names, and the
+-- slugs products had before, which redirect to the current one. Run the
+-- generate-slugs command after migrating to give existing products a slug.
+
+ALTER TABLE Product
+    ADD COLUMN Slug VARCHAR(160) NULL,
+    ADD UNIQUE KEY uq_product_slug (Slug);
+
+CREATE TABLE ProductSlugHistory (
+    Slug      VARCHAR(160) NOT NULL PRIMARY KEY,
+    ProductID BIGINT NOT NULL,
+    CreatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_product_slug_history_product (ProductID),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE
+);
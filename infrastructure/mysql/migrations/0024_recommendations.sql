@@ -0,0 +1,16 @@
+-- Recommendations: products often ordered or carted together, recomputed
+-- in full by the recommendation job. Score counts the orders or carts that
+-- held both products; Position ranks RecommendedID among ProductID's.
+
+CREATE TABLE ProductRecommendation (
+    ProductID     BIGINT NOT NULL,
+    Kind          VARCHAR(32) NOT NULL,
+    RecommendedID BIGINT NOT NULL,
+    Score         INT NOT NULL,
+    Position      INT NOT NULL,
+    ComputedAt    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ProductID, Kind, RecommendedID),
+    INDEX idx_product_recommendation_position (ProductID, Kind, Position),
+    FOREIGN KEY (ProductID) REFERENCES Product(ID) ON DELETE CASCADE,
+    FOREIGN KEY (RecommendedID) REFERENCES Product(ID) ON DELETE CASCADE
+);
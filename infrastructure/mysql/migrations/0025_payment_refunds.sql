@@ -0,0 +1,17 @@
+-- Payment refunds: a refund is reserved against its payment before the
+-- provider is called, so concurrent requests cannot refund more than was
+-- captured. The idempotency key sent to the provider is unique per refund.
+
+CREATE TABLE PaymentRefund (
+    ID             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    PaymentID      BIGINT NOT NULL,
+    IdempotencyKey VARCHAR(128) NOT NULL,
+    Amount         DECIMAL(19, 4) NOT NULL,
+    Currency       CHAR(3) NOT NULL,
+    Status         VARCHAR(32) NOT NULL,
+    CreatedAt      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt      DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_payment_refund_idempotency (IdempotencyKey),
+    INDEX idx_payment_refund_status (PaymentID, Status),
+    FOREIGN KEY (PaymentID) REFERENCES Payment(ID)
+);
@@ -0,0 +1,652 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"ecommerce-go/infrastructure/shipping"
+	"ecommerce-go/infrastructure/tax"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrCartNotFound      = errors.New("cart not found")
+	ErrCartEmpty         = errors.New("cart is empty")
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInvalidStatus     = errors.New("invalid order status")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+	ErrPaymentStatus     = errors.New("order status is set by its payments")
+	ErrOrderHasPayment   = errors.New("order has a payment to void or refund first")
+)
+
+const orderColumns = "ID, UserID, CartID, Currency, TotalAmount, DiscountAmount, CouponCode, TaxAmount, PricesIncludeTax, " +
+	"TaxCountry, TaxRegion, ShippingMethod, ShippingAmount, RefundedAmount, Status, CreatedAt"
+
+const orderAddressColumns = "Type, Name, Line1, Line2, City, Region, PostalCode, Country, Phone"
+
+// OrderRepository defines operations for Order
+type OrderRepository interface {
+	Checkout(userID int64, opts CheckoutOptions) (*domain.Order, error)
+	GetOrderByID(id int64) (*domain.Order, error)
+	GetOrdersByUserID(userID int64) ([]*domain.Order, error)
+	UpdateOrderStatus(orderID int64, status domain.OrderStatus, changedBy int64, note string) (*domain.Order, error)
+	GetOrderHistory(orderID int64) ([]*domain.OrderStatusChange, error)
+}
+
+// orderRepo is the concrete implementation
+type orderRepo struct {
+	db Repository
+}
+
+// CheckoutOptions holds what checkout needs beyond the cart itself. When a
+// shipping address is given the order is shipped there by ShippingMethod,
+// or by the cheapest method without one, and taxed at its location instead
+// of TaxLocation.
+type CheckoutOptions struct {
+	Taxes             tax.Calculator
+	TaxLocation       tax.Location
+	Shipping          shipping.RateProvider
+	ShippingAddressID int64
+	ShippingMethod    string
+	BillingAddressID  int64
+}
+
+// NewOrderRepository creates a new OrderRepository
+func NewOrderRepository(db Repository) OrderRepository {
+	return &orderRepo{db: db}
+}
+
+// Checkout converts the user's active cart into an order. The cart is
+// validated, product prices are snapshotted, promotions are applied, the
+// cart's coupon is redeemed, shipping and tax are charged, stock is
+// decremented and the cart is deactivated inside a single transaction.
+func (r *orderRepo) Checkout(userID int64, opts CheckoutOptions) (*domain.Order, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var cartID int64
+	var currency, couponCode string
+	err = tx.QueryRow(
+		"SELECT ID, Currency, CouponCode FROM Cart WHERE UserID = ? AND IsActive = true FOR UPDATE", userID,
+	).Scan(&cartID, &currency, &couponCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query("SELECT ProductID, Quantity FROM CartItem WHERE CartID = ?", cartID)
+	if err != nil {
+		return nil, err
+	}
+	var items []domain.OrderItem
+	for rows.Next() {
+		var item domain.OrderItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	var shipTo, billTo *domain.Address
+	if opts.ShippingAddressID != 0 {
+		shipTo, err = loadAddress(tx, userID, opts.ShippingAddressID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.BillingAddressID != 0 {
+		billTo, err = loadAddress(tx, userID, opts.BillingAddressID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Lock each product, snapshot its current price in the cart currency
+	// and check it is still sold and in stock
+	prices := &currencyRepo{db: r.db}
+	total := domain.NewMoney(0, currency)
+	taxClasses := make(map[int64]string, len(items))
+	weight := 0
+	for i := range items {
+		product, err := scanProduct(tx.QueryRow(
+			"SELECT "+productColumns+" FROM Product WHERE ID = ? FOR UPDATE", items[i].ProductID,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %d", ErrProductNotFound, items[i].ProductID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if product.IsArchived() {
+			return nil, fmt.Errorf("%w: %d", ErrProductArchived, items[i].ProductID)
+		}
+		if product.Stock < items[i].Quantity {
+			return nil, fmt.Errorf("%w for product %d", ErrInsufficientStock, items[i].ProductID)
+		}
+
+		items[i].ProductName = product.Name
+		taxClasses[product.ID] = product.TaxClass
+		items[i].Price, err = prices.PriceIn(product, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		total = total.Add(items[i].Price.Mul(int64(items[i].Quantity)))
+		weight += product.WeightGrams * items[i].Quantity
+	}
+
+	cart := &domain.Cart{ID: cartID, UserID: userID, Currency: currency}
+	for _, item := range items {
+		cart.Items = append(cart.Items, domain.CartItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			TaxClass:  taxClasses[item.ProductID],
+		})
+	}
+
+	// Apply promotions to the snapshotted prices
+	promotions, err := evaluatePromotions(tx, r.db, cart)
+	if err != nil {
+		return nil, err
+	}
+	discount := promotions.Total
+
+	// Re-evaluate the coupon on top of the promotions. Locking the coupon
+	// row serializes concurrent checkouts against its usage limits.
+	var coupon *domain.Coupon
+	couponDiscount := domain.NewMoney(0, currency)
+	if couponCode != "" {
+		coupon, err = loadCoupon(tx, "SELECT "+couponColumns+" FROM Coupon WHERE Code = ? FOR UPDATE", couponCode)
+		if err != nil {
+			return nil, err
+		}
+
+		couponDiscount, err = evaluateCoupon(tx, r.db, coupon, cart, promotions.LineDiscounts)
+		if err != nil {
+			return nil, fmt.Errorf("coupon %s: %w", couponCode, err)
+		}
+		discount = discount.Add(couponDiscount)
+	}
+
+	// Price the chosen shipping method; a free shipping coupon waives it
+	shippingMethod := ""
+	shippingAmount := domain.NewMoney(0, currency)
+	redeemed := couponDiscount
+	if shipTo != nil {
+		options, err := opts.Shipping.Rates(
+			shipping.Destination{Country: shipTo.Country, Region: shipTo.Region, PostalCode: shipTo.PostalCode},
+			shipping.Parcel{WeightGrams: weight, Value: total.Sub(discount)},
+		)
+		if err != nil {
+			return nil, err
+		}
+		method := opts.ShippingMethod
+		if method == "" && len(options) > 0 {
+			method = options[0].Code
+		}
+		option, err := shipping.FindOption(options, method)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", err, method)
+		}
+		shippingMethod = option.Code
+		shippingAmount = option.Price
+		if coupon != nil && coupon.Type == domain.CouponTypeFreeShipping {
+			redeemed = shippingAmount
+			shippingAmount = domain.NewMoney(0, currency)
+		}
+	}
+
+	// Spread the coupon over the lines and tax what the customer pays for
+	// each of them
+	lines := tax.CartLines(cart.Items, promotions.LineDiscounts, couponDiscount)
+	location := opts.TaxLocation.Normalize()
+	if shipTo != nil {
+		location = tax.Location{Country: shipTo.Country, Region: shipTo.Region}
+	}
+	taxes, err := opts.Taxes.Calculate(location, lines)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].DiscountAmount = items[i].Price.Mul(int64(items[i].Quantity)).Sub(lines[i].Amount)
+		items[i].TaxAmount = domain.NewMoney(0, currency).Add(taxes.Lines[i].Amount)
+		items[i].TaxRate = tax.FormatRate(taxes.Lines[i].Rate)
+	}
+
+	order := &domain.Order{
+		UserID:           userID,
+		CartID:           cartID,
+		Currency:         currency,
+		TotalAmount:      total.Sub(discount),
+		DiscountAmount:   discount,
+		CouponCode:       couponCode,
+		TaxAmount:        domain.NewMoney(0, currency).Add(taxes.Total),
+		PricesIncludeTax: taxes.PricesIncludeTax,
+		TaxCountry:       location.Country,
+		TaxRegion:        location.Region,
+		ShippingMethod:   shippingMethod,
+		ShippingAmount:   shippingAmount,
+		ShippingAddress:  shipTo,
+		BillingAddress:   billTo,
+		Status:           domain.OrderStatusPending,
+	}
+	if !order.PricesIncludeTax {
+		order.TotalAmount = order.TotalAmount.Add(order.TaxAmount)
+	}
+	order.TotalAmount = order.TotalAmount.Add(order.ShippingAmount)
+
+	result, err := tx.Exec(
+		`INSERT INTO Orders (UserID, CartID, Currency, TotalAmount, DiscountAmount, CouponCode, TaxAmount, PricesIncludeTax, TaxCountry, TaxRegion,
+		 ShippingMethod, ShippingAmount, Status)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		order.UserID, order.CartID, order.Currency, order.TotalAmount, order.DiscountAmount, order.CouponCode,
+		order.TaxAmount, order.PricesIncludeTax, order.TaxCountry, order.TaxRegion, order.ShippingMethod,
+		order.ShippingAmount, order.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	order.ID, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if coupon != nil {
+		_, err = tx.Exec(
+			"INSERT INTO CouponRedemption (CouponID, UserID, OrderID, Amount, Currency) VALUES (?, ?, ?, ?, ?)",
+			coupon.ID, userID, order.ID, redeemed, currency,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Snapshot the addresses so later address book edits leave the order alone
+	for addressType, address := range map[domain.AddressType]*domain.Address{
+		domain.AddressTypeShipping: shipTo,
+		domain.AddressTypeBilling:  billTo,
+	} {
+		if address == nil {
+			continue
+		}
+		_, err = tx.Exec(
+			"INSERT INTO OrderAddress (OrderID, "+orderAddressColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			order.ID, addressType, address.Name, address.Line1, address.Line2, address.City, address.Region,
+			address.PostalCode, address.Country, address.Phone,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range items {
+		items[i].OrderID = order.ID
+		result, err := tx.Exec(
+			"INSERT INTO OrderItem (OrderID, ProductID, ProductName, Quantity, Price, DiscountAmount, TaxAmount, TaxRate) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			items[i].OrderID, items[i].ProductID, items[i].ProductName, items[i].Quantity, items[i].Price, items[i].DiscountAmount,
+			items[i].TaxAmount, items[i].TaxRate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items[i].ID, err = result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("UPDATE Product SET Stock = Stock - ?, Version = Version + 1 WHERE ID = ?", items[i].Quantity, items[i].ProductID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE Cart SET IsActive = false WHERE ID = ?", cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertOrderStatusChange(tx, order.ID, "", order.Status, userID, "order placed")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// Read back the order so CreatedAt reflects the database value
+	return r.GetOrderByID(order.ID)
+}
+
+// GetOrderByID retrieves an order with its items
+func (r *orderRepo) GetOrderByID(id int64) (*domain.Order, error) {
+	order, err := scanOrder(r.db.QueryRow("SELECT "+orderColumns+" FROM Orders WHERE ID = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	order.Items, err = r.getOrderItems(order.ID, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadOrderAddresses(order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrdersByUserID retrieves all orders of a user, newest first
+func (r *orderRepo) GetOrdersByUserID(userID int64) ([]*domain.Order, error) {
+	query := "SELECT " + orderColumns + " FROM Orders WHERE UserID = ? ORDER BY CreatedAt DESC, ID DESC"
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, order := range orders {
+		order.Items, err = r.getOrderItems(order.ID, order.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.loadOrderAddresses(order); err != nil {
+			return nil, err
+		}
+	}
+
+	return orders, nil
+}
+
+// UpdateOrderStatus moves an order to a new status, rejecting transitions
+// not allowed by the order lifecycle, and records the change in the history.
+// Paid and refunded follow from the order's payments, so they cannot be set
+// here. Cancelling puts the items back in stock, and is refused while a
+// payment is authorized or captured.
+func (r *orderRepo) UpdateOrderStatus(orderID int64, status domain.OrderStatus, changedBy int64, note string) (*domain.Order, error) {
+	if status == domain.OrderStatusPaid || status == domain.OrderStatusRefunded {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentStatus, status)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := transitionOrder(tx, orderID, status, changedBy, note); err != nil {
+		return nil, err
+	}
+
+	if status == domain.OrderStatusCancelled {
+		if err := cancelOrder(tx, orderID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetOrderByID(orderID)
+}
+
+// GetOrderHistory retrieves the status changes of an order, oldest first
+func (r *orderRepo) GetOrderHistory(orderID int64) ([]*domain.OrderStatusChange, error) {
+	var exists int64
+	err := r.db.QueryRow("SELECT ID FROM Orders WHERE ID = ?", orderID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT ID, OrderID, FromStatus, ToStatus, ChangedBy, Note, ChangedAt FROM OrderStatusHistory WHERE OrderID = ? ORDER BY ChangedAt, ID"
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]*domain.OrderStatusChange, 0)
+	for rows.Next() {
+		change := &domain.OrderStatusChange{}
+		err := rows.Scan(&change.ID, &change.OrderID, &change.FromStatus, &change.ToStatus, &change.ChangedBy, &change.Note, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// transitionOrder locks the order row, validates the move against the
+// lifecycle and applies it within tx. It is shared by every repository that
+// changes order status so the history is always written.
+func transitionOrder(tx *sql.Tx, orderID int64, status domain.OrderStatus, changedBy int64, note string) error {
+	if !status.IsValid() {
+		return fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+
+	var current domain.OrderStatus
+	err := tx.QueryRow("SELECT Status FROM Orders WHERE ID = ? FOR UPDATE", orderID).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if !current.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current, status)
+	}
+
+	_, err = tx.Exec("UPDATE Orders SET Status = ? WHERE ID = ?", status, orderID)
+	if err != nil {
+		return err
+	}
+
+	return insertOrderStatusChange(tx, orderID, current, status, changedBy, note)
+}
+
+// cancelOrder checks an order locked in tx holds no money, puts its items
+// back in stock and releases its coupon redemption so the code can be used again
+func cancelOrder(tx *sql.Tx, orderID int64) error {
+	var paid bool
+	err := tx.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM Payment WHERE OrderID = ? AND Status IN (?, ?, ?))",
+		orderID, domain.PaymentStatusAuthorized, domain.PaymentStatusCaptured, domain.PaymentStatusPartiallyRefunded,
+	).Scan(&paid)
+	if err != nil {
+		return err
+	}
+	if paid {
+		return ErrOrderHasPayment
+	}
+
+	_, err = tx.Exec(
+		`UPDATE Product p
+		 JOIN (SELECT ProductID, SUM(Quantity) AS Quantity FROM OrderItem WHERE OrderID = ? GROUP BY ProductID) oi ON oi.ProductID = p.ID
+		 SET p.Stock = p.Stock + oi.Quantity, p.Version = p.Version + 1`,
+		orderID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM CouponRedemption WHERE OrderID = ?", orderID)
+	return err
+}
+
+// insertOrderStatusChange appends a row to the order status history
+func insertOrderStatusChange(tx *sql.Tx, orderID int64, from, to domain.OrderStatus, changedBy int64, note string) error {
+	_, err := tx.Exec(
+		"INSERT INTO OrderStatusHistory (OrderID, FromStatus, ToStatus, ChangedBy, Note) VALUES (?, ?, ?, ?, ?)",
+		orderID, from, to, changedBy, note,
+	)
+	return err
+}
+
+// getOrderItems retrieves all items of an order priced in currency
+func (r *orderRepo) getOrderItems(orderID int64, currency string) ([]domain.OrderItem, error) {
+	query := "SELECT ID, OrderID, ProductID, ProductName, Quantity, Price, DiscountAmount, TaxAmount, TaxRate, ReturnedQuantity FROM OrderItem WHERE OrderID = ?"
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]domain.OrderItem, 0)
+	for rows.Next() {
+		var item domain.OrderItem
+		var price, discount, taxAmount, taxRate string
+		err := rows.Scan(
+			&item.ID, &item.OrderID, &item.ProductID, &item.ProductName, &item.Quantity, &price, &discount, &taxAmount,
+			&taxRate, &item.ReturnedQuantity,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.Price, err = domain.ParseMoney(price, currency)
+		if err != nil {
+			return nil, err
+		}
+		item.DiscountAmount, err = domain.ParseMoney(discount, currency)
+		if err != nil {
+			return nil, err
+		}
+		item.TaxAmount, err = domain.ParseMoney(taxAmount, currency)
+		if err != nil {
+			return nil, err
+		}
+		item.TaxRate, err = normalizeRate(taxRate)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// loadOrderAddresses reads the shipping and billing addresses of an order
+func (r *orderRepo) loadOrderAddresses(order *domain.Order) error {
+	rows, err := r.db.Query("SELECT "+orderAddressColumns+" FROM OrderAddress WHERE OrderID = ?", order.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		address := &domain.Address{UserID: order.UserID}
+		var addressType domain.AddressType
+		err := rows.Scan(
+			&addressType, &address.Name, &address.Line1, &address.Line2, &address.City, &address.Region,
+			&address.PostalCode, &address.Country, &address.Phone,
+		)
+		if err != nil {
+			return err
+		}
+
+		switch addressType {
+		case domain.AddressTypeShipping:
+			order.ShippingAddress = address
+		case domain.AddressTypeBilling:
+			order.BillingAddress = address
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanOrder reads an order row selected with orderColumns
+func scanOrder(row rowScanner) (*domain.Order, error) {
+	order := &domain.Order{}
+	var total, discount, taxAmount, shippingAmount, refunded string
+	err := row.Scan(
+		&order.ID, &order.UserID, &order.CartID, &order.Currency, &total, &discount, &order.CouponCode,
+		&taxAmount, &order.PricesIncludeTax, &order.TaxCountry, &order.TaxRegion, &order.ShippingMethod,
+		&shippingAmount, &refunded, &order.Status, &order.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	order.TotalAmount, err = domain.ParseMoney(total, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+	order.DiscountAmount, err = domain.ParseMoney(discount, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+	order.TaxAmount, err = domain.ParseMoney(taxAmount, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+	order.ShippingAmount, err = domain.ParseMoney(shippingAmount, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+	order.RefundedAmount, err = domain.ParseMoney(refunded, order.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// normalizeRate trims the padding of a DECIMAL rate column, so "20.0000"
+// reads as "20"
+func normalizeRate(s string) (string, error) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", fmt.Errorf("invalid tax rate %q", s)
+	}
+	return tax.FormatRate(rate), nil
+}
@@ -0,0 +1,445 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrPaymentNotFound          = errors.New("payment not found")
+	ErrDuplicatePayment         = errors.New("payment with this idempotency key already exists")
+	ErrOrderNotAwaitingPayment  = errors.New("order is not awaiting payment")
+	ErrOrderPaymentInProgress   = errors.New("order already has a live payment")
+	ErrInvalidPaymentTransition = errors.New("invalid payment status transition")
+	ErrRefundExceedsPayment     = errors.New("refund amount exceeds captured amount")
+	ErrRefundNotFound           = errors.New("refund not found")
+	ErrRefundNotPending         = errors.New("refund is not pending")
+)
+
+// PaymentRepository defines operations for Payment
+type PaymentRepository interface {
+	CreatePayment(payment *domain.Payment) (*domain.Payment, error)
+	GetPaymentByID(id int64) (*domain.Payment, error)
+	GetPaymentByIdempotencyKey(key string) (*domain.Payment, error)
+	GetPaymentByProviderRef(provider, ref string) (*domain.Payment, error)
+	GetPaymentsByOrderID(orderID int64) ([]*domain.Payment, error)
+	UpdatePaymentStatus(id int64, status domain.PaymentStatus, providerRef, message string) (*domain.Payment, error)
+	ReserveRefund(id int64, amount domain.Money) (*domain.PaymentRefund, error)
+	ConfirmRefund(refundID int64) (*domain.Payment, error)
+	ReleaseRefund(refundID int64) error
+	ApplyWebhookEvent(provider, eventID string, id int64, status domain.PaymentStatus, providerRef, message string) (bool, error)
+}
+
+// paymentRepo is the concrete implementation
+type paymentRepo struct {
+	db Repository
+}
+
+// NewPaymentRepository creates a new PaymentRepository
+func NewPaymentRepository(db Repository) PaymentRepository {
+	return &paymentRepo{db: db}
+}
+
+const paymentColumns = "ID, OrderID, Provider, ProviderRef, IdempotencyKey, Amount, RefundedAmount, Currency, Status, Message, CreatedAt, UpdatedAt"
+
+const paymentRefundColumns = "ID, PaymentID, IdempotencyKey, Amount, Currency, Status, CreatedAt"
+
+// CreatePayment stores a new payment intent for a pending order. The order
+// is locked while its payments are checked, so it never has more than one
+// live payment; a concurrent duplicate of the idempotency key fails with
+// ErrDuplicatePayment.
+func (r *paymentRepo) CreatePayment(payment *domain.Payment) (*domain.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status domain.OrderStatus
+	err = tx.QueryRow("SELECT Status FROM Orders WHERE ID = ? FOR UPDATE", payment.OrderID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicate, live bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM Payment WHERE IdempotencyKey = ?),
+		        EXISTS (SELECT 1 FROM Payment WHERE OrderID = ? AND Status IN (?, ?, ?, ?))`,
+		payment.IdempotencyKey, payment.OrderID,
+		domain.PaymentStatusPending, domain.PaymentStatusAuthorized, domain.PaymentStatusCaptured, domain.PaymentStatusPartiallyRefunded,
+	).Scan(&duplicate, &live)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		return nil, ErrDuplicatePayment
+	}
+	if live {
+		return nil, ErrOrderPaymentInProgress
+	}
+	if status != domain.OrderStatusPending {
+		return nil, ErrOrderNotAwaitingPayment
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO Payment (OrderID, Provider, ProviderRef, IdempotencyKey, Amount, RefundedAmount, Currency, Status, Message) VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?)",
+		payment.OrderID, payment.Provider, payment.ProviderRef, payment.IdempotencyKey,
+		payment.Amount, payment.Currency, payment.Status, payment.Message,
+	)
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicatePayment
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetPaymentByID(id)
+}
+
+// GetPaymentByID retrieves a payment by its ID
+func (r *paymentRepo) GetPaymentByID(id int64) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow("SELECT "+paymentColumns+" FROM Payment WHERE ID = ?", id))
+}
+
+// GetPaymentByIdempotencyKey retrieves a payment by its idempotency key
+func (r *paymentRepo) GetPaymentByIdempotencyKey(key string) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow("SELECT "+paymentColumns+" FROM Payment WHERE IdempotencyKey = ?", key))
+}
+
+// GetPaymentByProviderRef retrieves a payment by the provider's reference
+func (r *paymentRepo) GetPaymentByProviderRef(provider, ref string) (*domain.Payment, error) {
+	return scanPayment(r.db.QueryRow("SELECT "+paymentColumns+" FROM Payment WHERE Provider = ? AND ProviderRef = ?", provider, ref))
+}
+
+// GetPaymentsByOrderID retrieves all payments of an order
+func (r *paymentRepo) GetPaymentsByOrderID(orderID int64) ([]*domain.Payment, error) {
+	rows, err := r.db.Query("SELECT "+paymentColumns+" FROM Payment WHERE OrderID = ? ORDER BY ID", orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make([]*domain.Payment, 0)
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// UpdatePaymentStatus moves a payment to a new status. Capturing a payment
+// marks its order as paid in the same transaction.
+func (r *paymentRepo) UpdatePaymentStatus(id int64, status domain.PaymentStatus, providerRef, message string) (*domain.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := updatePaymentStatus(tx, id, status, providerRef, message); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetPaymentByID(id)
+}
+
+// ReserveRefund reserves a refund of amount against a captured payment, or
+// of its remaining balance when amount is zero, before the payment provider
+// is called. The payment stays locked while the refunds still pending are
+// counted, so concurrent reservations never exceed the captured amount. Each
+// refund gets its own idempotency key for the provider.
+func (r *paymentRepo) ReserveRefund(id int64, amount domain.Money) (*domain.PaymentRefund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	payment, err := scanPayment(tx.QueryRow("SELECT "+paymentColumns+" FROM Payment WHERE ID = ? FOR UPDATE", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if !payment.Status.CanTransitionTo(domain.PaymentStatusPartiallyRefunded) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentTransition, payment.Status, domain.PaymentStatusPartiallyRefunded)
+	}
+
+	var pending string
+	var count int
+	err = tx.QueryRow(
+		"SELECT COALESCE(SUM(CASE WHEN Status = ? THEN Amount ELSE 0 END), 0), COUNT(*) FROM PaymentRefund WHERE PaymentID = ?",
+		domain.PaymentRefundStatusPending, id,
+	).Scan(&pending, &count)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := domain.ParseMoney(pending, payment.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := payment.Amount.Sub(payment.RefundedAmount).Sub(reserved)
+	if amount.IsZero() {
+		amount = remaining
+	}
+	if !amount.IsPositive() || amount.Currency != payment.Currency || amount.Cmp(remaining) > 0 {
+		return nil, ErrRefundExceedsPayment
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO PaymentRefund (PaymentID, IdempotencyKey, Amount, Currency, Status) VALUES (?, ?, ?, ?, ?)",
+		id, fmt.Sprintf("payment-%d-refund-%d", id, count+1), amount, amount.Currency, domain.PaymentRefundStatusPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	refundID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return scanPaymentRefund(r.db.QueryRow("SELECT "+paymentRefundColumns+" FROM PaymentRefund WHERE ID = ?", refundID))
+}
+
+// ConfirmRefund applies a pending refund to its payment once the payment
+// provider has returned the money. Once the payment is fully refunded its
+// order is marked as refunded.
+func (r *paymentRepo) ConfirmRefund(refundID int64) (*domain.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	refund, err := lockPaymentRefund(tx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addPaymentRefund(tx, refund.PaymentID, refund.Amount); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE PaymentRefund SET Status = ? WHERE ID = ?", domain.PaymentRefundStatusCompleted, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetPaymentByID(refund.PaymentID)
+}
+
+// ReleaseRefund gives a pending refund's amount back to its payment after
+// the payment provider refused it. The refund is kept, released, so its
+// idempotency key is never reused.
+func (r *paymentRepo) ReleaseRefund(refundID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPaymentRefund(tx, refundID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE PaymentRefund SET Status = ? WHERE ID = ?", domain.PaymentRefundStatusReleased, refundID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addPaymentRefund applies a refund to a payment within tx, moving its order
+// to refunded once the payment is fully refunded
+func addPaymentRefund(tx *sql.Tx, id int64, amount domain.Money) error {
+	payment, err := scanPayment(tx.QueryRow("SELECT "+paymentColumns+" FROM Payment WHERE ID = ? FOR UPDATE", id))
+	if err != nil {
+		return err
+	}
+
+	if !amount.IsPositive() || amount.Currency != payment.Currency {
+		return ErrRefundExceedsPayment
+	}
+	refunded := payment.RefundedAmount.Add(amount)
+	if refunded.Cmp(payment.Amount) > 0 {
+		return ErrRefundExceedsPayment
+	}
+
+	status := domain.PaymentStatusPartiallyRefunded
+	if refunded.Cmp(payment.Amount) >= 0 {
+		status = domain.PaymentStatusRefunded
+	}
+
+	if !payment.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentTransition, payment.Status, status)
+	}
+
+	_, err = tx.Exec("UPDATE Payment SET Status = ?, RefundedAmount = ? WHERE ID = ?", status, refunded, id)
+	if err != nil {
+		return err
+	}
+
+	if status == domain.PaymentStatusRefunded {
+		err := transitionOrder(tx, payment.OrderID, domain.OrderStatusRefunded, 0, "payment refunded")
+		if err != nil && !errors.Is(err, ErrInvalidTransition) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyWebhookEvent records a webhook event and applies its status update
+// in one transaction. It returns false without changing anything if the
+// event was already processed. An out-of-order event is still recorded, so
+// ErrInvalidPaymentTransition is returned alongside true.
+func (r *paymentRepo) ApplyWebhookEvent(provider, eventID string, id int64, status domain.PaymentStatus, providerRef, message string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO PaymentWebhookEvent (Provider, EventID) VALUES (?, ?)", provider, eventID)
+	if isDuplicateKey(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	applyErr := updatePaymentStatus(tx, id, status, providerRef, message)
+	if applyErr != nil && !errors.Is(applyErr, ErrInvalidPaymentTransition) {
+		return false, applyErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, applyErr
+}
+
+// updatePaymentStatus applies a payment status change within tx
+func updatePaymentStatus(tx *sql.Tx, id int64, status domain.PaymentStatus, providerRef, message string) error {
+	payment, err := scanPayment(tx.QueryRow("SELECT "+paymentColumns+" FROM Payment WHERE ID = ? FOR UPDATE", id))
+	if err != nil {
+		return err
+	}
+
+	if payment.Status != status {
+		if !payment.Status.CanTransitionTo(status) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentTransition, payment.Status, status)
+		}
+
+		if status == domain.PaymentStatusCaptured {
+			err := transitionOrder(tx, payment.OrderID, domain.OrderStatusPaid, 0, "payment captured")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if providerRef == "" {
+		providerRef = payment.ProviderRef
+	}
+
+	_, err = tx.Exec(
+		"UPDATE Payment SET Status = ?, ProviderRef = ?, Message = ? WHERE ID = ?",
+		status, providerRef, message, id,
+	)
+	return err
+}
+
+// lockPaymentRefund locks a refund row within tx and checks it is still pending
+func lockPaymentRefund(tx *sql.Tx, id int64) (*domain.PaymentRefund, error) {
+	refund, err := scanPaymentRefund(tx.QueryRow("SELECT "+paymentRefundColumns+" FROM PaymentRefund WHERE ID = ? FOR UPDATE", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.Status != domain.PaymentRefundStatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrRefundNotPending, refund.Status)
+	}
+	return refund, nil
+}
+
+// scanPaymentRefund reads a refund from a row selected with paymentRefundColumns
+func scanPaymentRefund(row rowScanner) (*domain.PaymentRefund, error) {
+	refund := &domain.PaymentRefund{}
+	var amount, currency string
+	err := row.Scan(&refund.ID, &refund.PaymentID, &refund.IdempotencyKey, &amount, &currency, &refund.Status, &refund.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refund.Amount, err = domain.ParseMoney(amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// scanPayment reads a payment from a row selected with paymentColumns
+func scanPayment(row rowScanner) (*domain.Payment, error) {
+	p := &domain.Payment{}
+	var amount, refunded string
+	err := row.Scan(
+		&p.ID, &p.OrderID, &p.Provider, &p.ProviderRef, &p.IdempotencyKey, &amount,
+		&refunded, &p.Currency, &p.Status, &p.Message, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.Amount, err = domain.ParseMoney(amount, p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	p.RefundedAmount, err = domain.ParseMoney(refunded, p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
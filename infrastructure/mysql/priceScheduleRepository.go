@@ -0,0 +1,338 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var (
+	ErrScheduleNotFound   = errors.New("price schedule not found")
+	ErrScheduleOverlap    = errors.New("price schedule overlaps another scheduled price for this product")
+	ErrScheduleNotPending = errors.New("price schedule has already started")
+)
+
+const priceScheduleColumns = "ID, ProductID, Price, Currency, StartsAt, EndsAt, RevertPrice, Status, CreatedBy, CreatedAt"
+
+// PriceScheduleRepository defines operations for scheduled price changes
+type PriceScheduleRepository interface {
+	CreateSchedule(schedule *domain.PriceSchedule) (*domain.PriceSchedule, error)
+	GetSchedule(id int64) (*domain.PriceSchedule, error)
+	GetSchedules(productID int64) ([]*domain.PriceSchedule, error)
+	CancelSchedule(id int64) (*domain.PriceSchedule, error)
+	ApplyDueSchedules() (started, ended int, err error)
+}
+
+// priceScheduleRepo is the concrete implementation
+type priceScheduleRepo struct {
+	db Repository
+}
+
+// NewPriceScheduleRepository creates a new PriceScheduleRepository
+func NewPriceScheduleRepository(db Repository) PriceScheduleRepository {
+	return &priceScheduleRepo{db: db}
+}
+
+// CreateSchedule adds a pending price change. The price must be in the
+// product's currency, and the schedule must not overlap another pending or
+// active schedule of the product; a schedule without an end occupies just
+// its start.
+func (r *priceScheduleRepo) CreateSchedule(schedule *domain.PriceSchedule) (*domain.PriceSchedule, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	product, err := scanProduct(tx.QueryRow("SELECT "+productColumns+" FROM Product WHERE ID = ? FOR UPDATE", schedule.ProductID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if product.IsArchived() {
+		return nil, ErrProductArchived
+	}
+	if schedule.Price.Currency != product.Price.Currency {
+		return nil, fmt.Errorf("%w: product is priced in %s", domain.ErrCurrencyMismatch, product.Price.Currency)
+	}
+
+	end := schedule.StartsAt
+	if schedule.EndsAt != nil {
+		end = *schedule.EndsAt
+	}
+	var overlaps bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM PriceSchedule
+		 WHERE ProductID = ? AND Status IN (?, ?) AND StartsAt < ? AND COALESCE(EndsAt, StartsAt) > ?)`,
+		schedule.ProductID, string(domain.PriceSchedulePending), string(domain.PriceScheduleActive), end, schedule.StartsAt,
+	).Scan(&overlaps)
+	if err != nil {
+		return nil, err
+	}
+	if overlaps {
+		return nil, ErrScheduleOverlap
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO PriceSchedule (ProductID, Price, Currency, StartsAt, EndsAt, Status, CreatedBy) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		schedule.ProductID, schedule.Price, schedule.Price.Currency, schedule.StartsAt, schedule.EndsAt,
+		string(domain.PriceSchedulePending), schedule.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetSchedule(id)
+}
+
+// GetSchedule retrieves a price schedule by its ID
+func (r *priceScheduleRepo) GetSchedule(id int64) (*domain.PriceSchedule, error) {
+	schedule, err := scanPriceSchedule(r.db.QueryRow("SELECT "+priceScheduleColumns+" FROM PriceSchedule WHERE ID = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, err
+}
+
+// GetSchedules retrieves every price schedule of a product by start
+func (r *priceScheduleRepo) GetSchedules(productID int64) ([]*domain.PriceSchedule, error) {
+	rows, err := r.db.Query("SELECT "+priceScheduleColumns+" FROM PriceSchedule WHERE ProductID = ? ORDER BY StartsAt, ID", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*domain.PriceSchedule, 0)
+	for rows.Next() {
+		schedule, err := scanPriceSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// CancelSchedule cancels a price schedule that has not started yet
+func (r *priceScheduleRepo) CancelSchedule(id int64) (*domain.PriceSchedule, error) {
+	result, err := r.db.Exec(
+		"UPDATE PriceSchedule SET Status = ? WHERE ID = ? AND Status = ?",
+		string(domain.PriceScheduleCancelled), id, string(domain.PriceSchedulePending),
+	)
+	if err != nil {
+		return nil, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := r.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrScheduleNotPending
+	}
+	return schedule, nil
+}
+
+// ApplyDueSchedules starts the pending schedules whose start has passed,
+// then ends the active sales whose end has passed, so a sale missed
+// entirely is started and ended in one run. Each schedule is applied in its
+// own transaction; one that fails is logged and retried on the next run.
+func (r *priceScheduleRepo) ApplyDueSchedules() (started, ended int, err error) {
+	startIDs, err := r.dueScheduleIDs("Status = ? AND StartsAt <= NOW()", domain.PriceSchedulePending)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, id := range startIDs {
+		if err := r.startSchedule(id); err != nil {
+			log.Printf("price schedule %d: start: %v", id, err)
+			continue
+		}
+		started++
+	}
+
+	endIDs, err := r.dueScheduleIDs("Status = ? AND EndsAt <= NOW()", domain.PriceScheduleActive)
+	if err != nil {
+		return started, 0, err
+	}
+	for _, id := range endIDs {
+		if err := r.endSchedule(id); err != nil {
+			log.Printf("price schedule %d: end: %v", id, err)
+			continue
+		}
+		ended++
+	}
+	return started, ended, nil
+}
+
+// dueScheduleIDs lists the schedules in status matching condition, earliest
+// first
+func (r *priceScheduleRepo) dueScheduleIDs(condition string, status domain.PriceScheduleStatus) ([]int64, error) {
+	rows, err := r.db.Query("SELECT ID FROM PriceSchedule WHERE "+condition+" ORDER BY StartsAt, ID", string(status))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// startSchedule sets the product's price to the scheduled one. A sale keeps
+// the price it replaces to return to at its end; a permanent change is
+// complete at once. Schedules for archived products or products now priced
+// in another currency are cancelled.
+func (r *priceScheduleRepo) startSchedule(id int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schedule, err := lockSchedule(tx, id, domain.PriceSchedulePending)
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	product, err := scanProduct(tx.QueryRow("SELECT "+productColumns+" FROM Product WHERE ID = ? FOR UPDATE", schedule.ProductID))
+	if err != nil {
+		return err
+	}
+	if product.IsArchived() || product.Price.Currency != schedule.Price.Currency {
+		if err := setScheduleStatus(tx, id, domain.PriceScheduleCancelled, nil); err != nil {
+			return err
+		}
+		log.Printf("price schedule %d: cancelled, product %d is archived or no longer priced in %s", id, product.ID, schedule.Price.Currency)
+		return tx.Commit()
+	}
+
+	if err := setScheduledPrice(tx, schedule, product.Price, schedule.Price); err != nil {
+		return err
+	}
+
+	status, revert := domain.PriceScheduleCompleted, (*domain.Money)(nil)
+	if schedule.EndsAt != nil {
+		status, revert = domain.PriceScheduleActive, &product.Price
+	}
+	if err := setScheduleStatus(tx, id, status, revert); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// endSchedule returns the product to its price from before the sale, unless
+// the price was changed since the sale started
+func (r *priceScheduleRepo) endSchedule(id int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schedule, err := lockSchedule(tx, id, domain.PriceScheduleActive)
+	if err != nil || schedule == nil {
+		return err
+	}
+
+	current, err := lockProductPrice(tx, schedule.ProductID)
+	if err != nil {
+		return err
+	}
+	if current == schedule.Price && schedule.RevertPrice != nil {
+		if err := setScheduledPrice(tx, schedule, current, *schedule.RevertPrice); err != nil {
+			return err
+		}
+	}
+
+	if err := setScheduleStatus(tx, id, domain.PriceScheduleCompleted, schedule.RevertPrice); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockSchedule locks a schedule for the rest of tx, returning nil if it is
+// no longer in status
+func lockSchedule(tx *sql.Tx, id int64, status domain.PriceScheduleStatus) (*domain.PriceSchedule, error) {
+	schedule, err := scanPriceSchedule(tx.QueryRow("SELECT "+priceScheduleColumns+" FROM PriceSchedule WHERE ID = ? FOR UPDATE", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil || schedule.Status != status {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// setScheduledPrice changes a product's price on behalf of a schedule and
+// records the change
+func setScheduledPrice(tx *sql.Tx, schedule *domain.PriceSchedule, from, to domain.Money) error {
+	_, err := tx.Exec("UPDATE Product SET Price = ?, Version = Version + 1 WHERE ID = ?", to, schedule.ProductID)
+	if err != nil {
+		return err
+	}
+	return recordPriceChange(tx, &domain.PriceChange{
+		ProductID:  schedule.ProductID,
+		OldPrice:   from,
+		NewPrice:   to,
+		Source:     domain.PriceChangeScheduled,
+		ScheduleID: schedule.ID,
+		ChangedBy:  schedule.CreatedBy,
+	})
+}
+
+// setScheduleStatus moves a schedule to status, keeping revert as the price
+// to return to
+func setScheduleStatus(tx *sql.Tx, id int64, status domain.PriceScheduleStatus, revert *domain.Money) error {
+	_, err := tx.Exec("UPDATE PriceSchedule SET Status = ?, RevertPrice = ? WHERE ID = ?", string(status), revert, id)
+	return err
+}
+
+// scanPriceSchedule reads a schedule selected with priceScheduleColumns
+func scanPriceSchedule(row rowScanner) (*domain.PriceSchedule, error) {
+	s := &domain.PriceSchedule{}
+	var price, currency, status string
+	var endsAt sql.NullTime
+	var revertPrice sql.NullString
+	err := row.Scan(&s.ID, &s.ProductID, &price, &currency, &s.StartsAt, &endsAt, &revertPrice, &status, &s.CreatedBy, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Price, err = domain.ParseMoney(price, currency)
+	if err != nil {
+		return nil, err
+	}
+	if endsAt.Valid {
+		s.EndsAt = &endsAt.Time
+	}
+	if revertPrice.Valid {
+		revert, err := domain.ParseMoney(revertPrice.String, currency)
+		if err != nil {
+			return nil, err
+		}
+		s.RevertPrice = &revert
+	}
+	s.Status = domain.PriceScheduleStatus(status)
+	return s, nil
+}
@@ -0,0 +1,285 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrImageNotFound     = errors.New("product image not found")
+	ErrInvalidImageOrder = errors.New("image order must list each of the product's images once")
+)
+
+const imageColumns = "ID, ProductID, BlobKey, ContentType, Width, Height, SizeBytes, AltText, Position, CreatedAt"
+
+// ProductImageRepository defines operations for product images and their
+// thumbnails. It records where the files are kept; the files themselves
+// live in blob storage.
+type ProductImageRepository interface {
+	AddImage(image *domain.ProductImage) (*domain.ProductImage, error)
+	GetImage(productID, imageID int64) (*domain.ProductImage, error)
+	GetImages(productID int64) ([]*domain.ProductImage, error)
+	GetImagesByProductIDs(productIDs []int64) (map[int64][]*domain.ProductImage, error)
+	ReorderImages(productID int64, imageIDs []int64) ([]*domain.ProductImage, error)
+	DeleteImage(productID, imageID int64) (*domain.ProductImage, error)
+}
+
+// productImageRepo is the concrete implementation
+type productImageRepo struct {
+	db Repository
+}
+
+// NewProductImageRepository creates a new ProductImageRepository
+func NewProductImageRepository(db Repository) ProductImageRepository {
+	return &productImageRepo{db: db}
+}
+
+// AddImage records an uploaded image and its thumbnails after the
+// product's other images
+func (r *productImageRepo) AddImage(image *domain.ProductImage) (*domain.ProductImage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the product so concurrent uploads get distinct positions
+	var productID int64
+	err = tx.QueryRow("SELECT ID FROM Product WHERE ID = ? FOR UPDATE", image.ProductID).Scan(&productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var position int
+	err = tx.QueryRow("SELECT COALESCE(MAX(Position) + 1, 0) FROM ProductImage WHERE ProductID = ?", image.ProductID).Scan(&position)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO ProductImage (ProductID, BlobKey, ContentType, Width, Height, SizeBytes, AltText, Position)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		image.ProductID, image.Key, image.ContentType, image.Width, image.Height, image.SizeBytes, image.AltText, position,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, thumbnail := range image.Thumbnails {
+		_, err := tx.Exec(
+			"INSERT INTO ProductImageThumbnail (ImageID, Size, BlobKey, Width, Height) VALUES (?, ?, ?, ?, ?)",
+			id, thumbnail.Size, thumbnail.Key, thumbnail.Width, thumbnail.Height,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetImage(image.ProductID, id)
+}
+
+// GetImage retrieves one of a product's images with its thumbnails
+func (r *productImageRepo) GetImage(productID, imageID int64) (*domain.ProductImage, error) {
+	images, err := r.queryImages(
+		"SELECT "+imageColumns+" FROM ProductImage WHERE ID = ? AND ProductID = ?", imageID, productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, ErrImageNotFound
+	}
+	return images[0], nil
+}
+
+// GetImages retrieves a product's images in display order
+func (r *productImageRepo) GetImages(productID int64) ([]*domain.ProductImage, error) {
+	return r.queryImages("SELECT "+imageColumns+" FROM ProductImage WHERE ProductID = ? ORDER BY Position, ID", productID)
+}
+
+// GetImagesByProductIDs retrieves the images of several products in display
+// order. Products without images are left out.
+func (r *productImageRepo) GetImagesByProductIDs(productIDs []int64) (map[int64][]*domain.ProductImage, error) {
+	byProduct := make(map[int64][]*domain.ProductImage)
+	if len(productIDs) == 0 {
+		return byProduct, nil
+	}
+
+	in, args := inClause(productIDs)
+	images, err := r.queryImages(
+		"SELECT "+imageColumns+" FROM ProductImage WHERE ProductID IN "+in+" ORDER BY ProductID, Position, ID", args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, image := range images {
+		byProduct[image.ProductID] = append(byProduct[image.ProductID], image)
+	}
+	return byProduct, nil
+}
+
+// ReorderImages sets the display order of a product's images. imageIDs must
+// list every image of the product exactly once.
+func (r *productImageRepo) ReorderImages(productID int64, imageIDs []int64) ([]*domain.ProductImage, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT ID FROM ProductImage WHERE ProductID = ? FOR UPDATE", productID)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(imageIDs) != len(current) {
+		return nil, ErrInvalidImageOrder
+	}
+	for position, id := range imageIDs {
+		if !current[id] {
+			return nil, fmt.Errorf("%w: image %d", ErrInvalidImageOrder, id)
+		}
+		// Remove as we go so duplicates are caught
+		delete(current, id)
+
+		if _, err := tx.Exec("UPDATE ProductImage SET Position = ? WHERE ID = ?", position, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetImages(productID)
+}
+
+// DeleteImage removes one of a product's images and its thumbnails, closing
+// the gap it leaves in the order. It returns the deleted image so its files
+// can be removed.
+func (r *productImageRepo) DeleteImage(productID, imageID int64) (*domain.ProductImage, error) {
+	image, err := r.GetImage(productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM ProductImageThumbnail WHERE ImageID = ?", imageID); err != nil {
+		return nil, err
+	}
+	result, err := tx.Exec("DELETE FROM ProductImage WHERE ID = ? AND ProductID = ?", imageID, productID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// Deleted concurrently
+		return nil, ErrImageNotFound
+	}
+
+	_, err = tx.Exec(
+		"UPDATE ProductImage SET Position = Position - 1 WHERE ProductID = ? AND Position > ?",
+		productID, image.Position,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// queryImages runs a query selecting imageColumns and loads the thumbnails
+// of the images found
+func (r *productImageRepo) queryImages(query string, args ...interface{}) ([]*domain.ProductImage, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := make([]*domain.ProductImage, 0)
+	byID := make(map[int64]*domain.ProductImage)
+	ids := make([]int64, 0)
+	for rows.Next() {
+		image := &domain.ProductImage{}
+		err := rows.Scan(
+			&image.ID, &image.ProductID, &image.Key, &image.ContentType, &image.Width, &image.Height,
+			&image.SizeBytes, &image.AltText, &image.Position, &image.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		image.Thumbnails = make([]domain.ImageThumbnail, 0)
+		images = append(images, image)
+		byID[image.ID] = image
+		ids = append(ids, image.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return images, nil
+	}
+
+	in, thumbArgs := inClause(ids)
+	thumbRows, err := r.db.Query(
+		"SELECT ImageID, Size, BlobKey, Width, Height FROM ProductImageThumbnail WHERE ImageID IN "+in+" ORDER BY ImageID, Width",
+		thumbArgs...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer thumbRows.Close()
+
+	for thumbRows.Next() {
+		var imageID int64
+		var thumbnail domain.ImageThumbnail
+		if err := thumbRows.Scan(&imageID, &thumbnail.Size, &thumbnail.Key, &thumbnail.Width, &thumbnail.Height); err != nil {
+			return nil, err
+		}
+		image := byID[imageID]
+		image.Thumbnails = append(image.Thumbnails, thumbnail)
+	}
+
+	return images, thumbRows.Err()
+}
@@ -4,17 +4,37 @@ import (
 	"database/sql"
 	"ecommerce-go/domain"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSKUExists          = errors.New("another product has this SKU")
+	ErrProductArchived    = errors.New("product is archived")
+	ErrProductNotArchived = errors.New("product is not archived")
 )
 
 // ProductRepository defines CRUD operations for Product
 type ProductRepository interface {
 	Create(product *domain.Product) (int64, error)
 	GetByID(id int64) (*domain.Product, error)
+	GetBySKU(sku string) (*domain.Product, error)
+	GetBySlug(slug string) (*domain.Product, error)
 	GetAll() ([]*domain.Product, error)
-	Update(product *domain.Product) error
-	Delete(id int64) error
+	GetArchived() ([]*domain.Product, error)
+	Update(product *domain.Product, changedBy int64, source domain.PriceChangeSource) error
+	Delete(id, version int64) error
+	Restore(id, version int64) error
+	PurgeArchived(olderThan time.Duration) ([]int64, error)
+	GetPriceHistory(productID int64) ([]*domain.PriceChange, error)
+	GenerateMissingSlugs() (int, error)
 }
 
+// productColumns is the column list read by scanProduct
+const productColumns = "ID, COALESCE(SKU, ''), COALESCE(Slug, ''), Name, Price, Currency, Description, Category, TaxClass, WeightGrams, Stock, Version, DeletedAt"
+
 // productRepo is the concrete implementation
 type productRepo struct {
 	db Repository
@@ -25,23 +45,61 @@ func NewProductRepository(db Repository) ProductRepository {
 	return &productRepo{db: db}
 }
 
-// Create inserts a new product into the database
+// Create inserts a new product into the database at version 1, with a
+// slug generated from its name
 func (r *productRepo) Create(product *domain.Product) (int64, error) {
-	result, err := r.db.Exec(
-		"INSERT INTO Product (Name, Price, Description) VALUES (?, ?, ?)",
-		product.Name, product.Price, product.Description,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO Product (SKU, Name, Price, Currency, Description, Category, TaxClass, WeightGrams, Stock)
+		 VALUES (NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?)`,
+		product.SKU, product.Name, product.Price, product.Price.Currency, product.Description, product.Category,
+		product.TaxClass, product.WeightGrams, product.Stock,
 	)
+	if isDuplicateKey(err) {
+		return 0, fmt.Errorf("%w: %s", ErrSKUExists, product.SKU)
+	}
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+
+	slug, err := assignSlug(tx, id, "", product.Name)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	product.Slug = slug
+	product.Version = 1
+	return id, nil
 }
 
-// GetByID retrieves a product by its ID
+// GetByID retrieves a product by its ID, including archived products
 func (r *productRepo) GetByID(id int64) (*domain.Product, error) {
-	row := r.db.QueryRow("SELECT ID, Name, Price, Description FROM Product WHERE ID = ?", id)
-	p := &domain.Product{}
-	err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Description)
+	row := r.db.QueryRow("SELECT "+productColumns+" FROM Product WHERE ID = ?", id)
+	p, err := scanProduct(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetBySKU retrieves a product by its SKU, including archived products
+func (r *productRepo) GetBySKU(sku string) (*domain.Product, error) {
+	row := r.db.QueryRow("SELECT "+productColumns+" FROM Product WHERE SKU = ?", sku)
+	p, err := scanProduct(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -51,9 +109,39 @@ func (r *productRepo) GetByID(id int64) (*domain.Product, error) {
 	return p, nil
 }
 
-// GetAll retrieves all products
+// GetBySlug retrieves a product by its current slug or, failing that, by a
+// slug it had before, including archived products. The product's Slug is
+// its current one either way.
+func (r *productRepo) GetBySlug(slug string) (*domain.Product, error) {
+	row := r.db.QueryRow(
+		`SELECT `+productColumns+` FROM Product
+		 WHERE Slug = ? OR ID = (SELECT ProductID FROM ProductSlugHistory WHERE Slug = ?)
+		 ORDER BY Slug = ? DESC LIMIT 1`,
+		slug, slug, slug,
+	)
+	p, err := scanProduct(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetAll retrieves all products that are not archived
 func (r *productRepo) GetAll() ([]*domain.Product, error) {
-	rows, err := r.db.Query("SELECT ID, Name, Price, Description FROM Product")
+	return r.queryProducts("SELECT " + productColumns + " FROM Product WHERE DeletedAt IS NULL")
+}
+
+// GetArchived retrieves the archived products, most recently archived first
+func (r *productRepo) GetArchived() ([]*domain.Product, error) {
+	return r.queryProducts("SELECT " + productColumns + " FROM Product WHERE DeletedAt IS NOT NULL ORDER BY DeletedAt DESC, ID DESC")
+}
+
+// queryProducts runs a query selecting productColumns
+func (r *productRepo) queryProducts(query string, args ...interface{}) ([]*domain.Product, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -61,27 +149,372 @@ func (r *productRepo) GetAll() ([]*domain.Product, error) {
 
 	var products []*domain.Product
 	for rows.Next() {
-		p := &domain.Product{}
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Description)
+		p, err := scanProduct(rows)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, p)
 	}
-	return products, nil
+	return products, rows.Err()
+}
+
+// Update modifies an existing product. Unless product.Version is zero the
+// product must still be at that version. On success product.Version is set
+// to the new version. A product without a SKU keeps its current one, as
+// does one with a Stock of domain.UnchangedStock, which is then set to the
+// current count. A
+// change of price is recorded in the price history as made by changedBy
+// through source. A change of name gives the product a new slug, and its
+// previous slug redirects to the new one.
+func (r *productRepo) Update(product *domain.Product, changedBy int64, source domain.PriceChangeSource) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	oldPrice, err := lockProductPrice(tx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(
+		`UPDATE Product SET SKU = COALESCE(NULLIF(?, ''), SKU), Name = ?, Price = ?, Currency = ?, Description = ?, Category = ?, TaxClass = ?,
+		 WeightGrams = ?, Stock = IF(? = ?, Stock, ?), Version = Version + 1
+		 WHERE ID = ? AND (? = 0 OR Version = ?)`,
+		product.SKU, product.Name, product.Price, product.Price.Currency, product.Description, product.Category,
+		product.TaxClass, product.WeightGrams, product.Stock, domain.UnchangedStock, product.Stock,
+		product.ID, product.Version, product.Version,
+	)
+	if isDuplicateKey(err) {
+		return fmt.Errorf("%w: %s", ErrSKUExists, product.SKU)
+	}
+	if err != nil {
+		return err
+	}
+	// The product is locked, so matching no row means another version
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = ErrVersionConflict
+		}
+		return err
+	}
+
+	var currentSlug string
+	if err := tx.QueryRow("SELECT COALESCE(Slug, '') FROM Product WHERE ID = ?", product.ID).Scan(&currentSlug); err != nil {
+		return err
+	}
+	if product.Slug, err = assignSlug(tx, product.ID, currentSlug, product.Name); err != nil {
+		return err
+	}
+
+	if oldPrice != product.Price {
+		err := recordPriceChange(tx, &domain.PriceChange{
+			ProductID: product.ID,
+			OldPrice:  oldPrice,
+			NewPrice:  product.Price,
+			Source:    source,
+			ChangedBy: changedBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow("SELECT Stock, Version FROM Product WHERE ID = ?", product.ID).Scan(&product.Stock, &product.Version)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// Update modifies an existing product
-func (r *productRepo) Update(product *domain.Product) error {
-	_, err := r.db.Exec(
-		"UPDATE Product SET Name = ?, Price = ?, Description = ? WHERE ID = ?",
-		product.Name, product.Price, product.Description, product.ID,
+// GetPriceHistory retrieves the changes to a product's base price, oldest
+// first
+func (r *productRepo) GetPriceHistory(productID int64) ([]*domain.PriceChange, error) {
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM Product WHERE ID = ?)", productID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	rows, err := r.db.Query(
+		`SELECT ID, ProductID, OldPrice, OldCurrency, NewPrice, NewCurrency, Source, COALESCE(ScheduleID, 0), ChangedBy, ChangedAt
+		 FROM PriceHistory WHERE ProductID = ? ORDER BY ChangedAt, ID`,
+		productID,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]*domain.PriceChange, 0)
+	for rows.Next() {
+		change := &domain.PriceChange{}
+		var oldPrice, oldCurrency, newPrice, newCurrency, source string
+		err := rows.Scan(&change.ID, &change.ProductID, &oldPrice, &oldCurrency, &newPrice, &newCurrency, &source,
+			&change.ScheduleID, &change.ChangedBy, &change.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+		if change.OldPrice, err = domain.ParseMoney(oldPrice, oldCurrency); err != nil {
+			return nil, err
+		}
+		if change.NewPrice, err = domain.ParseMoney(newPrice, newCurrency); err != nil {
+			return nil, err
+		}
+		change.Source = domain.PriceChangeSource(source)
+		history = append(history, change)
+	}
+	return history, rows.Err()
 }
 
-// Delete removes a product by its ID
-func (r *productRepo) Delete(id int64) error {
-	_, err := r.db.Exec("DELETE FROM Product WHERE ID = ?", id)
+// GenerateMissingSlugs gives every product without a slug, such as those
+// created before slugs were introduced, one generated from its name,
+// returning how many were given one
+func (r *productRepo) GenerateMissingSlugs() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT ID, Name FROM Product WHERE Slug IS NULL ORDER BY ID FOR UPDATE")
+	if err != nil {
+		return 0, err
+	}
+	type unslugged struct {
+		id   int64
+		name string
+	}
+	var products []unslugged
+	for rows.Next() {
+		var p unslugged
+		if err := rows.Scan(&p.id, &p.name); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range products {
+		if _, err := assignSlug(tx, p.id, "", p.name); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(products), nil
+}
+
+// Delete archives a product by its ID, hiding it from listings and
+// stopping it from being bought while carts and orders still referencing
+// it keep working. Unless version is zero the product must still be at
+// that version.
+func (r *productRepo) Delete(id, version int64) error {
+	result, err := r.db.Exec(
+		`UPDATE Product SET DeletedAt = CURRENT_TIMESTAMP, Version = Version + 1
+		 WHERE ID = ? AND DeletedAt IS NULL AND (? = 0 OR Version = ?)`,
+		id, version, version,
+	)
+	if err != nil {
+		return err
+	}
+	return r.checkArchiveChange(result, id, true)
+}
+
+// Restore brings back an archived product. Unless version is zero the
+// product must still be at that version.
+func (r *productRepo) Restore(id, version int64) error {
+	result, err := r.db.Exec(
+		`UPDATE Product SET DeletedAt = NULL, Version = Version + 1
+		 WHERE ID = ? AND DeletedAt IS NOT NULL AND (? = 0 OR Version = ?)`,
+		id, version, version,
+	)
+	if err != nil {
+		return err
+	}
+	return r.checkArchiveChange(result, id, false)
+}
+
+// PurgeArchived deletes the products archived for longer than olderThan that
+// no cart or order references, along with their prices, reviews, wishlist
+// entries and coupon and promotion targets, returning the IDs removed. Their
+// images are left for the caller to remove with their files.
+func (r *productRepo) PurgeArchived(olderThan time.Duration) ([]int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`SELECT p.ID FROM Product p
+		 WHERE p.DeletedAt < NOW() - INTERVAL ? SECOND
+		   AND NOT EXISTS (SELECT 1 FROM CartItem ci WHERE ci.ProductID = p.ID)
+		   AND NOT EXISTS (SELECT 1 FROM OrderItem oi WHERE oi.ProductID = p.ID)
+		 FOR UPDATE`,
+		int64(olderThan.Seconds()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	in, args := inClause(ids)
+	for _, table := range []string{"WishlistItem", "Review", "CouponProduct", "PromotionProduct", "ProductPrice", "Product"} {
+		column := "ProductID"
+		if table == "Product" {
+			column = "ID"
+		}
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE "+column+" IN "+in, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// checkArchiveChange tells why archiving (archive true) or restoring a
+// product matched no row
+func (r *productRepo) checkArchiveChange(result sql.Result, id int64, archive bool) error {
+	n, err := result.RowsAffected()
+	if err != nil || n > 0 {
+		return err
+	}
+
+	var archived bool
+	err = r.db.QueryRow("SELECT DeletedAt IS NOT NULL FROM Product WHERE ID = ?", id).Scan(&archived)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrProductNotFound
+	case err != nil:
+		return err
+	case archive && archived:
+		return ErrProductArchived
+	case !archive && !archived:
+		return ErrProductNotArchived
+	}
+	return ErrVersionConflict
+}
+
+// assignSlug sets the slug of a product in tx to one generated from name,
+// returning it. A current slug generated from the same name is kept. A
+// replaced slug moves to the slug history so it still leads to the product,
+// and a product may take back a slug it had before.
+func assignSlug(tx *sql.Tx, productID int64, current, name string) (string, error) {
+	base := domain.Slugify(name)
+	if current != "" && isSlugOf(current, base) {
+		return current, nil
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		var taken bool
+		err := tx.QueryRow(
+			`SELECT EXISTS (SELECT 1 FROM Product WHERE Slug = ? AND ID <> ?)
+			     OR EXISTS (SELECT 1 FROM ProductSlugHistory WHERE Slug = ? AND ProductID <> ?)`,
+			slug, productID, slug, productID,
+		).Scan(&taken)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			break
+		}
+		slug = base + "-" + strconv.Itoa(n)
+	}
+
+	if _, err := tx.Exec("DELETE FROM ProductSlugHistory WHERE Slug = ?", slug); err != nil {
+		return "", err
+	}
+	if current != "" {
+		_, err := tx.Exec("INSERT INTO ProductSlugHistory (Slug, ProductID) VALUES (?, ?)", current, productID)
+		if err != nil {
+			return "", err
+		}
+	}
+	if _, err := tx.Exec("UPDATE Product SET Slug = ? WHERE ID = ?", slug, productID); err != nil {
+		return "", err
+	}
+	return slug, nil
+}
+
+// isSlugOf reports whether slug is base or base with a numeric suffix added
+// to tell it apart from other products' slugs
+func isSlugOf(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(slug, base+"-")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+// lockProductPrice locks a product for the rest of tx and returns its price
+func lockProductPrice(tx *sql.Tx, id int64) (domain.Money, error) {
+	var price, currency string
+	err := tx.QueryRow("SELECT Price, Currency FROM Product WHERE ID = ? FOR UPDATE", id).Scan(&price, &currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Money{}, ErrProductNotFound
+	}
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return domain.ParseMoney(price, currency)
+}
+
+// recordPriceChange adds a change to the price history
+func recordPriceChange(tx *sql.Tx, change *domain.PriceChange) error {
+	_, err := tx.Exec(
+		`INSERT INTO PriceHistory (ProductID, OldPrice, OldCurrency, NewPrice, NewCurrency, Source, ScheduleID, ChangedBy)
+		 VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, 0), ?)`,
+		change.ProductID, change.OldPrice, change.OldPrice.Currency, change.NewPrice, change.NewPrice.Currency,
+		string(change.Source), change.ScheduleID, change.ChangedBy,
+	)
 	return err
 }
+
+// scanProduct reads a product selected with productColumns
+func scanProduct(row rowScanner) (*domain.Product, error) {
+	p := &domain.Product{}
+	var price, currency string
+	var deletedAt sql.NullTime
+	err := row.Scan(&p.ID, &p.SKU, &p.Slug, &p.Name, &price, &currency, &p.Description, &p.Category, &p.TaxClass, &p.WeightGrams, &p.Stock, &p.Version, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		p.DeletedAt = &deletedAt.Time
+	}
+
+	p.Price, err = domain.ParseMoney(price, currency)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,26 @@
+package infrastructure
+
+import "testing"
+
+func TestIsSlugOf(t *testing.T) {
+	tests := []struct {
+		slug string
+		base string
+		want bool
+	}{
+		{"blue-mug", "blue-mug", true},
+		{"blue-mug-2", "blue-mug", true},
+		{"blue-mug-12", "blue-mug", true},
+		{"blue-mug-large", "blue-mug", false},
+		{"blue-mug-", "blue-mug", false},
+		{"blue-mug2", "blue-mug", false},
+		{"blue", "blue-mug", false},
+		{"red-mug-2", "blue-mug", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSlugOf(tt.slug, tt.base); got != tt.want {
+			t.Errorf("isSlugOf(%q, %q): got %v, want %v", tt.slug, tt.base, got, tt.want)
+		}
+	}
+}
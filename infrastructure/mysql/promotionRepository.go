@@ -0,0 +1,292 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"time"
+)
+
+var ErrPromotionNotFound = errors.New("promotion not found")
+
+const promotionColumns = "ID, Name, Type, Priority, Stackable, StartsAt, EndsAt, BuyQuantity, GetQuantity, " +
+	"PercentOff, BundlePrice, Currency, IsActive, CreatedAt"
+
+// PromotionRepository defines operations for automatic promotions
+type PromotionRepository interface {
+	CreatePromotion(promotion *domain.Promotion) (*domain.Promotion, error)
+	GetPromotionByID(id int64) (*domain.Promotion, error)
+	GetPromotions() ([]*domain.Promotion, error)
+	SetPromotionActive(id int64, active bool) error
+	CartPromotions(cart *domain.Cart) (*domain.PromotionResult, error)
+}
+
+// promotionRepo is the concrete implementation
+type promotionRepo struct {
+	db Repository
+}
+
+// NewPromotionRepository creates a new PromotionRepository
+func NewPromotionRepository(db Repository) PromotionRepository {
+	return &promotionRepo{db: db}
+}
+
+// CreatePromotion stores a promotion with its targets and tiers
+func (r *promotionRepo) CreatePromotion(promotion *domain.Promotion) (*domain.Promotion, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	currency := ""
+	if !promotion.BundlePrice.IsZero() {
+		currency = promotion.BundlePrice.Currency
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO Promotion (Name, Type, Priority, Stackable, StartsAt, EndsAt, BuyQuantity, GetQuantity, PercentOff, BundlePrice, Currency, IsActive)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		promotion.Name, promotion.Type, promotion.Priority, promotion.Stackable, promotion.StartsAt, promotion.EndsAt,
+		promotion.BuyQuantity, promotion.GetQuantity, domain.FormatPercent(promotion.PercentOff), promotion.BundlePrice,
+		currency, promotion.IsActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, productID := range promotion.ProductIDs {
+		_, err := tx.Exec("INSERT IGNORE INTO PromotionProduct (PromotionID, ProductID) VALUES (?, ?)", id, productID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, category := range promotion.Categories {
+		_, err := tx.Exec("INSERT IGNORE INTO PromotionCategory (PromotionID, Category) VALUES (?, ?)", id, category)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, tier := range promotion.Tiers {
+		_, err := tx.Exec(
+			"INSERT INTO PromotionTier (PromotionID, MinQuantity, PercentOff) VALUES (?, ?, ?)",
+			id, tier.MinQuantity, domain.FormatPercent(tier.PercentOff),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetPromotionByID(id)
+}
+
+// GetPromotionByID retrieves a promotion by its ID
+func (r *promotionRepo) GetPromotionByID(id int64) (*domain.Promotion, error) {
+	promotion, err := scanPromotion(r.db.QueryRow("SELECT "+promotionColumns+" FROM Promotion WHERE ID = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPromotionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadPromotionDetails(r.db, promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// GetPromotions retrieves all promotions by descending priority
+func (r *promotionRepo) GetPromotions() ([]*domain.Promotion, error) {
+	return queryPromotions(r.db, "SELECT "+promotionColumns+" FROM Promotion ORDER BY Priority DESC, ID")
+}
+
+// SetPromotionActive enables or disables a promotion
+func (r *promotionRepo) SetPromotionActive(id int64, active bool) error {
+	result, err := r.db.Exec("UPDATE Promotion SET IsActive = ? WHERE ID = ?", active, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := r.GetPromotionByID(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CartPromotions runs the currently active promotions over a cart
+func (r *promotionRepo) CartPromotions(cart *domain.Cart) (*domain.PromotionResult, error) {
+	return evaluatePromotions(r.db, r.db, cart)
+}
+
+// evaluatePromotions runs the active promotions over cart, reading through
+// q so checkout can evaluate inside its transaction; exchange rates for
+// bundle prices are read through db
+func evaluatePromotions(q queryer, db Repository, cart *domain.Cart) (*domain.PromotionResult, error) {
+	now := time.Now()
+	promotions, err := queryPromotions(q,
+		"SELECT "+promotionColumns+" FROM Promotion WHERE IsActive = true "+
+			"AND (StartsAt IS NULL OR StartsAt <= ?) AND (EndsAt IS NULL OR EndsAt > ?)",
+		now, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := &currencyRepo{db: db}
+	for i, promotion := range promotions {
+		if promotion.BundlePrice.IsZero() || promotion.BundlePrice.Currency == cart.Currency {
+			continue
+		}
+		rate, err := rates.GetRate(promotion.BundlePrice.Currency, cart.Currency)
+		if err != nil {
+			return nil, err
+		}
+		promotions[i] = promotion.InCurrency(cart.Currency, rate)
+	}
+
+	categories, err := productCategories(q, cart.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.ApplyPromotions(cart, promotions, categories, now), nil
+}
+
+// queryPromotions runs a query selecting promotionColumns and loads the
+// details of every promotion found
+func queryPromotions(q queryer, query string, args ...interface{}) ([]*domain.Promotion, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions := make([]*domain.Promotion, 0)
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, promotion := range promotions {
+		if err := loadPromotionDetails(q, promotion); err != nil {
+			return nil, err
+		}
+	}
+
+	return promotions, nil
+}
+
+// loadPromotionDetails reads the targeted products and categories and the
+// volume tiers of a promotion
+func loadPromotionDetails(q queryer, promotion *domain.Promotion) error {
+	rows, err := q.Query("SELECT ProductID FROM PromotionProduct WHERE PromotionID = ? ORDER BY ProductID", promotion.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		promotion.ProductIDs = append(promotion.ProductIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query("SELECT Category FROM PromotionCategory WHERE PromotionID = ? ORDER BY Category", promotion.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			rows.Close()
+			return err
+		}
+		promotion.Categories = append(promotion.Categories, category)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query("SELECT MinQuantity, PercentOff FROM PromotionTier WHERE PromotionID = ? ORDER BY MinQuantity", promotion.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tier domain.PromotionTier
+		var percent string
+		if err := rows.Scan(&tier.MinQuantity, &percent); err != nil {
+			return err
+		}
+		tier.PercentOff, err = domain.ParsePercent(percent)
+		if err != nil {
+			return err
+		}
+		promotion.Tiers = append(promotion.Tiers, tier)
+	}
+	return rows.Err()
+}
+
+// scanPromotion reads a promotion row selected with promotionColumns
+func scanPromotion(row rowScanner) (*domain.Promotion, error) {
+	promotion := &domain.Promotion{}
+	var percent, bundlePrice, currency string
+	var startsAt, endsAt sql.NullTime
+	err := row.Scan(
+		&promotion.ID, &promotion.Name, &promotion.Type, &promotion.Priority, &promotion.Stackable, &startsAt, &endsAt,
+		&promotion.BuyQuantity, &promotion.GetQuantity, &percent, &bundlePrice, &currency, &promotion.IsActive,
+		&promotion.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if startsAt.Valid {
+		promotion.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		promotion.EndsAt = &endsAt.Time
+	}
+
+	promotion.PercentOff, err = domain.ParsePercent(percent)
+	if err != nil {
+		return nil, err
+	}
+	// Only bundles carry an amount and with it a currency
+	if currency != "" {
+		promotion.BundlePrice, err = domain.ParseMoney(bundlePrice, currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return promotion, nil
+}
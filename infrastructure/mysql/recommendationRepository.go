@@ -0,0 +1,115 @@
+package infrastructure
+
+import (
+	"ecommerce-go/domain"
+)
+
+// recommendationBaskets selects the products held together, one row per
+// basket and product, that each kind of recommendation is computed from
+var recommendationBaskets = map[domain.RecommendationKind]string{
+	domain.RecommendationBoughtTogether: `SELECT DISTINCT oi.OrderID AS BasketID, oi.ProductID
+		FROM OrderItem oi JOIN Orders o ON o.ID = oi.OrderID JOIN Product p ON p.ID = oi.ProductID
+		WHERE o.Status <> '` + string(domain.OrderStatusCancelled) + `'`,
+	domain.RecommendationAlsoViewed: `SELECT DISTINCT ci.CartID AS BasketID, ci.ProductID
+		FROM CartItem ci JOIN Product p ON p.ID = ci.ProductID`,
+}
+
+// RecommendationRepository defines operations for product recommendations
+type RecommendationRepository interface {
+	Compute(limit, minScore int) (int, error)
+	GetRecommendations(productIDs []int64, kind domain.RecommendationKind, limit int) ([]*domain.Product, error)
+}
+
+// recommendationRepo is the concrete implementation
+type recommendationRepo struct {
+	db Repository
+}
+
+// NewRecommendationRepository creates a new RecommendationRepository
+func NewRecommendationRepository(db Repository) RecommendationRepository {
+	return &recommendationRepo{db: db}
+}
+
+// Compute replaces every recommendation with ones counted from the orders
+// and carts: for each product and kind, the limit products most often held
+// together with it in at least minScore orders or carts. It returns how
+// many recommendations were stored.
+func (r *recommendationRepo) Compute(limit, minScore int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM ProductRecommendation"); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, kind := range domain.RecommendationKinds {
+		result, err := tx.Exec(
+			`INSERT INTO ProductRecommendation (ProductID, Kind, RecommendedID, Score, Position)
+			 WITH Baskets AS (`+recommendationBaskets[kind]+`)
+			 SELECT ProductID, ?, RecommendedID, Score, Position FROM (
+				SELECT a.ProductID, b.ProductID AS RecommendedID, COUNT(*) AS Score,
+					ROW_NUMBER() OVER (PARTITION BY a.ProductID ORDER BY COUNT(*) DESC, b.ProductID) AS Position
+				FROM Baskets a JOIN Baskets b ON b.BasketID = a.BasketID AND b.ProductID <> a.ProductID
+				GROUP BY a.ProductID, b.ProductID
+				HAVING COUNT(*) >= ?
+			 ) pairs
+			 WHERE Position <= ?`,
+			string(kind), minScore, limit,
+		)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetRecommendations retrieves up to limit products of a kind recommended
+// for the given products together, best first, adding up the scores of a
+// product recommended for several of them. The given products themselves
+// and archived products are left out.
+func (r *recommendationRepo) GetRecommendations(productIDs []int64, kind domain.RecommendationKind, limit int) ([]*domain.Product, error) {
+	products := make([]*domain.Product, 0)
+	if len(productIDs) == 0 || limit <= 0 {
+		return products, nil
+	}
+
+	in, args := inClause(productIDs)
+	rows, err := r.db.Query(
+		`SELECT `+productColumns+` FROM Product
+		 JOIN (
+			SELECT RecommendedID, SUM(Score) AS Total FROM ProductRecommendation
+			WHERE Kind = ? AND ProductID IN `+in+`
+			GROUP BY RecommendedID
+		 ) recommended ON recommended.RecommendedID = Product.ID
+		 WHERE DeletedAt IS NULL AND ID NOT IN `+in+`
+		 ORDER BY recommended.Total DESC, ID
+		 LIMIT ?`,
+		append(append(append([]interface{}{string(kind)}, args...), args...), limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
@@ -4,10 +4,18 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
+// mysqlErrDuplicateEntry is the MySQL error number for unique key violations
+const mysqlErrDuplicateEntry = 1062
+
+// ErrVersionConflict is returned by conditional updates when the row has
+// changed since the version the caller read
+var ErrVersionConflict = errors.New("modified by another request")
+
 // Repository defines the interface for database operations
 type Repository interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
@@ -17,6 +25,11 @@ type Repository interface {
 	Close() error
 }
 
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 // mysqlRepository implements Repository
 type mysqlRepository struct {
 	db *sql.DB
@@ -67,3 +80,20 @@ func (r *mysqlRepository) Close() error {
 	}
 	return errors.New("db connection is nil")
 }
+
+// isDuplicateKey reports whether err is a unique key violation
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+// inClause returns a parenthesized placeholder list for ids and its arguments
+func inClause(ids []int64) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")", args
+}
@@ -0,0 +1,364 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrReturnNotFound          = errors.New("return request not found")
+	ErrOrderItemNotFound       = errors.New("order item not found")
+	ErrOrderNotReturnable      = errors.New("order is not eligible for returns")
+	ErrReturnQuantityExceeded  = errors.New("return quantity exceeds returnable quantity")
+	ErrInvalidReturnTransition = errors.New("invalid return status transition")
+)
+
+// returnableOrderStatuses are the order statuses in which lines may be returned
+var returnableOrderStatuses = map[domain.OrderStatus]bool{
+	domain.OrderStatusPaid:      true,
+	domain.OrderStatusFulfilled: true,
+	domain.OrderStatusShipped:   true,
+	domain.OrderStatusDelivered: true,
+}
+
+// ReturnRepository defines operations for ReturnRequest
+type ReturnRepository interface {
+	CreateReturn(req *domain.ReturnRequest) (*domain.ReturnRequest, error)
+	GetReturnByID(id int64) (*domain.ReturnRequest, error)
+	GetReturnsByOrderID(orderID int64) ([]*domain.ReturnRequest, error)
+	GetReturnsByStatus(status domain.ReturnStatus) ([]*domain.ReturnRequest, error)
+	ReviewReturn(id int64, status domain.ReturnStatus, staffID int64, note string) (*domain.ReturnRequest, error)
+	ReceiveReturn(id int64, staffID int64) (*domain.ReturnRequest, error)
+	StartRefund(id int64, staffID int64, amount domain.Money) (*domain.ReturnRequest, error)
+	CancelRefund(id int64) (*domain.ReturnRequest, error)
+	RefundReturn(id int64, paymentID int64) (*domain.ReturnRequest, error)
+}
+
+// returnRepo is the concrete implementation
+type returnRepo struct {
+	db Repository
+}
+
+// NewReturnRepository creates a new ReturnRepository
+func NewReturnRepository(db Repository) ReturnRepository {
+	return &returnRepo{db: db}
+}
+
+// returnColumns also selects the order currency the refund amount is in
+const returnColumns = "ID, OrderID, OrderItemID, UserID, Quantity, Reason, Comment, Status, RefundAmount, " +
+	"(SELECT Currency FROM Orders WHERE Orders.ID = ReturnRequest.OrderID), ReviewedBy, StaffNote, CreatedAt, UpdatedAt"
+
+// CreateReturn opens a return request for one order line. The requested
+// quantity may not exceed what was bought minus what is already being
+// returned on other open or completed requests.
+func (r *returnRepo) CreateReturn(req *domain.ReturnRequest) (*domain.ReturnRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var orderID, userID int64
+	var quantity int
+	var status domain.OrderStatus
+	err = tx.QueryRow(
+		`SELECT oi.OrderID, o.UserID, o.Status, oi.Quantity
+		 FROM OrderItem oi JOIN Orders o ON o.ID = oi.OrderID
+		 WHERE oi.ID = ? FOR UPDATE`, req.OrderItemID,
+	).Scan(&orderID, &userID, &status, &quantity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != req.UserID {
+		return nil, ErrOrderItemNotFound
+	}
+	if !returnableOrderStatuses[status] {
+		return nil, fmt.Errorf("%w: order is %s", ErrOrderNotReturnable, status)
+	}
+
+	var pending int
+	err = tx.QueryRow(
+		"SELECT COALESCE(SUM(Quantity), 0) FROM ReturnRequest WHERE OrderItemID = ? AND Status <> ?",
+		req.OrderItemID, domain.ReturnStatusRejected,
+	).Scan(&pending)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Quantity > quantity-pending {
+		return nil, fmt.Errorf("%w: %d left", ErrReturnQuantityExceeded, quantity-pending)
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO ReturnRequest (OrderID, OrderItemID, UserID, Quantity, Reason, Comment, Status) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		orderID, req.OrderItemID, req.UserID, req.Quantity, req.Reason, req.Comment, domain.ReturnStatusRequested,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReturnByID(id)
+}
+
+// GetReturnByID retrieves a return request by its ID
+func (r *returnRepo) GetReturnByID(id int64) (*domain.ReturnRequest, error) {
+	return scanReturn(r.db.QueryRow("SELECT "+returnColumns+" FROM ReturnRequest WHERE ID = ?", id))
+}
+
+// GetReturnsByOrderID retrieves all return requests of an order
+func (r *returnRepo) GetReturnsByOrderID(orderID int64) ([]*domain.ReturnRequest, error) {
+	return r.queryReturns("SELECT "+returnColumns+" FROM ReturnRequest WHERE OrderID = ? ORDER BY ID", orderID)
+}
+
+// GetReturnsByStatus retrieves all return requests in a status, oldest first
+func (r *returnRepo) GetReturnsByStatus(status domain.ReturnStatus) ([]*domain.ReturnRequest, error) {
+	return r.queryReturns("SELECT "+returnColumns+" FROM ReturnRequest WHERE Status = ? ORDER BY CreatedAt, ID", status)
+}
+
+// ReviewReturn approves or rejects a requested return
+func (r *returnRepo) ReviewReturn(id int64, status domain.ReturnStatus, staffID int64, note string) (*domain.ReturnRequest, error) {
+	if status != domain.ReturnStatusApproved && status != domain.ReturnStatusRejected {
+		return nil, fmt.Errorf("%w: cannot review to %s", ErrInvalidReturnTransition, status)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockReturn(tx, id, status); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE ReturnRequest SET Status = ?, ReviewedBy = ?, StaffNote = ? WHERE ID = ?",
+		status, staffID, note, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReturnByID(id)
+}
+
+// ReceiveReturn marks the returned goods as received and puts them back
+// into stock
+func (r *returnRepo) ReceiveReturn(id int64, staffID int64) (*domain.ReturnRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ret, err := lockReturn(tx, id, domain.ReturnStatusReceived)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE ReturnRequest SET Status = ?, ReviewedBy = ? WHERE ID = ?", domain.ReturnStatusReceived, staffID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE OrderItem SET ReturnedQuantity = ReturnedQuantity + ? WHERE ID = ?", ret.Quantity, ret.OrderItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE Product SET Stock = Stock + ?, Version = Version + 1 WHERE ID = (SELECT ProductID FROM OrderItem WHERE ID = ?)",
+		ret.Quantity, ret.OrderItemID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReturnByID(id)
+}
+
+// StartRefund claims a received return for refunding amount, before the
+// payment provider is called, so only one refund of it can be in progress.
+// A return already refunding keeps the amount it was claimed for, letting
+// an interrupted refund be retried.
+func (r *returnRepo) StartRefund(id int64, staffID int64, amount domain.Money) (*domain.ReturnRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ret, err := lockReturn(tx, id, domain.ReturnStatusRefunding)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != domain.ReturnStatusRefunding {
+		_, err = tx.Exec(
+			"UPDATE ReturnRequest SET Status = ?, RefundAmount = ?, ReviewedBy = ? WHERE ID = ?",
+			domain.ReturnStatusRefunding, amount, staffID, id,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReturnByID(id)
+}
+
+// CancelRefund moves a return back to received after the payment provider
+// refused its refund
+func (r *returnRepo) CancelRefund(id int64) (*domain.ReturnRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ret, err := lockReturn(tx, id, domain.ReturnStatusReceived)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != domain.ReturnStatusRefunding {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidReturnTransition, ret.Status, domain.ReturnStatusReceived)
+	}
+
+	_, err = tx.Exec("UPDATE ReturnRequest SET Status = ?, RefundAmount = 0 WHERE ID = ?", domain.ReturnStatusReceived, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReturnByID(id)
+}
+
+// RefundReturn completes a refunding return once the payment provider has
+// refunded it, recording the refund against the payment and recalculating
+// the refunded total of its order
+func (r *returnRepo) RefundReturn(id int64, paymentID int64) (*domain.ReturnRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ret, err := lockReturn(tx, id, domain.ReturnStatusRefunded)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addPaymentRefund(tx, paymentID, ret.RefundAmount); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE ReturnRequest SET Status = ? WHERE ID = ?", domain.ReturnStatusRefunded, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE Orders SET RefundedAmount = (
+			SELECT COALESCE(SUM(RefundAmount), 0) FROM ReturnRequest WHERE OrderID = ? AND Status = ?
+		) WHERE ID = ?`,
+		ret.OrderID, domain.ReturnStatusRefunded, ret.OrderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReturnByID(id)
+}
+
+// queryReturns runs a query selecting returnColumns
+func (r *returnRepo) queryReturns(query string, args ...interface{}) ([]*domain.ReturnRequest, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := make([]*domain.ReturnRequest, 0)
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+// lockReturn locks a return request within tx and checks it may move to next
+func lockReturn(tx *sql.Tx, id int64, next domain.ReturnStatus) (*domain.ReturnRequest, error) {
+	ret, err := scanReturn(tx.QueryRow("SELECT "+returnColumns+" FROM ReturnRequest WHERE ID = ? FOR UPDATE", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if !ret.Status.CanTransitionTo(next) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidReturnTransition, ret.Status, next)
+	}
+
+	return ret, nil
+}
+
+// scanReturn reads a return request from a row selected with returnColumns
+func scanReturn(row rowScanner) (*domain.ReturnRequest, error) {
+	ret := &domain.ReturnRequest{}
+	var refund, currency string
+	err := row.Scan(
+		&ret.ID, &ret.OrderID, &ret.OrderItemID, &ret.UserID, &ret.Quantity, &ret.Reason, &ret.Comment,
+		&ret.Status, &refund, &currency, &ret.ReviewedBy, &ret.StaffNote, &ret.CreatedAt, &ret.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReturnNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret.RefundAmount, err = domain.ParseMoney(refund, currency)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
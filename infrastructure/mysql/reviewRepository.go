@@ -0,0 +1,240 @@
+package infrastructure
+
+import (
+	"database/sql"
+	"ecommerce-go/domain"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrReviewNotFound          = errors.New("review not found")
+	ErrReviewExists            = errors.New("user has already reviewed this product")
+	ErrInvalidReviewTransition = errors.New("invalid review status transition")
+)
+
+// reviewOrders maps each sort to its ORDER BY clause
+var reviewOrders = map[domain.ReviewSort]string{
+	domain.ReviewSortNewest:     "CreatedAt DESC, ID DESC",
+	domain.ReviewSortOldest:     "CreatedAt, ID",
+	domain.ReviewSortRatingHigh: "Rating DESC, CreatedAt DESC, ID DESC",
+	domain.ReviewSortRatingLow:  "Rating, CreatedAt DESC, ID DESC",
+}
+
+const reviewColumns = "ID, ProductID, UserID, Rating, Title, Body, VerifiedPurchase, Status, ModeratedBy, " +
+	"ModerationNote, CreatedAt, UpdatedAt"
+
+// ReviewRepository defines operations for product reviews
+type ReviewRepository interface {
+	CreateReview(review *domain.Review) (*domain.Review, error)
+	GetReviewByID(id int64) (*domain.Review, error)
+	GetProductReviews(productID int64, sort domain.ReviewSort, limit, offset int) ([]*domain.Review, int, error)
+	GetReviewsByStatus(status domain.ReviewStatus) ([]*domain.Review, error)
+	ModerateReview(id int64, status domain.ReviewStatus, staffID int64, note string) (*domain.Review, error)
+	GetRatingSummary(productID int64) (domain.RatingSummary, error)
+	GetRatingSummaries(productIDs []int64) (map[int64]domain.RatingSummary, error)
+}
+
+// reviewRepo is the concrete implementation
+type reviewRepo struct {
+	db Repository
+}
+
+// NewReviewRepository creates a new ReviewRepository
+func NewReviewRepository(db Repository) ReviewRepository {
+	return &reviewRepo{db: db}
+}
+
+// CreateReview submits a review for moderation. It is flagged as a verified
+// purchase when the user has a paid order containing the product.
+func (r *reviewRepo) CreateReview(review *domain.Review) (*domain.Review, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM Product WHERE ID = ?)", review.ProductID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	// Orders that were paid for, and not since cancelled or refunded
+	var verified bool
+	err = r.db.QueryRow(
+		`SELECT EXISTS (
+		     SELECT 1 FROM OrderItem oi JOIN Orders o ON o.ID = oi.OrderID
+		     WHERE o.UserID = ? AND oi.ProductID = ? AND o.Status IN (?, ?, ?, ?))`,
+		review.UserID, review.ProductID,
+		domain.OrderStatusPaid, domain.OrderStatusFulfilled, domain.OrderStatusShipped, domain.OrderStatusDelivered,
+	).Scan(&verified)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.Exec(
+		"INSERT INTO Review (ProductID, UserID, Rating, Title, Body, VerifiedPurchase, Status) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		review.ProductID, review.UserID, review.Rating, review.Title, review.Body, verified, domain.ReviewStatusPending,
+	)
+	if isDuplicateKey(err) {
+		return nil, ErrReviewExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetReviewByID(id)
+}
+
+// GetReviewByID retrieves a review by its ID
+func (r *reviewRepo) GetReviewByID(id int64) (*domain.Review, error) {
+	return scanReview(r.db.QueryRow("SELECT "+reviewColumns+" FROM Review WHERE ID = ?", id))
+}
+
+// GetProductReviews retrieves a page of a product's approved reviews in
+// sort order, along with the number of approved reviews
+func (r *reviewRepo) GetProductReviews(productID int64, sort domain.ReviewSort, limit, offset int) ([]*domain.Review, int, error) {
+	order, ok := reviewOrders[sort]
+	if !ok {
+		order = reviewOrders[domain.ReviewSortNewest]
+	}
+
+	var total int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM Review WHERE ProductID = ? AND Status = ?",
+		productID, domain.ReviewStatusApproved,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reviews, err := r.queryReviews(
+		"SELECT "+reviewColumns+" FROM Review WHERE ProductID = ? AND Status = ? ORDER BY "+order+" LIMIT ? OFFSET ?",
+		productID, domain.ReviewStatusApproved, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// GetReviewsByStatus retrieves all reviews in a status, oldest first
+func (r *reviewRepo) GetReviewsByStatus(status domain.ReviewStatus) ([]*domain.Review, error) {
+	return r.queryReviews("SELECT "+reviewColumns+" FROM Review WHERE Status = ? ORDER BY CreatedAt, ID", status)
+}
+
+// ModerateReview approves or rejects a review
+func (r *reviewRepo) ModerateReview(id int64, status domain.ReviewStatus, staffID int64, note string) (*domain.Review, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	review, err := scanReview(tx.QueryRow("SELECT "+reviewColumns+" FROM Review WHERE ID = ? FOR UPDATE", id))
+	if err != nil {
+		return nil, err
+	}
+	if !review.Status.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidReviewTransition, review.Status, status)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE Review SET Status = ?, ModeratedBy = ?, ModerationNote = ? WHERE ID = ?",
+		status, staffID, note, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReviewByID(id)
+}
+
+// GetRatingSummary aggregates a product's approved reviews
+func (r *reviewRepo) GetRatingSummary(productID int64) (domain.RatingSummary, error) {
+	summaries, err := r.GetRatingSummaries([]int64{productID})
+	if err != nil {
+		return domain.RatingSummary{}, err
+	}
+	return summaries[productID], nil
+}
+
+// GetRatingSummaries aggregates the approved reviews of several products.
+// Products without approved reviews are left out.
+func (r *reviewRepo) GetRatingSummaries(productIDs []int64) (map[int64]domain.RatingSummary, error) {
+	summaries := make(map[int64]domain.RatingSummary)
+	if len(productIDs) == 0 {
+		return summaries, nil
+	}
+
+	in, args := inClause(productIDs)
+	rows, err := r.db.Query(
+		"SELECT ProductID, Rating, COUNT(*) FROM Review WHERE Status = ? AND ProductID IN "+in+" GROUP BY ProductID, Rating",
+		append([]interface{}{domain.ReviewStatusApproved}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int64
+		var rating, count int
+		if err := rows.Scan(&productID, &rating, &count); err != nil {
+			return nil, err
+		}
+		summary := summaries[productID]
+		summary.Add(rating, count)
+		summaries[productID] = summary
+	}
+
+	return summaries, rows.Err()
+}
+
+// queryReviews runs a query selecting reviewColumns
+func (r *reviewRepo) queryReviews(query string, args ...interface{}) ([]*domain.Review, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]*domain.Review, 0)
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// scanReview reads a review from a row selected with reviewColumns
+func scanReview(row rowScanner) (*domain.Review, error) {
+	review := &domain.Review{}
+	err := row.Scan(
+		&review.ID, &review.ProductID, &review.UserID, &review.Rating, &review.Title, &review.Body,
+		&review.VerifiedPurchase, &review.Status, &review.ModeratedBy, &review.ModerationNote,
+		&review.CreatedAt, &review.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
@@ -0,0 +1,199 @@
+package infrastructure
+
+import (
+	"ecommerce-go/domain"
+	"errors"
+	"strings"
+)
+
+var ErrTranslationNotFound = errors.New("translation not found")
+
+// searchOperators are the characters with a meaning in MySQL boolean
+// full-text queries, treated as word separators in search queries
+const searchOperators = `+-<>()~*"@`
+
+// TranslationRepository defines operations for localized product content
+type TranslationRepository interface {
+	SetTranslation(translation *domain.ProductTranslation) error
+	DeleteTranslation(productID int64, locale string) error
+	GetTranslations(productID int64) ([]*domain.ProductTranslation, error)
+	GetTranslationsFor(productIDs []int64, locales []string) (map[int64]map[string]*domain.ProductTranslation, error)
+	SearchProducts(query string, locales []string) ([]int64, error)
+}
+
+// translationRepo is the concrete implementation
+type translationRepo struct {
+	db Repository
+}
+
+// NewTranslationRepository creates a new TranslationRepository
+func NewTranslationRepository(db Repository) TranslationRepository {
+	return &translationRepo{db: db}
+}
+
+// SetTranslation creates or replaces a product's content in one locale
+func (r *translationRepo) SetTranslation(translation *domain.ProductTranslation) error {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM Product WHERE ID = ?)", translation.ProductID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO ProductTranslation (ProductID, Locale, Name, Description) VALUES (?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE Name = VALUES(Name), Description = VALUES(Description)`,
+		translation.ProductID, translation.Locale, translation.Name, translation.Description,
+	)
+	return err
+}
+
+// DeleteTranslation removes a product's content in one locale
+func (r *translationRepo) DeleteTranslation(productID int64, locale string) error {
+	result, err := r.db.Exec("DELETE FROM ProductTranslation WHERE ProductID = ? AND Locale = ?", productID, locale)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTranslationNotFound
+	}
+	return nil
+}
+
+// GetTranslations retrieves every translation of a product by locale
+func (r *translationRepo) GetTranslations(productID int64) ([]*domain.ProductTranslation, error) {
+	rows, err := r.db.Query(
+		"SELECT ProductID, Locale, Name, Description FROM ProductTranslation WHERE ProductID = ? ORDER BY Locale",
+		productID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := make([]*domain.ProductTranslation, 0)
+	for rows.Next() {
+		t, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, t)
+	}
+	return translations, rows.Err()
+}
+
+// GetTranslationsFor retrieves the translations of each product into the
+// given locales, keyed by product ID and then locale
+func (r *translationRepo) GetTranslationsFor(productIDs []int64, locales []string) (map[int64]map[string]*domain.ProductTranslation, error) {
+	translations := make(map[int64]map[string]*domain.ProductTranslation, len(productIDs))
+	if len(productIDs) == 0 || len(locales) == 0 {
+		return translations, nil
+	}
+
+	idsIn, args := inClause(productIDs)
+	localesIn, localeArgs := stringInClause(locales)
+	rows, err := r.db.Query(
+		"SELECT ProductID, Locale, Name, Description FROM ProductTranslation WHERE ProductID IN "+idsIn+" AND Locale IN "+localesIn,
+		append(args, localeArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		if translations[t.ProductID] == nil {
+			translations[t.ProductID] = make(map[string]*domain.ProductTranslation)
+		}
+		translations[t.ProductID][t.Locale] = t
+	}
+	return translations, rows.Err()
+}
+
+// SearchProducts finds the products that are not archived whose name or
+// description, in the default content or in a translation into one of
+// locales, contains every word of query as a word or word prefix. The IDs
+// are ordered by relevance, best first.
+func (r *translationRepo) SearchProducts(query string, locales []string) ([]int64, error) {
+	// Operators in the query separate words rather than change the search
+	query = strings.Map(func(c rune) rune {
+		if strings.ContainsRune(searchOperators, c) {
+			return ' '
+		}
+		return c
+	}, query)
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		terms = append(terms, "+"+word+"*")
+	}
+	if len(terms) == 0 {
+		return []int64{}, nil
+	}
+	match := strings.Join(terms, " ")
+
+	statement := `SELECT ID FROM (
+		SELECT ID, MATCH(Name, Description) AGAINST (? IN BOOLEAN MODE) AS Score
+		FROM Product
+		WHERE DeletedAt IS NULL AND MATCH(Name, Description) AGAINST (? IN BOOLEAN MODE)`
+	args := []interface{}{match, match}
+	if len(locales) > 0 {
+		localesIn, localeArgs := stringInClause(locales)
+		statement += `
+		UNION ALL
+		SELECT t.ProductID, MATCH(t.Name, t.Description) AGAINST (? IN BOOLEAN MODE)
+		FROM ProductTranslation t JOIN Product p ON p.ID = t.ProductID
+		WHERE p.DeletedAt IS NULL AND t.Locale IN ` + localesIn + ` AND MATCH(t.Name, t.Description) AGAINST (? IN BOOLEAN MODE)`
+		args = append(args, match)
+		args = append(args, localeArgs...)
+		args = append(args, match)
+	}
+	statement += `
+	) matches GROUP BY ID ORDER BY MAX(Score) DESC, ID`
+
+	rows, err := r.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// stringInClause returns a parenthesized placeholder list for values and
+// its arguments
+func stringInClause(values []string) (string, []interface{}) {
+	placeholders := make([]string, len(values))
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		placeholders[i] = "?"
+		args[i] = value
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// scanTranslation reads a translation row
+func scanTranslation(row rowScanner) (*domain.ProductTranslation, error) {
+	t := &domain.ProductTranslation{}
+	if err := row.Scan(&t.ProductID, &t.Locale, &t.Name, &t.Description); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
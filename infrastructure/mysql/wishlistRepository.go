@@ -0,0 +1,338 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"ecommerce-go/domain"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrWishlistNotFound     = errors.New("wishlist not found")
+	ErrWishlistExists       = errors.New("a wishlist with this name already exists")
+	ErrWishlistItemNotFound = errors.New("item not found in wishlist")
+)
+
+const wishlistColumns = "ID, UserID, Name, Kind, IsPublic, COALESCE(ShareToken, ''), CreatedAt"
+
+// WishlistRepository defines operations for the users' wishlists and
+// saved-for-later lists. Every operation is scoped to the owning user.
+type WishlistRepository interface {
+	CreateWishlist(wishlist *domain.Wishlist) (*domain.Wishlist, error)
+	GetWishlist(userID, id int64) (*domain.Wishlist, error)
+	GetWishlistsByUserID(userID int64) ([]*domain.Wishlist, error)
+	GetSharedWishlist(token string) (*domain.Wishlist, error)
+	GetSavedForLater(userID int64) (*domain.Wishlist, error)
+	UpdateWishlist(userID, id int64, name string, isPublic bool) (*domain.Wishlist, error)
+	DeleteWishlist(userID, id int64) error
+	AddWishlistItem(userID, wishlistID, productID int64, quantity int, price domain.Money) (*domain.WishlistItem, error)
+	GetWishlistItem(userID, wishlistID, productID int64) (*domain.WishlistItem, error)
+	RemoveWishlistItem(userID, wishlistID, productID int64) error
+}
+
+// wishlistRepo is the concrete implementation
+type wishlistRepo struct {
+	db Repository
+}
+
+// NewWishlistRepository creates a new WishlistRepository
+func NewWishlistRepository(db Repository) WishlistRepository {
+	return &wishlistRepo{db: db}
+}
+
+// CreateWishlist creates a named wishlist. A public wishlist is given a
+// share token.
+func (r *wishlistRepo) CreateWishlist(wishlist *domain.Wishlist) (*domain.Wishlist, error) {
+	if wishlist.Kind == "" {
+		wishlist.Kind = domain.WishlistKindWishlist
+	}
+
+	var token interface{}
+	if wishlist.IsPublic {
+		token = newShareToken()
+	}
+
+	result, err := r.db.Exec(
+		"INSERT INTO Wishlist (UserID, Name, Kind, IsPublic, ShareToken) VALUES (?, ?, ?, ?, ?)",
+		wishlist.UserID, wishlist.Name, wishlist.Kind, wishlist.IsPublic, token,
+	)
+	if isDuplicateKey(err) {
+		return nil, fmt.Errorf("%w: %s", ErrWishlistExists, wishlist.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetWishlist(wishlist.UserID, id)
+}
+
+// GetWishlist retrieves one of a user's wishlists with its items
+func (r *wishlistRepo) GetWishlist(userID, id int64) (*domain.Wishlist, error) {
+	return r.getWishlist("ID = ? AND UserID = ?", id, userID)
+}
+
+// GetWishlistsByUserID retrieves a user's wishlists with their items, the
+// saved-for-later list first
+func (r *wishlistRepo) GetWishlistsByUserID(userID int64) ([]*domain.Wishlist, error) {
+	rows, err := r.db.Query(
+		"SELECT "+wishlistColumns+" FROM Wishlist WHERE UserID = ? ORDER BY Kind = ? DESC, ID",
+		userID, domain.WishlistKindSavedForLater,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wishlists := make([]*domain.Wishlist, 0)
+	for rows.Next() {
+		wishlist, err := scanWishlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, wishlist := range wishlists {
+		if wishlist.Items, err = r.getItems(wishlist.ID); err != nil {
+			return nil, err
+		}
+	}
+	return wishlists, nil
+}
+
+// GetSharedWishlist retrieves a public wishlist by its share token
+func (r *wishlistRepo) GetSharedWishlist(token string) (*domain.Wishlist, error) {
+	return r.getWishlist("ShareToken = ? AND IsPublic = true", token)
+}
+
+// GetSavedForLater retrieves the user's saved-for-later list, creating it
+// on first use
+func (r *wishlistRepo) GetSavedForLater(userID int64) (*domain.Wishlist, error) {
+	wishlist, err := r.getWishlist("UserID = ? AND Kind = ?", userID, domain.WishlistKindSavedForLater)
+	if !errors.Is(err, ErrWishlistNotFound) {
+		return wishlist, err
+	}
+
+	wishlist, err = r.CreateWishlist(&domain.Wishlist{
+		UserID: userID,
+		Name:   domain.SavedForLaterName,
+		Kind:   domain.WishlistKindSavedForLater,
+	})
+	if errors.Is(err, ErrWishlistExists) {
+		// Created concurrently by another request
+		return r.getWishlist("UserID = ? AND Kind = ?", userID, domain.WishlistKindSavedForLater)
+	}
+	return wishlist, err
+}
+
+// UpdateWishlist renames a wishlist and makes it public or private. A
+// wishlist keeps its share token, so making it public again revives old
+// links. The saved-for-later list cannot be renamed.
+func (r *wishlistRepo) UpdateWishlist(userID, id int64, name string, isPublic bool) (*domain.Wishlist, error) {
+	wishlist, err := r.GetWishlist(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if wishlist.Kind == domain.WishlistKindSavedForLater {
+		name = wishlist.Name
+	}
+
+	token := wishlist.ShareToken
+	if isPublic && token == "" {
+		token = newShareToken()
+	}
+
+	_, err = r.db.Exec(
+		"UPDATE Wishlist SET Name = ?, IsPublic = ?, ShareToken = NULLIF(?, '') WHERE ID = ? AND UserID = ?",
+		name, isPublic, token, id, userID,
+	)
+	if isDuplicateKey(err) {
+		return nil, fmt.Errorf("%w: %s", ErrWishlistExists, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetWishlist(userID, id)
+}
+
+// DeleteWishlist removes a wishlist and its items
+func (r *wishlistRepo) DeleteWishlist(userID, id int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockWishlist(tx, userID, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM WishlistItem WHERE WishlistID = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM Wishlist WHERE ID = ?", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddWishlistItem adds a product to a wishlist at price. A product already
+// in the list gains quantity and keeps the price it was first added at.
+func (r *wishlistRepo) AddWishlistItem(userID, wishlistID, productID int64, quantity int, price domain.Money) (*domain.WishlistItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockWishlist(tx, userID, wishlistID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO WishlistItem (WishlistID, ProductID, Quantity, Price, Currency) VALUES (?, ?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE Quantity = Quantity + VALUES(Quantity)`,
+		wishlistID, productID, quantity, price, price.Currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetWishlistItem(userID, wishlistID, productID)
+}
+
+// GetWishlistItem retrieves a product's line in one of a user's wishlists
+func (r *wishlistRepo) GetWishlistItem(userID, wishlistID, productID int64) (*domain.WishlistItem, error) {
+	item, err := scanWishlistItem(r.db.QueryRow(
+		`SELECT wi.ID, wi.WishlistID, wi.ProductID, wi.Quantity, wi.Price, wi.Currency, wi.AddedAt
+		 FROM WishlistItem wi JOIN Wishlist w ON w.ID = wi.WishlistID
+		 WHERE wi.WishlistID = ? AND wi.ProductID = ? AND w.UserID = ?`,
+		wishlistID, productID, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWishlistItemNotFound
+	}
+	return item, err
+}
+
+// RemoveWishlistItem removes a product from one of a user's wishlists
+func (r *wishlistRepo) RemoveWishlistItem(userID, wishlistID, productID int64) error {
+	result, err := r.db.Exec(
+		`DELETE wi FROM WishlistItem wi JOIN Wishlist w ON w.ID = wi.WishlistID
+		 WHERE wi.WishlistID = ? AND wi.ProductID = ? AND w.UserID = ?`,
+		wishlistID, productID, userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWishlistItemNotFound
+	}
+	return nil
+}
+
+// getWishlist reads the wishlist matching a condition with its items
+func (r *wishlistRepo) getWishlist(condition string, args ...interface{}) (*domain.Wishlist, error) {
+	wishlist, err := scanWishlist(r.db.QueryRow("SELECT "+wishlistColumns+" FROM Wishlist WHERE "+condition, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWishlistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist.Items, err = r.getItems(wishlist.ID)
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// getItems reads the items of a wishlist, oldest first
+func (r *wishlistRepo) getItems(wishlistID int64) ([]domain.WishlistItem, error) {
+	rows, err := r.db.Query(
+		"SELECT ID, WishlistID, ProductID, Quantity, Price, Currency, AddedAt FROM WishlistItem WHERE WishlistID = ? ORDER BY ID",
+		wishlistID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]domain.WishlistItem, 0)
+	for rows.Next() {
+		item, err := scanWishlistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+// lockWishlist locks one of a user's wishlists within tx
+func lockWishlist(tx *sql.Tx, userID, id int64) error {
+	var found int64
+	err := tx.QueryRow("SELECT ID FROM Wishlist WHERE ID = ? AND UserID = ? FOR UPDATE", id, userID).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWishlistNotFound
+	}
+	return err
+}
+
+// newShareToken returns a random token for a wishlist's share link
+func newShareToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// scanWishlist reads a wishlist row selected with wishlistColumns
+func scanWishlist(row rowScanner) (*domain.Wishlist, error) {
+	wishlist := &domain.Wishlist{}
+	err := row.Scan(
+		&wishlist.ID, &wishlist.UserID, &wishlist.Name, &wishlist.Kind, &wishlist.IsPublic, &wishlist.ShareToken,
+		&wishlist.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// scanWishlistItem reads a wishlist item row
+func scanWishlistItem(row rowScanner) (*domain.WishlistItem, error) {
+	item := &domain.WishlistItem{}
+	var price, currency string
+	err := row.Scan(&item.ID, &item.WishlistID, &item.ProductID, &item.Quantity, &price, &currency, &item.AddedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	item.PriceWhenAdded, err = domain.ParseMoney(price, currency)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
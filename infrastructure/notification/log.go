@@ -0,0 +1,19 @@
+package notification
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// LogNotifier writes events to the server log, for development
+type LogNotifier struct{}
+
+// Notify logs the event as JSON
+func (LogNotifier) Notify(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	log.Printf("notification: %s", payload)
+	return nil
+}
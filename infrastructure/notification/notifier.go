@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// SignatureHeader is the request header carrying the webhook signature
+const SignatureHeader = "X-Notification-Signature"
+
+// Event types
+const (
+	EventCartAbandoned = "cart.abandoned"
+)
+
+// Event is something customers or other systems may want to hear about.
+// Data is encoded as JSON.
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// NewEvent creates an event of the given type happening now
+func NewEvent(eventType string, data interface{}) Event {
+	return Event{
+		ID:         "evt_" + randomID(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+}
+
+// Notifier is implemented by every way of delivering events
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// randomID returns a random hex identifier
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,245 @@
+package payment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"ecommerce-go/domain"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Payment methods understood by the fake provider
+const (
+	FakeMethodSuccess = "fake_success"
+	FakeMethodDecline = "fake_decline"
+	FakeMethodDelayed = "fake_delayed"
+)
+
+// FakeProvider is an in-memory provider for development and tests.
+// FakeMethodSuccess authorizes immediately, FakeMethodDecline is declined and
+// FakeMethodDelayed stays pending until a signed webhook confirms it.
+type FakeProvider struct {
+	secret     []byte
+	webhookURL string
+	delay      time.Duration
+	client     *http.Client
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	byKey    map[string]string
+	refunds  map[string]fakeRefund
+}
+
+type fakePayment struct {
+	amount   domain.Money
+	captured domain.Money
+	refunded domain.Money
+	status   domain.PaymentStatus
+}
+
+// fakeRefund is a refund as first requested with an idempotency key
+type fakeRefund struct {
+	reference string
+	amount    domain.Money
+	result    Result
+}
+
+// NewFakeProvider creates a FakeProvider. Delayed confirmations are posted to
+// webhookURL after delay; with an empty URL they are never sent.
+func NewFakeProvider(secret, webhookURL string, delay time.Duration) *FakeProvider {
+	return &FakeProvider{
+		secret:     []byte(secret),
+		webhookURL: webhookURL,
+		delay:      delay,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		payments:   make(map[string]*fakePayment),
+		byKey:      make(map[string]string),
+		refunds:    make(map[string]fakeRefund),
+	}
+}
+
+// Name identifies the provider
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// Authorize simulates an authorization based on the payment method
+func (p *FakeProvider) Authorize(req AuthorizeRequest) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Replaying an idempotency key returns the original outcome
+	if ref, ok := p.byKey[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+		return &Result{Reference: ref, Status: p.payments[ref].status}, nil
+	}
+
+	ref := "fake_" + randomID()
+	payment := &fakePayment{amount: req.Amount}
+	result := &Result{Reference: ref}
+
+	switch req.PaymentMethod {
+	case FakeMethodDecline:
+		payment.status = domain.PaymentStatusDeclined
+		result.Message = "card declined"
+	case FakeMethodDelayed:
+		payment.status = domain.PaymentStatusPending
+		result.Message = "awaiting confirmation"
+		time.AfterFunc(p.delay, func() { p.confirm(ref) })
+	case FakeMethodSuccess, "":
+		payment.status = domain.PaymentStatusAuthorized
+	default:
+		return nil, fmt.Errorf("unsupported payment method %q", req.PaymentMethod)
+	}
+
+	p.payments[ref] = payment
+	if req.IdempotencyKey != "" {
+		p.byKey[req.IdempotencyKey] = ref
+	}
+
+	result.Status = payment.status
+	return result, nil
+}
+
+// Capture captures an authorized payment
+func (p *FakeProvider) Capture(reference string, amount domain.Money) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	if payment.status != domain.PaymentStatusAuthorized {
+		return nil, fmt.Errorf("cannot capture payment in status %s", payment.status)
+	}
+	if amount.Cmp(payment.amount) > 0 {
+		return nil, fmt.Errorf("capture amount exceeds authorized amount")
+	}
+
+	payment.captured = amount
+	payment.status = domain.PaymentStatusCaptured
+	return &Result{Reference: reference, Status: payment.status}, nil
+}
+
+// Void cancels an authorized payment
+func (p *FakeProvider) Void(reference string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	if payment.status != domain.PaymentStatusAuthorized {
+		return nil, fmt.Errorf("cannot void payment in status %s", payment.status)
+	}
+
+	payment.status = domain.PaymentStatusVoided
+	return &Result{Reference: reference, Status: payment.status}, nil
+}
+
+// Refund returns part or all of a captured payment
+func (p *FakeProvider) Refund(reference string, amount domain.Money, idempotencyKey string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Replaying an idempotency key returns the original outcome
+	if refund, ok := p.refunds[idempotencyKey]; ok && idempotencyKey != "" {
+		if refund.reference != reference || refund.amount != amount {
+			return nil, fmt.Errorf("idempotency key %q was used for another refund", idempotencyKey)
+		}
+		result := refund.result
+		return &result, nil
+	}
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+	if payment.status != domain.PaymentStatusCaptured && payment.status != domain.PaymentStatusPartiallyRefunded {
+		return nil, fmt.Errorf("cannot refund payment in status %s", payment.status)
+	}
+	refunded := payment.refunded.Add(amount)
+	if refunded.Cmp(payment.captured) > 0 {
+		return nil, fmt.Errorf("refund amount exceeds captured amount")
+	}
+
+	payment.refunded = refunded
+	payment.status = domain.PaymentStatusPartiallyRefunded
+	if payment.refunded.Cmp(payment.captured) >= 0 {
+		payment.status = domain.PaymentStatusRefunded
+	}
+	result := Result{Reference: reference, Status: payment.status}
+	if idempotencyKey != "" {
+		p.refunds[idempotencyKey] = fakeRefund{reference: reference, amount: amount, result: result}
+	}
+	return &result, nil
+}
+
+// ParseWebhook verifies and decodes a webhook sent by the fake provider
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	if !VerifySignature(p.secret, payload, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// confirm authorizes a delayed payment and notifies the webhook endpoint
+func (p *FakeProvider) confirm(reference string) {
+	p.mu.Lock()
+	payment, ok := p.payments[reference]
+	if !ok || payment.status != domain.PaymentStatusPending {
+		p.mu.Unlock()
+		return
+	}
+	payment.status = domain.PaymentStatusAuthorized
+	p.mu.Unlock()
+
+	if p.webhookURL == "" {
+		return
+	}
+
+	event := WebhookEvent{
+		ID:        "evt_" + randomID(),
+		Reference: reference,
+		Status:    domain.PaymentStatusAuthorized,
+		Message:   "confirmed",
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("fake payment: encode webhook: %v", err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("fake payment: build webhook: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(p.secret, payload))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		log.Printf("fake payment: deliver webhook: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// randomID returns a random hex identifier
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,144 @@
+package payment
+
+import (
+	"ecommerce-go/domain"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeProviderAuthorize(t *testing.T) {
+	usd := domain.MustParseMoney("10.00", "USD")
+
+	tests := []struct {
+		method     string
+		wantStatus domain.PaymentStatus
+		wantErr    bool
+	}{
+		{FakeMethodSuccess, domain.PaymentStatusAuthorized, false},
+		{"", domain.PaymentStatusAuthorized, false},
+		{FakeMethodDecline, domain.PaymentStatusDeclined, false},
+		{FakeMethodDelayed, domain.PaymentStatusPending, false},
+		{"cheque", "", true},
+	}
+
+	for _, tt := range tests {
+		provider := NewFakeProvider("secret", "", time.Hour)
+		result, err := provider.Authorize(AuthorizeRequest{IdempotencyKey: "key", Amount: usd, Currency: "USD", PaymentMethod: tt.method})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v, want error %v", tt.method, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if result.Status != tt.wantStatus {
+			t.Errorf("%q: got status %s, want %s", tt.method, result.Status, tt.wantStatus)
+		}
+
+		// Replaying the key returns the first outcome, whatever the method
+		replay, err := provider.Authorize(AuthorizeRequest{IdempotencyKey: "key", Amount: usd, Currency: "USD", PaymentMethod: FakeMethodSuccess})
+		if err != nil {
+			t.Fatalf("%q: replay: unexpected error %v", tt.method, err)
+		}
+		if replay.Reference != result.Reference || replay.Status != result.Status {
+			t.Errorf("%q: replay: got %s %s, want %s %s", tt.method, replay.Reference, replay.Status, result.Reference, result.Status)
+		}
+	}
+}
+
+func TestFakeProviderAuthorizeWithoutKey(t *testing.T) {
+	provider := NewFakeProvider("secret", "", time.Hour)
+	req := AuthorizeRequest{Amount: domain.MustParseMoney("10.00", "USD"), Currency: "USD"}
+
+	first, err := provider.Authorize(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := provider.Authorize(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Reference == second.Reference {
+		t.Errorf("got the same reference %s for two payments without a key", first.Reference)
+	}
+}
+
+func TestFakeProviderRefund(t *testing.T) {
+	usd := func(s string) domain.Money { return domain.MustParseMoney(s, "USD") }
+
+	provider := NewFakeProvider("secret", "", time.Hour)
+	authorized, err := provider.Authorize(AuthorizeRequest{IdempotencyKey: "pay", Amount: usd("10.00"), Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := authorized.Reference
+	if _, err := provider.Capture(ref, usd("10.00")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each step runs against the state the previous steps left behind
+	steps := []struct {
+		name       string
+		reference  string
+		amount     domain.Money
+		key        string
+		wantStatus domain.PaymentStatus
+		wantErr    bool
+	}{
+		{"partial refund", ref, usd("4.00"), "refund-1", domain.PaymentStatusPartiallyRefunded, false},
+		{"replayed key refunds once", ref, usd("4.00"), "refund-1", domain.PaymentStatusPartiallyRefunded, false},
+		{"key reused for another amount", ref, usd("5.00"), "refund-1", "", true},
+		{"more than remains", ref, usd("6.01"), "refund-2", "", true},
+		{"unknown payment", "fake_missing", usd("1.00"), "refund-3", "", true},
+		{"rest of the payment", ref, usd("6.00"), "refund-4", domain.PaymentStatusRefunded, false},
+		{"replay after full refund", ref, usd("6.00"), "refund-4", domain.PaymentStatusRefunded, false},
+		{"nothing left", ref, usd("0.01"), "refund-5", "", true},
+	}
+
+	for _, tt := range steps {
+		result, err := provider.Refund(tt.reference, tt.amount, tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && result.Status != tt.wantStatus {
+			t.Errorf("%s: got status %s, want %s", tt.name, result.Status, tt.wantStatus)
+		}
+	}
+
+	if got := provider.payments[ref].refunded; got != usd("10.00") {
+		t.Errorf("refunded: got %s, want 10.00", got)
+	}
+}
+
+func TestFakeProviderParseWebhook(t *testing.T) {
+	provider := NewFakeProvider("secret", "", time.Hour)
+	payload, err := json.Marshal(WebhookEvent{ID: "evt_1", Reference: "fake_1", Status: domain.PaymentStatusAuthorized})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+		wantErr   error
+	}{
+		{"signed", payload, Sign([]byte("secret"), payload), nil},
+		{"signed with another secret", payload, Sign([]byte("other"), payload), ErrInvalidSignature},
+		{"unsigned", payload, "", ErrInvalidSignature},
+		{"payload changed after signing", append([]byte(" "), payload...), Sign([]byte("secret"), payload), ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		event, err := provider.ParseWebhook(tt.payload, tt.signature)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (event.ID != "evt_1" || event.Reference != "fake_1" || event.Status != domain.PaymentStatusAuthorized) {
+			t.Errorf("%s: got event %+v", tt.name, event)
+		}
+	}
+}
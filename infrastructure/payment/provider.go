@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"ecommerce-go/domain"
+	"encoding/hex"
+	"errors"
+)
+
+// SignatureHeader is the request header carrying the webhook signature
+const SignatureHeader = "X-Payment-Signature"
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownPayment   = errors.New("unknown payment reference")
+)
+
+// Provider is implemented by every payment gateway
+type Provider interface {
+	// Name identifies the provider; it is stored with each payment
+	Name() string
+	Authorize(req AuthorizeRequest) (*Result, error)
+	Capture(reference string, amount domain.Money) (*Result, error)
+	Void(reference string) (*Result, error)
+	// Refund refunds amount of a captured payment. Replaying an idempotency
+	// key returns the original outcome without refunding again.
+	Refund(reference string, amount domain.Money, idempotencyKey string) (*Result, error)
+	// ParseWebhook verifies the signature of an incoming webhook and decodes it
+	ParseWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+type AuthorizeRequest struct {
+	IdempotencyKey string
+	Amount         domain.Money
+	Currency       string
+	PaymentMethod  string
+}
+
+// Result is the outcome of a provider call. A declined payment is reported
+// through Status rather than as an error.
+type Result struct {
+	Reference string
+	Status    domain.PaymentStatus
+	Message   string
+}
+
+// WebhookEvent is an asynchronous status update sent by the provider
+type WebhookEvent struct {
+	ID        string               `json:"id"`
+	Reference string               `json:"reference"`
+	Status    domain.PaymentStatus `json:"status"`
+	Message   string               `json:"message"`
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of payload
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks signature against the HMAC-SHA256 of payload
+func VerifySignature(secret, payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
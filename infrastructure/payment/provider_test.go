@@ -0,0 +1,30 @@
+package payment
+
+import "testing"
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("secret")
+	payload := []byte(`{"id":"evt_1"}`)
+	signature := Sign(secret, payload)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		payload   []byte
+		signature string
+		want      bool
+	}{
+		{"valid", secret, payload, signature, true},
+		{"wrong secret", []byte("other"), payload, signature, false},
+		{"tampered payload", secret, []byte(`{"id":"evt_2"}`), signature, false},
+		{"truncated signature", secret, payload, signature[:len(signature)-2], false},
+		{"not hex", secret, payload, "zz" + signature[2:], false},
+		{"empty", secret, payload, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := VerifySignature(tt.secret, tt.payload, tt.signature); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
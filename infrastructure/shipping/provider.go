@@ -0,0 +1,66 @@
+package shipping
+
+import (
+	"ecommerce-go/domain"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var ErrMethodUnavailable = errors.New("shipping method not available")
+
+// RateProvider is implemented by every source of shipping rates
+type RateProvider interface {
+	// Rates returns the shipping options for a parcel sent to destination,
+	// cheapest first, priced in the currency of the parcel's value
+	Rates(destination Destination, parcel Parcel) ([]Option, error)
+}
+
+// ExchangeRates converts rule amounts into the currency of a parcel. It is
+// satisfied by the currency repository.
+type ExchangeRates interface {
+	GetRate(from, to string) (*big.Rat, error)
+}
+
+// Destination is where a parcel is sent
+type Destination struct {
+	Country    string
+	Region     string
+	PostalCode string
+}
+
+// Normalize returns the destination with upper case codes
+func (d Destination) Normalize() Destination {
+	return Destination{
+		Country:    strings.ToUpper(strings.TrimSpace(d.Country)),
+		Region:     strings.ToUpper(strings.TrimSpace(d.Region)),
+		PostalCode: strings.TrimSpace(d.PostalCode),
+	}
+}
+
+// Parcel describes what is shipped. Value is what the customer pays for the
+// goods after discounts and decides free shipping thresholds.
+type Parcel struct {
+	WeightGrams int
+	Value       domain.Money
+}
+
+// Option is one way of shipping a parcel
+type Option struct {
+	Code         string
+	Name         string
+	Price        domain.Money
+	MinDays      int
+	MaxDays      int
+	FreeShipping bool // the price was waived by a free shipping threshold
+}
+
+// FindOption returns the option with the given code
+func FindOption(options []Option, code string) (Option, error) {
+	for _, option := range options {
+		if option.Code == code {
+			return option, nil
+		}
+	}
+	return Option{}, ErrMethodUnavailable
+}
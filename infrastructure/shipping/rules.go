@@ -0,0 +1,204 @@
+package shipping
+
+import (
+	"ecommerce-go/domain"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Zone groups destinations that share rates. Entries are country codes,
+// "CC-RR" for a single region of a country, or "*" for anywhere.
+type Zone struct {
+	Code         string   `json:"code"`
+	Destinations []string `json:"destinations"`
+}
+
+// Method is a shipping service offered in some zones. Its price is taken
+// from the first weight band the parcel fits in; heavier parcels cannot use
+// the method. Parcels worth at least FreeOver ship for free.
+type Method struct {
+	Code     string       `json:"code"`
+	Name     string       `json:"name"`
+	Zones    []string     `json:"zones"`
+	Currency string       `json:"currency"`
+	Bands    []WeightBand `json:"bands"`
+	FreeOver string       `json:"free_over"`
+	MinDays  int          `json:"min_days"`
+	MaxDays  int          `json:"max_days"`
+}
+
+// WeightBand prices parcels up to MaxWeightGrams
+type WeightBand struct {
+	MaxWeightGrams int    `json:"max_weight_grams"`
+	Price          string `json:"price"`
+}
+
+// Rules is the layout of the shipping rates file
+type Rules struct {
+	Zones   []Zone   `json:"zones"`
+	Methods []Method `json:"methods"`
+}
+
+// RuleProvider prices shipping from a fixed set of zones and methods. A
+// destination belongs to the first zone that lists it.
+type RuleProvider struct {
+	zones   []Zone
+	methods []*method
+	rates   ExchangeRates
+}
+
+type method struct {
+	Method
+	zones    map[string]bool
+	bands    []band
+	freeOver *domain.Money
+}
+
+type band struct {
+	maxWeight int
+	price     domain.Money
+}
+
+// NewRuleProvider creates a RuleProvider, converting rule amounts into
+// parcel currencies through rates
+func NewRuleProvider(rules Rules, rates ExchangeRates) (*RuleProvider, error) {
+	p := &RuleProvider{rates: rates}
+
+	zones := make(map[string]bool, len(rules.Zones))
+	for _, zone := range rules.Zones {
+		if zone.Code == "" || zones[zone.Code] {
+			return nil, fmt.Errorf("invalid or duplicate zone %q", zone.Code)
+		}
+		zones[zone.Code] = true
+
+		normalized := Zone{Code: zone.Code}
+		for _, destination := range zone.Destinations {
+			normalized.Destinations = append(normalized.Destinations, strings.ToUpper(strings.TrimSpace(destination)))
+		}
+		p.zones = append(p.zones, normalized)
+	}
+
+	codes := make(map[string]bool, len(rules.Methods))
+	for _, def := range rules.Methods {
+		if def.Code == "" || codes[def.Code] {
+			return nil, fmt.Errorf("invalid or duplicate shipping method %q", def.Code)
+		}
+		codes[def.Code] = true
+
+		m := &method{Method: def, zones: make(map[string]bool)}
+		for _, zone := range def.Zones {
+			if !zones[zone] {
+				return nil, fmt.Errorf("shipping method %s: unknown zone %q", def.Code, zone)
+			}
+			m.zones[zone] = true
+		}
+
+		if len(def.Bands) == 0 {
+			return nil, fmt.Errorf("shipping method %s: no weight bands", def.Code)
+		}
+		for _, b := range def.Bands {
+			price, err := domain.ParseMoney(b.Price, def.Currency)
+			if err != nil || price.IsNegative() || b.MaxWeightGrams <= 0 {
+				return nil, fmt.Errorf("shipping method %s: invalid band %d/%q", def.Code, b.MaxWeightGrams, b.Price)
+			}
+			m.bands = append(m.bands, band{maxWeight: b.MaxWeightGrams, price: price})
+		}
+		sort.Slice(m.bands, func(i, j int) bool { return m.bands[i].maxWeight < m.bands[j].maxWeight })
+
+		if def.FreeOver != "" {
+			threshold, err := domain.ParseMoney(def.FreeOver, def.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("shipping method %s: %w", def.Code, err)
+			}
+			m.freeOver = &threshold
+		}
+
+		p.methods = append(p.methods, m)
+	}
+
+	return p, nil
+}
+
+// LoadRules reads shipping rules from a JSON file
+func LoadRules(path string) (Rules, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Rules{}, fmt.Errorf("could not open shipping rates: %w", err)
+	}
+	defer file.Close()
+
+	var rules Rules
+	if err := json.NewDecoder(file).Decode(&rules); err != nil {
+		return Rules{}, fmt.Errorf("could not parse shipping rates: %w", err)
+	}
+	return rules, nil
+}
+
+// Rates returns the methods serving the destination's zone that can carry
+// the parcel
+func (p *RuleProvider) Rates(destination Destination, parcel Parcel) ([]Option, error) {
+	zone, ok := p.zoneOf(destination.Normalize())
+	if !ok {
+		return []Option{}, nil
+	}
+
+	currency := parcel.Value.Currency
+	options := make([]Option, 0)
+	for _, m := range p.methods {
+		if !m.zones[zone] {
+			continue
+		}
+
+		price, ok := m.price(parcel.WeightGrams)
+		if !ok {
+			continue
+		}
+
+		rate, err := p.rates.GetRate(m.Currency, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		option := Option{
+			Code:    m.Code,
+			Name:    m.Name,
+			Price:   price.Convert(currency, rate),
+			MinDays: m.MinDays,
+			MaxDays: m.MaxDays,
+		}
+		if m.freeOver != nil && parcel.Value.Cmp(m.freeOver.Convert(currency, rate)) >= 0 {
+			option.Price = domain.NewMoney(0, currency)
+			option.FreeShipping = true
+		}
+		options = append(options, option)
+	}
+
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Price.Cmp(options[j].Price) < 0 })
+	return options, nil
+}
+
+// zoneOf returns the code of the first zone listing the destination
+func (p *RuleProvider) zoneOf(destination Destination) (string, bool) {
+	region := destination.Country + "-" + destination.Region
+	for _, zone := range p.zones {
+		for _, entry := range zone.Destinations {
+			if entry == "*" || entry == destination.Country || (destination.Region != "" && entry == region) {
+				return zone.Code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// price returns the price of the lightest band the weight fits in
+func (m *method) price(weight int) (domain.Money, bool) {
+	for _, b := range m.bands {
+		if weight <= b.maxWeight {
+			return b.price, true
+		}
+	}
+	return domain.Money{}, false
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrBlobNotFound = errors.New("blob not found")
+	ErrInvalidKey   = errors.New("invalid blob key")
+)
+
+// BlobStore keeps uploaded files under slash separated keys such as
+// "products/12/3f9a/original.jpg"
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	// Get opens a blob and returns its content type; the caller closes it
+	Get(key string) (io.ReadCloser, string, error)
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(key string) error
+}
+
+// validKey reports whether key is a relative path without empty, "." or
+// ".." segments, so it cannot escape the store
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package tax
+
+import (
+	"ecommerce-go/domain"
+	"math/big"
+	"strings"
+)
+
+// ClassStandard is the tax class of products without a specific one
+const ClassStandard = "standard"
+
+// Calculator is implemented by every tax engine
+type Calculator interface {
+	// Calculate taxes lines sold into location. Line amounts are what the
+	// customer pays for the line after discounts.
+	Calculate(location Location, lines []Line) (*Result, error)
+}
+
+// Location is the jurisdiction a sale is taxed in
+type Location struct {
+	Country string
+	Region  string
+}
+
+// Normalize returns the location with upper case codes
+func (l Location) Normalize() Location {
+	return Location{
+		Country: strings.ToUpper(strings.TrimSpace(l.Country)),
+		Region:  strings.ToUpper(strings.TrimSpace(l.Region)),
+	}
+}
+
+// Line is one taxable amount
+type Line struct {
+	ProductID int64
+	TaxClass  string
+	Amount    domain.Money
+}
+
+// LineTax is the tax on one line. Rate is a percentage.
+type LineTax struct {
+	ProductID int64
+	Rate      *big.Rat
+	Amount    domain.Money
+}
+
+// Result is the tax on a set of lines. When PricesIncludeTax is set the
+// line amounts already contain the tax; otherwise it is added on top.
+type Result struct {
+	Lines            []LineTax
+	Total            domain.Money
+	PricesIncludeTax bool
+}
+
+// FormatRate formats a percentage rate for display and storage, e.g. "8.875"
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(4)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// CartLines builds the taxable lines of cart items. Each line is reduced by
+// its own discount from lineDiscounts (keyed by product ID) and by its share
+// of orderDiscount, which is spread over the lines by what is left of them.
+func CartLines(items []domain.CartItem, lineDiscounts map[int64]domain.Money, orderDiscount domain.Money) []Line {
+	lines := make([]Line, len(items))
+	for i, item := range items {
+		lines[i] = Line{
+			ProductID: item.ProductID,
+			TaxClass:  item.TaxClass,
+			Amount:    item.Price.Mul(int64(item.Quantity)).Sub(lineDiscounts[item.ProductID]),
+		}
+	}
+
+	amounts := make([]domain.Money, len(lines))
+	for i := range lines {
+		amounts[i] = lines[i].Amount
+	}
+	for i, share := range orderDiscount.Allocate(amounts) {
+		lines[i].Amount = lines[i].Amount.Sub(share)
+	}
+	return lines
+}
@@ -0,0 +1,132 @@
+package tax
+
+import (
+	"ecommerce-go/domain"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// Jurisdiction holds the rates of a country, or of one region of it when
+// Region is set. Rates are percentages by tax class.
+type Jurisdiction struct {
+	Country          string            `json:"country"`
+	Region           string            `json:"region"`
+	PricesIncludeTax bool              `json:"prices_include_tax"`
+	Rates            map[string]string `json:"rates"`
+}
+
+// TableCalculator looks rates up in a fixed table. A region without its own
+// entry uses its country's entry, and a country without any entry is not
+// taxed. A tax class missing from a jurisdiction is taxed at its standard
+// rate.
+type TableCalculator struct {
+	jurisdictions map[Location]*jurisdiction
+}
+
+type jurisdiction struct {
+	pricesIncludeTax bool
+	rates            map[string]*big.Rat
+}
+
+// NewTableCalculator creates a TableCalculator from a list of jurisdictions
+func NewTableCalculator(jurisdictions []Jurisdiction) (*TableCalculator, error) {
+	c := &TableCalculator{jurisdictions: make(map[Location]*jurisdiction)}
+
+	for _, j := range jurisdictions {
+		location := Location{Country: j.Country, Region: j.Region}.Normalize()
+		if !isCountryCode(location.Country) {
+			return nil, fmt.Errorf("invalid country code %q", j.Country)
+		}
+		if _, ok := c.jurisdictions[location]; ok {
+			return nil, fmt.Errorf("duplicate jurisdiction %s/%s", location.Country, location.Region)
+		}
+
+		entry := &jurisdiction{pricesIncludeTax: j.PricesIncludeTax, rates: make(map[string]*big.Rat)}
+		for class, value := range j.Rates {
+			rate, ok := new(big.Rat).SetString(value)
+			if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(100, 1)) > 0 {
+				return nil, fmt.Errorf("invalid %s rate %q for %s", class, value, location.Country)
+			}
+			entry.rates[strings.ToLower(class)] = rate
+		}
+		c.jurisdictions[location] = entry
+	}
+
+	return c, nil
+}
+
+// LoadTable reads a TableCalculator from a JSON file holding
+// {"jurisdictions": [...]}
+func LoadTable(path string) (*TableCalculator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open tax table: %w", err)
+	}
+	defer file.Close()
+
+	var data struct {
+		Jurisdictions []Jurisdiction `json:"jurisdictions"`
+	}
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return nil, fmt.Errorf("could not parse tax table: %w", err)
+	}
+
+	return NewTableCalculator(data.Jurisdictions)
+}
+
+// Calculate taxes each line at the rate of its class in location
+func (c *TableCalculator) Calculate(location Location, lines []Line) (*Result, error) {
+	location = location.Normalize()
+
+	entry, ok := c.jurisdictions[location]
+	if !ok {
+		entry = c.jurisdictions[Location{Country: location.Country}]
+	}
+
+	result := &Result{Lines: make([]LineTax, 0, len(lines))}
+	if entry != nil {
+		result.PricesIncludeTax = entry.pricesIncludeTax
+	}
+
+	for _, line := range lines {
+		rate := new(big.Rat)
+		if entry != nil {
+			rate = entry.rate(line.TaxClass)
+		}
+
+		amount := domain.NewMoney(0, line.Amount.Currency)
+		if rate.Sign() > 0 {
+			// Exclusive: amount * rate/100; inclusive: amount * rate/(100+rate)
+			denominator := big.NewRat(100, 1)
+			if result.PricesIncludeTax {
+				denominator.Add(denominator, rate)
+			}
+			share := new(big.Rat).Quo(rate, denominator)
+			amount = line.Amount.MulRat(share.Num().Int64(), share.Denom().Int64())
+		}
+
+		result.Lines = append(result.Lines, LineTax{ProductID: line.ProductID, Rate: rate, Amount: amount})
+		result.Total = result.Total.Add(amount)
+	}
+
+	return result, nil
+}
+
+// rate returns the rate of a tax class, falling back to the standard rate
+func (j *jurisdiction) rate(class string) *big.Rat {
+	if rate, ok := j.rates[strings.ToLower(class)]; ok {
+		return rate
+	}
+	if rate, ok := j.rates[ClassStandard]; ok {
+		return rate
+	}
+	return new(big.Rat)
+}
+
+// isCountryCode reports whether code looks like an ISO 3166-1 alpha-2 code
+func isCountryCode(code string) bool {
+	return len(code) == 2 && code[0] >= 'A' && code[0] <= 'Z' && code[1] >= 'A' && code[1] <= 'Z'
+}
@@ -0,0 +1,166 @@
+package tax
+
+import (
+	"ecommerce-go/domain"
+	"math/big"
+	"testing"
+)
+
+func TestTableCalculatorCalculate(t *testing.T) {
+	calculator, err := NewTableCalculator([]Jurisdiction{
+		{Country: "US", Rates: map[string]string{"standard": "5"}},
+		{Country: "US", Region: "CA", Rates: map[string]string{"standard": "7.25", "food": "0"}},
+		{Country: "US", Region: "NY", Rates: map[string]string{"standard": "8.875"}},
+		{Country: "DE", PricesIncludeTax: true, Rates: map[string]string{"standard": "19", "Reduced": "7"}},
+		{Country: "JP", PricesIncludeTax: true, Rates: map[string]string{"standard": "10"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usd := func(s string) domain.Money { return domain.MustParseMoney(s, "USD") }
+	eur := func(s string) domain.Money { return domain.MustParseMoney(s, "EUR") }
+
+	tests := []struct {
+		name         string
+		location     Location
+		line         Line
+		wantRate     string
+		wantAmount   domain.Money
+		wantIncluded bool
+	}{
+		{"exclusive rounds half up", Location{"US", "CA"}, Line{TaxClass: "standard", Amount: usd("10.00")}, "7.25", usd("0.73"), false},
+		{"exclusive rounds down", Location{"US", "NY"}, Line{TaxClass: "standard", Amount: usd("19.99")}, "8.875", usd("1.77"), false},
+		{"zero rated class", Location{"US", "CA"}, Line{TaxClass: "food", Amount: usd("10.00")}, "0", usd("0.00"), false},
+		{"unknown class uses standard", Location{"US", "CA"}, Line{TaxClass: "toys", Amount: usd("10.00")}, "7.25", usd("0.73"), false},
+		{"empty class uses standard", Location{"US", "CA"}, Line{Amount: usd("10.00")}, "7.25", usd("0.73"), false},
+		{"region falls back to country", Location{"US", "OR"}, Line{TaxClass: "standard", Amount: usd("10.00")}, "5", usd("0.50"), false},
+		{"codes are case insensitive", Location{" us", "ca "}, Line{TaxClass: "standard", Amount: usd("10.00")}, "7.25", usd("0.73"), false},
+		{"untaxed country", Location{"BR", ""}, Line{TaxClass: "standard", Amount: usd("10.00")}, "0", usd("0.00"), false},
+		{"inclusive", Location{"DE", ""}, Line{TaxClass: "standard", Amount: eur("11.90")}, "19", eur("1.90"), true},
+		{"inclusive rounds half up", Location{"DE", ""}, Line{TaxClass: "standard", Amount: eur("9.99")}, "19", eur("1.60"), true},
+		{"class names are case insensitive", Location{"DE", ""}, Line{TaxClass: "REDUCED", Amount: eur("10.70")}, "7", eur("0.70"), true},
+		{"region of a country taxed as a whole", Location{"DE", "BY"}, Line{TaxClass: "standard", Amount: eur("11.90")}, "19", eur("1.90"), true},
+		{"currency without minor units", Location{"JP", ""}, Line{TaxClass: "standard", Amount: domain.NewMoney(1105, "JPY")}, "10", domain.NewMoney(100, "JPY"), true},
+		{"negative line", Location{"US", "CA"}, Line{TaxClass: "standard", Amount: usd("-10.00")}, "7.25", usd("-0.73"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculator.Calculate(tt.location, []Line{tt.line})
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(result.Lines) != 1 {
+				t.Fatalf("got %d lines, want 1", len(result.Lines))
+			}
+			if got := FormatRate(result.Lines[0].Rate); got != tt.wantRate {
+				t.Errorf("rate: got %s, want %s", got, tt.wantRate)
+			}
+			if got := result.Lines[0].Amount; got != tt.wantAmount {
+				t.Errorf("amount: got %s %s, want %s %s", got, got.Currency, tt.wantAmount, tt.wantAmount.Currency)
+			}
+			if result.Total != tt.wantAmount {
+				t.Errorf("total: got %s, want %s", result.Total, tt.wantAmount)
+			}
+			if result.PricesIncludeTax != tt.wantIncluded {
+				t.Errorf("prices include tax: got %v, want %v", result.PricesIncludeTax, tt.wantIncluded)
+			}
+		})
+	}
+}
+
+func TestTableCalculatorRoundsEachLine(t *testing.T) {
+	calculator, err := NewTableCalculator([]Jurisdiction{
+		{Country: "US", Region: "CA", Rates: map[string]string{"standard": "7.25"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.10 at 7.25% is 0.00725, so each line rounds to 0.01 even though the
+	// three together are only 0.02175
+	line := Line{TaxClass: "standard", Amount: domain.MustParseMoney("0.10", "USD")}
+	result, err := calculator.Calculate(Location{"US", "CA"}, []Line{line, line, line})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := domain.NewMoney(3, "USD"); result.Total != want {
+		t.Errorf("total: got %s, want %s", result.Total, want)
+	}
+}
+
+func TestNewTableCalculator(t *testing.T) {
+	tests := []struct {
+		name          string
+		jurisdictions []Jurisdiction
+		wantErr       bool
+	}{
+		{"valid", []Jurisdiction{{Country: "us", Region: "ca", Rates: map[string]string{"standard": "7.25"}}}, false},
+		{"whole percentage", []Jurisdiction{{Country: "GB", Rates: map[string]string{"standard": "100"}}}, false},
+		{"three letter country", []Jurisdiction{{Country: "USA", Rates: map[string]string{"standard": "5"}}}, true},
+		{"duplicate", []Jurisdiction{{Country: "US", Region: "CA"}, {Country: "us", Region: "ca"}}, true},
+		{"over 100 percent", []Jurisdiction{{Country: "US", Rates: map[string]string{"standard": "100.01"}}}, true},
+		{"negative rate", []Jurisdiction{{Country: "US", Rates: map[string]string{"standard": "-1"}}}, true},
+		{"not a number", []Jurisdiction{{Country: "US", Rates: map[string]string{"standard": "seven"}}}, true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewTableCalculator(tt.jurisdictions)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCartLines(t *testing.T) {
+	usd := func(s string) domain.Money { return domain.MustParseMoney(s, "USD") }
+	items := []domain.CartItem{
+		{ProductID: 1, Quantity: 2, Price: usd("10.00"), TaxClass: "standard"},
+		{ProductID: 2, Quantity: 1, Price: usd("10.00"), TaxClass: "food"},
+		{ProductID: 3, Quantity: 1, Price: usd("10.00")},
+	}
+
+	tests := []struct {
+		name          string
+		lineDiscounts map[int64]domain.Money
+		orderDiscount domain.Money
+		want          []domain.Money
+	}{
+		{"no discounts", nil, usd("0"), []domain.Money{usd("20.00"), usd("10.00"), usd("10.00")}},
+		{"line discount", map[int64]domain.Money{1: usd("5.00")}, usd("0"), []domain.Money{usd("15.00"), usd("10.00"), usd("10.00")}},
+		{"order discount by line amount", nil, usd("4.00"), []domain.Money{usd("18.00"), usd("9.00"), usd("9.00")}},
+		{"order discount remainder on last line", nil, usd("0.10"), []domain.Money{usd("19.95"), usd("9.97"), usd("9.98")}},
+		{"order discount after line discounts", map[int64]domain.Money{2: usd("10.00")}, usd("0.03"), []domain.Money{usd("19.98"), usd("0.00"), usd("9.99")}},
+	}
+
+	for _, tt := range tests {
+		lines := CartLines(items, tt.lineDiscounts, tt.orderDiscount)
+		for i, line := range lines {
+			if line.Amount != tt.want[i] {
+				t.Errorf("%s: line %d: got %s, want %s", tt.name, i, line.Amount, tt.want[i])
+			}
+			if line.ProductID != items[i].ProductID || line.TaxClass != items[i].TaxClass {
+				t.Errorf("%s: line %d: got product %d class %q", tt.name, i, line.ProductID, line.TaxClass)
+			}
+		}
+	}
+}
+
+func TestFormatRate(t *testing.T) {
+	tests := []struct {
+		rate *big.Rat
+		want string
+	}{
+		{big.NewRat(8875, 1000), "8.875"},
+		{big.NewRat(7, 1), "7"},
+		{big.NewRat(55, 10), "5.5"},
+		{big.NewRat(0, 1), "0"},
+		{big.NewRat(1, 3), "0.3333"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatRate(tt.rate); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.rate, got, tt.want)
+		}
+	}
+}
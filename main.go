@@ -1,13 +1,37 @@
 package main
 
 import (
+	applicationAddress "ecommerce-go/application/address"
 	applicationCart "ecommerce-go/application/cart"
+	applicationCatalog "ecommerce-go/application/catalog"
+	applicationCoupon "ecommerce-go/application/coupon"
+	applicationFeed "ecommerce-go/application/feed"
+	applicationLocale "ecommerce-go/application/locale"
+	applicationMedia "ecommerce-go/application/media"
+	applicationOrder "ecommerce-go/application/order"
+	applicationPayment "ecommerce-go/application/payment"
+	applicationPricing "ecommerce-go/application/pricing"
 	applicationProduct "ecommerce-go/application/product"
+	applicationPromotion "ecommerce-go/application/promotion"
+	applicationRecommendation "ecommerce-go/application/recommendation"
+	applicationReturns "ecommerce-go/application/returns"
+	applicationReview "ecommerce-go/application/review"
+	applicationStaff "ecommerce-go/application/staff"
 	applicationUser "ecommerce-go/application/user"
+	applicationWishlist "ecommerce-go/application/wishlist"
 	"ecommerce-go/config"
+	"ecommerce-go/domain"
 	infrastructure "ecommerce-go/infrastructure/mysql"
+	"ecommerce-go/infrastructure/notification"
+	"ecommerce-go/infrastructure/payment"
+	"ecommerce-go/infrastructure/shipping"
+	"ecommerce-go/infrastructure/storage"
+	"ecommerce-go/infrastructure/tax"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -32,30 +56,343 @@ func main() {
 	productRepo := infrastructure.NewProductRepository(dbRepo)
 	userRepo := infrastructure.NewUserRepository(dbRepo)
 	cartRepo := infrastructure.NewCartRepository(dbRepo)
+	orderRepo := infrastructure.NewOrderRepository(dbRepo)
+	paymentRepo := infrastructure.NewPaymentRepository(dbRepo)
+	returnRepo := infrastructure.NewReturnRepository(dbRepo)
+	currencyRepo := infrastructure.NewCurrencyRepository(dbRepo)
+	couponRepo := infrastructure.NewCouponRepository(dbRepo)
+	promotionRepo := infrastructure.NewPromotionRepository(dbRepo)
+	addressRepo := infrastructure.NewAddressRepository(dbRepo)
+	wishlistRepo := infrastructure.NewWishlistRepository(dbRepo)
+	reviewRepo := infrastructure.NewReviewRepository(dbRepo)
+	productImageRepo := infrastructure.NewProductImageRepository(dbRepo)
+	priceScheduleRepo := infrastructure.NewPriceScheduleRepository(dbRepo)
+	attributeRepo := infrastructure.NewAttributeRepository(dbRepo)
+	translationRepo := infrastructure.NewTranslationRepository(dbRepo)
+	recommendationRepo := infrastructure.NewRecommendationRepository(dbRepo)
+
+	currencies := applicationPricing.Currencies{
+		Default:   conf.Currency.Default,
+		Supported: conf.Currency.Supported,
+	}
+	if conf.Currency.RatesFile != "" {
+		rates, err := config.LoadExchangeRates(conf.Currency.RatesFile)
+		if err != nil {
+			log.Fatalf("Error loading exchange rates: %v", err)
+		}
+		if err := currencyRepo.SaveRates(rates); err != nil {
+			log.Fatalf("Error saving exchange rates: %v", err)
+		}
+	}
+
+	var locales applicationLocale.Locales
+	locales.Default, err = domain.NormalizeLocale(conf.Locale.Default)
+	if err != nil {
+		log.Fatalf("Invalid default locale %q: %v", conf.Locale.Default, err)
+	}
+	for _, tag := range conf.Locale.Supported {
+		supported, err := domain.NormalizeLocale(tag)
+		if err != nil {
+			log.Fatalf("Invalid locale %q: %v", tag, err)
+		}
+		locales.Supported = append(locales.Supported, supported)
+	}
+
+	taxTable, err := tax.LoadTable(conf.Tax.RatesFile)
+	if err != nil {
+		log.Fatalf("Error loading tax rates: %v", err)
+	}
+	taxes := applicationPricing.Taxes{
+		Calculator: taxTable,
+		Default:    tax.Location{Country: conf.Tax.DefaultCountry, Region: conf.Tax.DefaultRegion},
+	}
+
+	shippingRules, err := shipping.LoadRules(conf.Shipping.RatesFile)
+	if err != nil {
+		log.Fatalf("Error loading shipping rates: %v", err)
+	}
+	shippingRates, err := shipping.NewRuleProvider(shippingRules, currencyRepo)
+	if err != nil {
+		log.Fatalf("Error loading shipping rates: %v", err)
+	}
+	delivery := applicationPricing.Shipping{
+		Provider:  shippingRates,
+		Addresses: addressRepo,
+	}
+
+	mergeStrategy := domain.CartMergeStrategy(conf.Cart.MergeStrategy)
+	if !mergeStrategy.IsValid() {
+		log.Fatalf("Unsupported cart merge strategy: %q", conf.Cart.MergeStrategy)
+	}
+	guestCarts := applicationCart.GuestCarts{
+		Secret:       []byte(conf.Cart.GuestSecret),
+		MaxAge:       time.Duration(conf.Cart.GuestCartDays) * 24 * time.Hour,
+		Strategy:     mergeStrategy,
+		Repo:         cartRepo,
+		ProductRepo:  productRepo,
+		CurrencyRepo: currencyRepo,
+	}
+
+	catalog := applicationCatalog.Catalog{
+		Repo:       productRepo,
+		Currencies: currencies,
+	}
+
+	recommender := applicationRecommendation.Recommender{
+		Repo:     recommendationRepo,
+		Limit:    conf.Recommendations.Limit,
+		MinScore: conf.Recommendations.MinScore,
+	}
+
+	// Maintenance commands run instead of the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "repair-carts":
+			repairCarts(os.Args[2:], applicationCart.CartRepair{
+				Repo:         cartRepo,
+				ProductRepo:  productRepo,
+				CurrencyRepo: currencyRepo,
+				Strategy:     mergeStrategy,
+			})
+		case "import-products":
+			importProducts(os.Args[2:], catalog)
+		case "export-products":
+			exportProducts(os.Args[2:], catalog)
+		case "generate-slugs":
+			generateSlugs(productRepo)
+		case "compute-recommendations":
+			computeRecommendations(recommender)
+		default:
+			log.Fatalf("Unknown command: %q", os.Args[1])
+		}
+		return
+	}
+
+	requireSecret("guest cart secret", conf.Cart.GuestSecret)
+
+	var notifier notification.Notifier
+	switch conf.Notify.Provider {
+	case "log", "":
+		notifier = notification.LogNotifier{}
+	case "webhook":
+		if conf.Notify.WebhookURL == "" {
+			log.Fatal("The notifications webhook URL must be configured")
+		}
+		requireSecret("notifications webhook secret", conf.Notify.WebhookSecret)
+		notifier = notification.NewWebhookNotifier(conf.Notify.WebhookURL, conf.Notify.WebhookSecret)
+	default:
+		log.Fatalf("Unsupported notification provider: %q", conf.Notify.Provider)
+	}
+
+	if conf.Cart.ExpiryIntervalMinutes > 0 {
+		cartExpiry := applicationCart.CartExpiry{
+			Repo:         cartRepo,
+			Notifier:     notifier,
+			GuestTTL:     guestCarts.MaxAge,
+			AbandonAfter: time.Duration(conf.Cart.AbandonAfterHours) * time.Hour,
+		}
+		cartExpiry.Start(time.Duration(conf.Cart.ExpiryIntervalMinutes) * time.Minute)
+	}
+
+	if conf.Payment.Provider != "fake" {
+		log.Fatalf("Unsupported payment provider: %q", conf.Payment.Provider)
+	}
+	requireSecret("payment webhook secret", conf.Payment.WebhookSecret)
+	paymentProvider := payment.NewFakeProvider(
+		conf.Payment.WebhookSecret,
+		conf.Payment.WebhookURL,
+		time.Duration(conf.Payment.ConfirmDelaySeconds)*time.Second,
+	)
+
+	var blobStore storage.BlobStore
+	switch conf.Media.Provider {
+	case "local", "":
+		blobStore, err = storage.NewLocalStore(conf.Media.LocalDir)
+		if err != nil {
+			log.Fatalf("Error opening media directory: %v", err)
+		}
+	case "s3":
+		blobStore = storage.NewS3Store(storage.S3Config{
+			Endpoint:  conf.Media.S3.Endpoint,
+			Region:    conf.Media.S3.Region,
+			Bucket:    conf.Media.S3.Bucket,
+			AccessKey: conf.Media.S3.AccessKey,
+			SecretKey: conf.Media.S3.SecretKey,
+		})
+	default:
+		log.Fatalf("Unsupported media provider: %q", conf.Media.Provider)
+	}
+	if conf.Media.BaseURL == "" || conf.Media.MaxUploadMB <= 0 {
+		log.Fatal("Media base URL and upload limit must be configured")
+	}
+	productMedia := applicationMedia.Media{
+		Repo:           productImageRepo,
+		Store:          blobStore,
+		BaseURL:        conf.Media.BaseURL,
+		MaxUploadBytes: int64(conf.Media.MaxUploadMB) << 20,
+		ThumbnailSizes: conf.Media.ThumbnailSizes,
+	}
+
+	if conf.Products.PurgeAfterDays > 0 && conf.Products.PurgeIntervalMinutes > 0 {
+		productPurge := applicationProduct.ProductPurge{
+			Repo:      productRepo,
+			Media:     productMedia,
+			Retention: time.Duration(conf.Products.PurgeAfterDays) * 24 * time.Hour,
+		}
+		productPurge.Start(time.Duration(conf.Products.PurgeIntervalMinutes) * time.Minute)
+	}
+
+	if conf.Products.PriceScheduleIntervalMinutes > 0 {
+		priceScheduler := applicationProduct.PriceScheduler{Repo: priceScheduleRepo}
+		priceScheduler.Start(time.Duration(conf.Products.PriceScheduleIntervalMinutes) * time.Minute)
+	}
+
+	if conf.Recommendations.IntervalMinutes > 0 {
+		recommender.Start(time.Duration(conf.Recommendations.IntervalMinutes) * time.Minute)
+	}
+
+	productFeeds := &applicationFeed.Feeds{
+		Repo:    productRepo,
+		Media:   productMedia,
+		SiteURL: conf.Feeds.SiteURL,
+		Title:   conf.Feeds.Title,
+	}
+	if conf.Feeds.IntervalMinutes > 0 {
+		productFeeds.Start(time.Duration(conf.Feeds.IntervalMinutes) * time.Minute)
+	}
 
 	// Product routes
-	http.HandleFunc("/product", applicationProduct.GetProductHandler(productRepo))
-	http.HandleFunc("/products", applicationProduct.GetAllProductsHandler(productRepo))
-	http.HandleFunc("/product/create", applicationProduct.CreateProductHandler(productRepo))
-	http.HandleFunc("/product/update", applicationProduct.UpdateProductHandler(productRepo))
+	http.HandleFunc("/product", applicationProduct.GetProductHandler(productRepo, reviewRepo, productMedia, attributeRepo, translationRepo, currencyRepo, recommender, currencies, locales))
+	http.HandleFunc("/products", applicationProduct.GetAllProductsHandler(productRepo, reviewRepo, productMedia, attributeRepo, translationRepo, currencyRepo, currencies, locales))
+	http.HandleFunc("/products/{slug}", applicationProduct.GetProductBySlugHandler(productRepo, reviewRepo, productMedia, attributeRepo, translationRepo, currencyRepo, recommender, currencies, locales))
+	http.HandleFunc("/product/create", applicationProduct.CreateProductHandler(productRepo, currencies))
+	http.HandleFunc("/product/update", applicationProduct.UpdateProductHandler(productRepo, currencies))
 	http.HandleFunc("/product/delete", applicationProduct.DeleteProductHandler(productRepo))
+	http.HandleFunc("/product/prices", applicationProduct.GetProductPricesHandler(productRepo, currencyRepo))
+	http.HandleFunc("/product/price-history", applicationProduct.GetPriceHistoryHandler(productRepo))
+	http.HandleFunc("/attributes", applicationProduct.GetAttributesHandler(attributeRepo))
+
+	// Media routes
+	http.HandleFunc("/product/images", applicationMedia.GetImagesHandler(productMedia))
+	http.HandleFunc(strings.TrimRight(conf.Media.BaseURL, "/")+"/", applicationMedia.ServeMediaHandler(productMedia))
+
+	// Feed routes
+	http.HandleFunc("/feeds/products.xml", applicationFeed.ProductFeedHandler(productFeeds, applicationFeed.FormatXML))
+	http.HandleFunc("/feeds/products.csv", applicationFeed.ProductFeedHandler(productFeeds, applicationFeed.FormatCSV))
+
+	// Review routes
+	http.HandleFunc("/product/reviews", applicationReview.GetProductReviewsHandler(reviewRepo))
+	http.HandleFunc("/product/review/create", applicationReview.CreateReviewHandler(reviewRepo))
+
+	// Currency routes
+	http.HandleFunc("/exchange-rates", applicationPricing.GetExchangeRatesHandler(currencyRepo))
 
 	// User routes
 	http.HandleFunc("/auth/login", func(w http.ResponseWriter, r *http.Request) {
-		applicationUser.HandleUserLogin(w, r, userRepo)
+		applicationUser.HandleUserLogin(w, r, userRepo, guestCarts)
 	})
 	http.HandleFunc("/auth/signup", func(w http.ResponseWriter, r *http.Request) {
 		applicationUser.HandleUserSignUp(w, r, userRepo)
 	})
 
+	// Address routes
+	http.HandleFunc("/addresses", applicationAddress.GetAddressesHandler(addressRepo))
+	http.HandleFunc("/address/create", applicationAddress.CreateAddressHandler(addressRepo))
+	http.HandleFunc("/address/update", applicationAddress.UpdateAddressHandler(addressRepo))
+	http.HandleFunc("/address/delete", applicationAddress.DeleteAddressHandler(addressRepo))
+	http.HandleFunc("/address/default", applicationAddress.SetDefaultAddressHandler(addressRepo))
+
 	// Cart routes
-	http.HandleFunc("/cart/create", applicationCart.CreateCartHandler(cartRepo))
-	http.HandleFunc("/cart/get", applicationCart.GetCartHandler(cartRepo))
-	http.HandleFunc("/cart/add", applicationCart.AddToCartHandler(cartRepo))
-	http.HandleFunc("/cart/remove", applicationCart.RemoveFromCartHandler(cartRepo))
-	http.HandleFunc("/cart/update", applicationCart.UpdateCartItemHandler(cartRepo))
-	http.HandleFunc("/cart/clear", applicationCart.ClearCartHandler(cartRepo))
+	http.HandleFunc("/cart/create", applicationCart.CreateCartHandler(cartRepo, guestCarts, couponRepo, promotionRepo, productRepo, currencyRepo, currencies, taxes, delivery))
+	http.HandleFunc("/cart/get", applicationCart.GetCartHandler(cartRepo, guestCarts, couponRepo, promotionRepo, productRepo, currencyRepo, recommender, currencies, taxes, delivery))
+	http.HandleFunc("/cart/add", applicationCart.AddToCartHandler(cartRepo, guestCarts, couponRepo, promotionRepo, productRepo, currencyRepo, currencies, taxes, delivery))
+	http.HandleFunc("/cart/remove", applicationCart.RemoveFromCartHandler(cartRepo, guestCarts, couponRepo, promotionRepo, taxes, delivery))
+	http.HandleFunc("/cart/update", applicationCart.UpdateCartItemHandler(cartRepo, guestCarts, couponRepo, promotionRepo, taxes, delivery))
+	http.HandleFunc("/cart/clear", applicationCart.ClearCartHandler(cartRepo, guestCarts))
+	http.HandleFunc("/cart/coupon/apply", applicationCart.ApplyCouponHandler(cartRepo, guestCarts, couponRepo, promotionRepo, taxes, delivery))
+	http.HandleFunc("/cart/coupon/remove", applicationCart.RemoveCouponHandler(cartRepo, guestCarts, couponRepo, promotionRepo, taxes, delivery))
+	http.HandleFunc("/cart/save-for-later", applicationWishlist.SaveForLaterHandler(wishlistRepo, cartRepo, productRepo, currencyRepo))
+
+	// Wishlist routes
+	http.HandleFunc("/wishlists", applicationWishlist.GetWishlistsHandler(wishlistRepo, productRepo, currencyRepo))
+	http.HandleFunc("/wishlist", applicationWishlist.GetWishlistHandler(wishlistRepo, productRepo, currencyRepo))
+	http.HandleFunc("/wishlist/shared", applicationWishlist.GetSharedWishlistHandler(wishlistRepo, productRepo, currencyRepo))
+	http.HandleFunc("/wishlist/price-drops", applicationWishlist.GetPriceDropsHandler(wishlistRepo, productRepo, currencyRepo))
+	http.HandleFunc("/wishlist/create", applicationWishlist.CreateWishlistHandler(wishlistRepo, productRepo, currencyRepo))
+	http.HandleFunc("/wishlist/update", applicationWishlist.UpdateWishlistHandler(wishlistRepo, productRepo, currencyRepo))
+	http.HandleFunc("/wishlist/delete", applicationWishlist.DeleteWishlistHandler(wishlistRepo))
+	http.HandleFunc("/wishlist/item/add", applicationWishlist.AddWishlistItemHandler(wishlistRepo, productRepo, currencyRepo, currencies))
+	http.HandleFunc("/wishlist/item/remove", applicationWishlist.RemoveWishlistItemHandler(wishlistRepo))
+	http.HandleFunc("/wishlist/item/move-to-cart", applicationWishlist.MoveToCartHandler(wishlistRepo, cartRepo, productRepo, currencyRepo, currencies))
+
+	// Order routes
+	http.HandleFunc("/checkout", applicationOrder.CheckoutHandler(orderRepo, taxes, delivery))
+	http.HandleFunc("/order", applicationOrder.GetOrderHandler(orderRepo))
+	http.HandleFunc("/orders", applicationOrder.GetUserOrdersHandler(orderRepo))
+
+	// Payment routes
+	http.HandleFunc("/payment/authorize", applicationPayment.AuthorizePaymentHandler(paymentRepo, orderRepo, paymentProvider))
+	http.HandleFunc("/payment/webhook", applicationPayment.PaymentWebhookHandler(paymentRepo, paymentProvider))
+	http.HandleFunc("/payments", applicationPayment.GetOrderPaymentsHandler(paymentRepo))
+
+	// Return routes
+	http.HandleFunc("/return/create", applicationReturns.CreateReturnHandler(returnRepo))
+	http.HandleFunc("/returns", applicationReturns.GetOrderReturnsHandler(returnRepo))
+
+	// Staff routes
+	staffKey := conf.Staff.APIKey
+	requireSecret("staff API key", staffKey)
+	http.HandleFunc("/staff/order/status", applicationStaff.RequireStaff(staffKey, applicationOrder.UpdateOrderStatusHandler(orderRepo)))
+	http.HandleFunc("/staff/order/history", applicationStaff.RequireStaff(staffKey, applicationOrder.GetOrderHistoryHandler(orderRepo)))
+	http.HandleFunc("/staff/payment/capture", applicationStaff.RequireStaff(staffKey, applicationPayment.CapturePaymentHandler(paymentRepo, paymentProvider)))
+	http.HandleFunc("/staff/payment/void", applicationStaff.RequireStaff(staffKey, applicationPayment.VoidPaymentHandler(paymentRepo, paymentProvider)))
+	http.HandleFunc("/staff/payment/refund", applicationStaff.RequireStaff(staffKey, applicationPayment.RefundPaymentHandler(paymentRepo, paymentProvider)))
+	http.HandleFunc("/staff/product/price/set", applicationStaff.RequireStaff(staffKey, applicationProduct.SetProductPriceHandler(productRepo, currencyRepo, currencies)))
+	http.HandleFunc("/staff/product/price/delete", applicationStaff.RequireStaff(staffKey, applicationProduct.DeleteProductPriceHandler(currencyRepo)))
+	http.HandleFunc("/staff/product/price-schedules", applicationStaff.RequireStaff(staffKey, applicationProduct.GetPriceSchedulesHandler(priceScheduleRepo)))
+	http.HandleFunc("/staff/product/price-schedule/create", applicationStaff.RequireStaff(staffKey, applicationProduct.CreatePriceScheduleHandler(priceScheduleRepo, currencies)))
+	http.HandleFunc("/staff/product/price-schedule/cancel", applicationStaff.RequireStaff(staffKey, applicationProduct.CancelPriceScheduleHandler(priceScheduleRepo)))
+	http.HandleFunc("/staff/product/translations", applicationStaff.RequireStaff(staffKey, applicationProduct.GetTranslationsHandler(translationRepo)))
+	http.HandleFunc("/staff/product/translation", applicationStaff.RequireStaff(staffKey, applicationProduct.SetTranslationHandler(translationRepo, locales)))
+	http.HandleFunc("/staff/product/translation/delete", applicationStaff.RequireStaff(staffKey, applicationProduct.DeleteTranslationHandler(translationRepo, locales)))
+	http.HandleFunc("/staff/attribute/create", applicationStaff.RequireStaff(staffKey, applicationProduct.CreateAttributeHandler(attributeRepo)))
+	http.HandleFunc("/staff/attribute/delete", applicationStaff.RequireStaff(staffKey, applicationProduct.DeleteAttributeHandler(attributeRepo)))
+	http.HandleFunc("/staff/product/attributes", applicationStaff.RequireStaff(staffKey, applicationProduct.SetProductAttributesHandler(attributeRepo)))
+	http.HandleFunc("/staff/products/archived", applicationStaff.RequireStaff(staffKey, applicationProduct.GetArchivedProductsHandler(productRepo)))
+	http.HandleFunc("/staff/product/restore", applicationStaff.RequireStaff(staffKey, applicationProduct.RestoreProductHandler(productRepo)))
+	http.HandleFunc("/staff/products/import", applicationStaff.RequireStaff(staffKey, applicationCatalog.ImportProductsHandler(catalog)))
+	http.HandleFunc("/staff/products/export", applicationStaff.RequireStaff(staffKey, applicationCatalog.ExportProductsHandler(catalog)))
+	http.HandleFunc("/staff/product/image/upload", applicationStaff.RequireStaff(staffKey, applicationMedia.UploadImageHandler(productMedia)))
+	http.HandleFunc("/staff/product/images/reorder", applicationStaff.RequireStaff(staffKey, applicationMedia.ReorderImagesHandler(productMedia)))
+	http.HandleFunc("/staff/product/image/delete", applicationStaff.RequireStaff(staffKey, applicationMedia.DeleteImageHandler(productMedia)))
+	http.HandleFunc("/staff/reviews", applicationStaff.RequireStaff(staffKey, applicationReview.GetReviewsByStatusHandler(reviewRepo)))
+	http.HandleFunc("/staff/review/approve", applicationStaff.RequireStaff(staffKey, applicationReview.ApproveReviewHandler(reviewRepo)))
+	http.HandleFunc("/staff/review/reject", applicationStaff.RequireStaff(staffKey, applicationReview.RejectReviewHandler(reviewRepo)))
+	http.HandleFunc("/staff/returns", applicationStaff.RequireStaff(staffKey, applicationReturns.GetReturnsByStatusHandler(returnRepo)))
+	http.HandleFunc("/staff/return/approve", applicationStaff.RequireStaff(staffKey, applicationReturns.ApproveReturnHandler(returnRepo)))
+	http.HandleFunc("/staff/return/reject", applicationStaff.RequireStaff(staffKey, applicationReturns.RejectReturnHandler(returnRepo)))
+	http.HandleFunc("/staff/return/receive", applicationStaff.RequireStaff(staffKey, applicationReturns.ReceiveReturnHandler(returnRepo)))
+	http.HandleFunc("/staff/return/refund", applicationStaff.RequireStaff(staffKey, applicationReturns.RefundReturnHandler(returnRepo, orderRepo, paymentRepo, paymentProvider)))
+	http.HandleFunc("/staff/coupons", applicationStaff.RequireStaff(staffKey, applicationCoupon.GetCouponsHandler(couponRepo)))
+	http.HandleFunc("/staff/coupon/create", applicationStaff.RequireStaff(staffKey, applicationCoupon.CreateCouponHandler(couponRepo)))
+	http.HandleFunc("/staff/coupon/status", applicationStaff.RequireStaff(staffKey, applicationCoupon.SetCouponActiveHandler(couponRepo)))
+	http.HandleFunc("/staff/promotions", applicationStaff.RequireStaff(staffKey, applicationPromotion.GetPromotionsHandler(promotionRepo)))
+	http.HandleFunc("/staff/promotion/create", applicationStaff.RequireStaff(staffKey, applicationPromotion.CreatePromotionHandler(promotionRepo)))
+	http.HandleFunc("/staff/promotion/status", applicationStaff.RequireStaff(staffKey, applicationPromotion.SetPromotionActiveHandler(promotionRepo)))
+	http.HandleFunc("/staff/exchange-rates/reload", applicationStaff.RequireStaff(staffKey, applicationPricing.ReloadExchangeRatesHandler(currencyRepo, conf.Currency.RatesFile)))
 
 	log.Println("Server running at http://localhost:9000")
 	log.Fatal(http.ListenAndServe(":9000", nil))
 }
+
+// sampleSecret is the placeholder secrets in the sample config are replaced
+// with
+const sampleSecret = "change-me"
+
+// requireSecret stops the server when a secret is unset or still the sample
+// placeholder
+func requireSecret(name, value string) {
+	if value == "" || value == sampleSecret {
+		log.Fatalf("The %s must be configured", name)
+	}
+}